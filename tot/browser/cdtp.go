@@ -12,6 +12,14 @@ https://chromedevtools.github.io/devtools-protocol/tot/Browser/#type-WindowID
 */
 type WindowID int
 
+/*
+PermissionType is a permission that can be granted, such as 'geolocation',
+'notifications', 'clipboardReadWrite' or 'midi'. EXPERIMENTAL
+
+https://chromedevtools.github.io/devtools-protocol/tot/Browser/#type-PermissionType
+*/
+type PermissionType string
+
 /*
 WindowState holds the state of the browser window. EXPERIMENTAL
 
@@ -0,0 +1,82 @@
+package chrome
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	errs "github.com/bdlm/errors"
+)
+
+/*
+BinaryPaths is the list of common Chromium install locations checked by
+FindBinary, in order.
+*/
+var BinaryPaths = []string{
+	"/usr/bin/google-chrome",
+	"/usr/bin/google-chrome-stable",
+	"/usr/bin/chromium",
+	"/usr/bin/chromium-browser",
+	"/usr/lib/chromium/chromium",
+	"/usr/lib/chromium-browser/chromium-browser",
+	"/opt/google/chrome/chrome",
+	"/opt/google/chrome/google-chrome",
+	"/snap/bin/chromium",
+	"/headless-shell/headless-shell",
+}
+
+/*
+BinaryNames is the list of Chromium executable names searched for in $PATH by
+FindBinary, in order.
+*/
+var BinaryNames = []string{
+	"google-chrome",
+	"google-chrome-stable",
+	"chromium",
+	"chromium-browser",
+	"headless_shell",
+	"headless-shell",
+	"chrome",
+}
+
+/*
+FindBinary returns the path to a Chromium executable. The CHROME_PATH
+environment variable is used if it is set, otherwise the paths in BinaryPaths
+are checked followed by the names in BinaryNames in $PATH.
+
+Chrome.Binary uses FindBinary when no binary is specified.
+*/
+func FindBinary() (string, error) {
+	if path := os.Getenv("CHROME_PATH"); "" != path {
+		if !isExecutable(path) {
+			return "", errs.New(0, fmt.Sprintf("CHROME_PATH '%s' is not an executable file", path))
+		}
+		return path, nil
+	}
+	for _, path := range BinaryPaths {
+		if isExecutable(path) {
+			return path, nil
+		}
+	}
+	for _, name := range BinaryNames {
+		if path, err := exec.LookPath(name); nil == err {
+			return path, nil
+		}
+	}
+	return "", errs.New(0, fmt.Sprintf(
+		"no Chromium executable found, set CHROME_PATH or install one of %s",
+		strings.Join(BinaryNames, ", "),
+	))
+}
+
+/*
+isExecutable reports whether path is an executable file.
+*/
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if nil != err || info.IsDir() {
+		return false
+	}
+	return 0 != info.Mode()&0111
+}
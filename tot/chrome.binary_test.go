@@ -0,0 +1,92 @@
+package chrome
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-chrome-binary-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	binary := filepath.Join(dir, "chromium")
+	if err := ioutil.WriteFile(binary, []byte("#!/bin/sh\n"), 0700); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	notExecutable := filepath.Join(dir, "google-chrome")
+	if err := ioutil.WriteFile(notExecutable, []byte("#!/bin/sh\n"), 0600); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+
+	chromePath, path, paths := os.Getenv("CHROME_PATH"), os.Getenv("PATH"), BinaryPaths
+	defer func() {
+		os.Setenv("CHROME_PATH", chromePath)
+		os.Setenv("PATH", path)
+		BinaryPaths = paths
+	}()
+
+	os.Setenv("CHROME_PATH", binary)
+	if found, err := FindBinary(); nil != err || binary != found {
+		t.Errorf("Expected '%s', received '%s' (%v)", binary, found, err)
+	}
+	os.Setenv("CHROME_PATH", notExecutable)
+	if _, err := FindBinary(); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	os.Setenv("CHROME_PATH", "")
+	BinaryPaths = []string{notExecutable, dir}
+	os.Setenv("PATH", dir)
+	if found, err := FindBinary(); nil != err || binary != found {
+		t.Errorf("Expected '%s', received '%s' (%v)", binary, found, err)
+	}
+
+	os.Setenv("PATH", "")
+	if _, err := FindBinary(); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestChromeBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-chrome-binary-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	binary := filepath.Join(dir, "chromium")
+	if err := ioutil.WriteFile(binary, []byte("#!/bin/sh\n"), 0700); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+
+	chromePath, path, paths := os.Getenv("CHROME_PATH"), os.Getenv("PATH"), BinaryPaths
+	defer func() {
+		os.Setenv("CHROME_PATH", chromePath)
+		os.Setenv("PATH", path)
+		BinaryPaths = paths
+	}()
+	BinaryPaths = []string{}
+	os.Setenv("PATH", "")
+
+	os.Setenv("CHROME_PATH", binary)
+	if found := New(&Flags{}, "", "", "", "").Binary(); binary != found {
+		t.Errorf("Expected '%s', received '%s'", binary, found)
+	}
+	if found := New(&Flags{}, "/path/to/chrome", "", "", "").Binary(); "/path/to/chrome" != found {
+		t.Errorf("Expected '/path/to/chrome', received '%s'", found)
+	}
+
+	// An invalid CHROME_PATH falls back to the default binary.
+	os.Setenv("CHROME_PATH", filepath.Join(dir, "missing"))
+	if found := New(&Flags{}, "", "", "", "").Binary(); "/usr/bin/google-chrome" != found {
+		t.Errorf("Expected '/usr/bin/google-chrome', received '%s'", found)
+	}
+
+	os.Setenv("CHROME_PATH", "")
+	if found := New(&Flags{}, "", "", "", "").Binary(); "/usr/bin/google-chrome" != found {
+		t.Errorf("Expected '/usr/bin/google-chrome', received '%s'", found)
+	}
+}
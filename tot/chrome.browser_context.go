@@ -0,0 +1,478 @@
+package chrome
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	errs "github.com/bdlm/errors"
+	"github.com/mkenney/go-chrome/tot/browser"
+	"github.com/mkenney/go-chrome/tot/network"
+	"github.com/mkenney/go-chrome/tot/storage"
+	"github.com/mkenney/go-chrome/tot/target"
+)
+
+/*
+BrowserContextOptions holds optional settings for a browser context.
+*/
+type BrowserContextOptions struct {
+	// Permissions holds the permissions granted to each origin when the
+	// context is created. All other permissions are denied.
+	Permissions map[string][]browser.PermissionType
+
+	// ProxyBypassList is a list of hosts that don't use the proxy, similar to
+	// the --proxy-bypass-list flag.
+	ProxyBypassList string
+
+	// ProxyServer is the proxy server used by the context, similar to the
+	// --proxy-server flag.
+	ProxyServer string
+}
+
+/*
+NewBrowserContext creates an isolated browser context, similar to an incognito
+profile. Tabs created in a browser context share cookies, storage and cache
+with each other but not with tabs in other contexts, so a single Chromium
+process can serve several users:
+
+	tenant, err := browser.NewBrowserContext(ctx, &chrome.BrowserContextOptions{
+		ProxyServer: "http://proxy.internal:3128",
+	})
+	...
+	defer tenant.Dispose(ctx)
+	tab, err := tenant.NewTab(ctx, "https://example.com/")
+
+Proxies and permissions require a Chromium version that supports them,
+options may be nil.
+*/
+func (chrome *Chrome) NewBrowserContext(ctx context.Context, options *BrowserContextOptions) (*BrowserContext, error) {
+	if nil == options {
+		options = &BrowserContextOptions{}
+	}
+	socket, err := chrome.browserSocket()
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "browser connection failed")
+	}
+
+	var result *target.CreateBrowserContextResult
+	resultChan := socket.Target().CreateBrowserContext(&target.CreateBrowserContextParams{
+		ProxyServer:     options.ProxyServer,
+		ProxyBypassList: options.ProxyBypassList,
+	})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "Target.createBrowserContext failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Target.createBrowserContext failed")
+	}
+
+	browserContext := &BrowserContext{
+		chrome: chrome,
+		id:     result.BrowserContextID,
+		mux:    &sync.Mutex{},
+		tabs:   []*Tab{},
+	}
+	for origin, permissions := range options.Permissions {
+		if err := browserContext.GrantPermissions(ctx, origin, permissions...); nil != err {
+			browserContext.Dispose(ctx)
+			return nil, err
+		}
+	}
+	return browserContext, nil
+}
+
+/*
+BrowserContext is an isolated browser context with its own tabs, cookies and
+storage.
+*/
+type BrowserContext struct {
+	// chrome is the browser the context belongs to.
+	chrome *Chrome
+
+	// disposed is set when the context has been disposed.
+	disposed bool
+
+	// id is the browser context ID.
+	id target.BrowserContextID
+
+	// mux protects disposed and tabs.
+	mux *sync.Mutex
+
+	// tabs holds the open tabs in the context.
+	tabs []*Tab
+}
+
+/*
+Cookies returns all cookies in the browser context.
+*/
+func (browserContext *BrowserContext) Cookies(ctx context.Context) ([]*network.Cookie, error) {
+	socket, err := browserContext.chrome.browserSocket()
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "browser connection failed")
+	}
+	var result *storage.GetCookiesResult
+	resultChan := socket.Storage().GetCookies(&storage.GetCookiesParams{
+		BrowserContextID: browserContext.id,
+	})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "Storage.getCookies failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Storage.getCookies failed")
+	}
+	return result.Cookies, nil
+}
+
+/*
+Dispose closes all tabs in the browser context and deletes it, along with its
+cookies and storage.
+*/
+func (browserContext *BrowserContext) Dispose(ctx context.Context) error {
+	browserContext.mux.Lock()
+	if browserContext.disposed {
+		browserContext.mux.Unlock()
+		return nil
+	}
+	browserContext.disposed = true
+	tabs := browserContext.tabs
+	browserContext.tabs = []*Tab{}
+	browserContext.mux.Unlock()
+
+	var closeErr error
+	for _, tab := range tabs {
+		if _, err := tab.Close(); nil != err && nil == closeErr {
+			closeErr = err
+		}
+	}
+
+	socket, err := browserContext.chrome.browserSocket()
+	if nil != err {
+		return errs.Wrap(err, 0, "browser connection failed")
+	}
+	resultChan := socket.Target().DisposeBrowserContext(&target.DisposeBrowserContextParams{
+		BrowserContextID: browserContext.id,
+	})
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Target.disposeBrowserContext failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "Target.disposeBrowserContext failed")
+	}
+	if nil != closeErr {
+		return errs.Wrap(closeErr, 0, "could not close all browser context tabs")
+	}
+	return nil
+}
+
+/*
+GrantPermissions grants permissions to an origin in the browser context and
+denies all others.
+*/
+func (browserContext *BrowserContext) GrantPermissions(
+	ctx context.Context,
+	origin string,
+	permissions ...browser.PermissionType,
+) error {
+	socket, err := browserContext.chrome.browserSocket()
+	if nil != err {
+		return errs.Wrap(err, 0, "browser connection failed")
+	}
+	resultChan := socket.Browser().GrantPermissions(&browser.GrantPermissionsParams{
+		Origin:           origin,
+		Permissions:      permissions,
+		BrowserContextID: browserContext.id,
+	})
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Browser.grantPermissions failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "Browser.grantPermissions failed")
+	}
+	return nil
+}
+
+/*
+ID returns the browser context ID.
+*/
+func (browserContext *BrowserContext) ID() target.BrowserContextID {
+	return browserContext.id
+}
+
+/*
+NewTab opens a tab in the browser context.
+*/
+func (browserContext *BrowserContext) NewTab(ctx context.Context, uri string) (*Tab, error) {
+	if "" == uri {
+		uri = "about:blank"
+	}
+	targetURL, err := url.Parse(uri)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "invalid URL")
+	}
+
+	browserContext.mux.Lock()
+	disposed := browserContext.disposed
+	browserContext.mux.Unlock()
+	if disposed {
+		return nil, errs.New(0, fmt.Sprintf("browser context '%s' has been disposed", browserContext.id))
+	}
+
+	socket, err := browserContext.chrome.browserSocket()
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "browser connection failed")
+	}
+	var result *target.CreateTargetResult
+	resultChan := socket.Target().CreateTarget(&target.CreateTargetParams{
+		URL:              uri,
+		BrowserContextID: browserContext.id,
+	})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "Target.createTarget failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Target.createTarget failed")
+	}
+
+	tab, err := browserContext.chrome.newTab(targetURL, browserContext.chrome.targetData(result.ID, "page", uri))
+	if nil != err {
+		return nil, err
+	}
+	browserContext.addTab(tab)
+	return tab, nil
+}
+
+/*
+ResetPermissions resets the permissions granted in the browser context.
+*/
+func (browserContext *BrowserContext) ResetPermissions(ctx context.Context) error {
+	socket, err := browserContext.chrome.browserSocket()
+	if nil != err {
+		return errs.Wrap(err, 0, "browser connection failed")
+	}
+	resultChan := socket.Browser().ResetPermissions(&browser.ResetPermissionsParams{
+		BrowserContextID: browserContext.id,
+	})
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Browser.resetPermissions failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "Browser.resetPermissions failed")
+	}
+	return nil
+}
+
+/*
+SetCookies sets cookies in the browser context, such as cookies returned by
+Cookies or read with ReadNetscapeCookies or ReadJSONCookies.
+*/
+func (browserContext *BrowserContext) SetCookies(ctx context.Context, cookies []*network.Cookie) error {
+	params := &network.SetCookiesParams{
+		Cookies: make([]*network.SetCookieParams, 0, len(cookies)),
+	}
+	for _, cookie := range cookies {
+		params.Cookies = append(params.Cookies, CookieParams(cookie))
+	}
+	return browserContext.setCookies(ctx, params)
+}
+
+/*
+Tabs returns the open tabs in the browser context, including popups opened by
+them while target discovery is enabled.
+*/
+func (browserContext *BrowserContext) Tabs() []*Tab {
+	browserContext.mux.Lock()
+	defer browserContext.mux.Unlock()
+	tabs := make([]*Tab, len(browserContext.tabs))
+	copy(tabs, browserContext.tabs)
+	return tabs
+}
+
+/*
+addTab adds a tab to the browser context.
+*/
+func (browserContext *BrowserContext) addTab(tab *Tab) {
+	tab.mux.Lock()
+	tab.browserContext = browserContext
+	tab.mux.Unlock()
+
+	browserContext.mux.Lock()
+	defer browserContext.mux.Unlock()
+	browserContext.tabs = append(browserContext.tabs, tab)
+}
+
+/*
+deleteCookies deletes the cookies matching params by setting them again with an
+expiration date in the past, Storage has no command to delete cookies.
+*/
+func (browserContext *BrowserContext) deleteCookies(ctx context.Context, params *network.DeleteCookiesParams) error {
+	var targetURL *url.URL
+	if "" != params.URL {
+		var err error
+		if targetURL, err = url.Parse(params.URL); nil != err {
+			return errs.Wrap(err, 0, "invalid URL")
+		}
+	}
+	cookies, err := browserContext.Cookies(ctx)
+	if nil != err {
+		return err
+	}
+
+	expired := &network.SetCookiesParams{Cookies: []*network.SetCookieParams{}}
+	for _, cookie := range cookies {
+		if params.Name != cookie.Name ||
+			(nil != targetURL && !cookieMatchesURL(cookie, targetURL)) ||
+			("" != params.Domain && strings.TrimPrefix(params.Domain, ".") != strings.TrimPrefix(cookie.Domain, ".")) ||
+			("" != params.Path && params.Path != cookie.Path) {
+			continue
+		}
+		cookieParams := CookieParams(cookie)
+		// Host-only cookies are set with a URL, a domain would set a domain
+		// cookie instead of replacing them.
+		if !strings.HasPrefix(cookie.Domain, ".") {
+			scheme := "http"
+			if cookie.Secure {
+				scheme = "https"
+			}
+			cookieParams.URL = scheme + "://" + cookie.Domain + cookie.Path
+			cookieParams.Domain = ""
+		}
+		cookieParams.Expires = 1
+		expired.Cookies = append(expired.Cookies, cookieParams)
+	}
+	if 0 == len(expired.Cookies) {
+		return nil
+	}
+	return browserContext.setCookies(ctx, expired)
+}
+
+/*
+getCookies returns the cookies in the browser context that would be sent to the
+specified URLs.
+*/
+func (browserContext *BrowserContext) getCookies(ctx context.Context, urls ...string) ([]*network.Cookie, error) {
+	targetURLs := make([]*url.URL, 0, len(urls))
+	for _, uri := range urls {
+		targetURL, err := url.Parse(uri)
+		if nil != err {
+			return nil, errs.Wrap(err, 0, "invalid URL")
+		}
+		targetURLs = append(targetURLs, targetURL)
+	}
+	cookies, err := browserContext.Cookies(ctx)
+	if nil != err {
+		return nil, err
+	}
+
+	matches := []*network.Cookie{}
+	for _, cookie := range cookies {
+		for _, targetURL := range targetURLs {
+			if cookieMatchesURL(cookie, targetURL) {
+				matches = append(matches, cookie)
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+/*
+removeTab removes a closed tab from the browser context.
+*/
+func (browserContext *BrowserContext) removeTab(tab *Tab) {
+	browserContext.mux.Lock()
+	defer browserContext.mux.Unlock()
+	for k, t := range browserContext.tabs {
+		if t == tab {
+			browserContext.tabs = append(browserContext.tabs[:k], browserContext.tabs[k+1:]...)
+			return
+		}
+	}
+}
+
+/*
+setCookies sends a Storage.setCookies command for the browser context and waits
+for the result.
+*/
+func (browserContext *BrowserContext) setCookies(ctx context.Context, params *network.SetCookiesParams) error {
+	socket, err := browserContext.chrome.browserSocket()
+	if nil != err {
+		return errs.Wrap(err, 0, "browser connection failed")
+	}
+	resultChan := socket.Storage().SetCookies(&storage.SetCookiesParams{
+		Cookies:          params.Cookies,
+		BrowserContextID: browserContext.id,
+	})
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Storage.setCookies failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "Storage.setCookies failed")
+	}
+	return nil
+}
+
+/*
+BrowserContext returns the browser context the tab was opened in, or nil for
+the default context.
+*/
+func (tab *Tab) BrowserContext() *BrowserContext {
+	tab.mux.Lock()
+	defer tab.mux.Unlock()
+	return tab.browserContext
+}
+
+/*
+cookieMatchesURL reports whether a cookie would be sent to a URL.
+*/
+func cookieMatchesURL(cookie *network.Cookie, targetURL *url.URL) bool {
+	host := strings.ToLower(targetURL.Hostname())
+	domain := strings.ToLower(cookie.Domain)
+	if strings.HasPrefix(domain, ".") {
+		if host != domain[1:] && !strings.HasSuffix(host, domain) {
+			return false
+		}
+	} else if host != domain {
+		return false
+	}
+
+	path := targetURL.Path
+	if "" == path {
+		path = "/"
+	}
+	if path != cookie.Path && "" != cookie.Path {
+		if !strings.HasPrefix(path, cookie.Path) {
+			return false
+		}
+		if !strings.HasSuffix(cookie.Path, "/") && '/' != path[len(cookie.Path)] {
+			return false
+		}
+	}
+
+	if cookie.Secure && "https" != targetURL.Scheme && "wss" != targetURL.Scheme {
+		return false
+	}
+	return true
+}
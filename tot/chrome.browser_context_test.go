@@ -0,0 +1,161 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mkenney/go-chrome/tot/network"
+	"github.com/mkenney/go-chrome/tot/storage"
+	"github.com/mkenney/go-chrome/tot/target"
+)
+
+func TestChromeNewBrowserContext(t *testing.T) {
+	chrome := New(
+		&Flags{
+			"remote-debugging-port": 0,
+			"port":                  0,
+		},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := chrome.NewBrowserContext(ctx, nil); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestBrowserContextTabs(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestBrowserContextTabs")
+	popup, _ := browser.NewTab("https://TestBrowserContextTabs/popup")
+
+	browserContext := &BrowserContext{
+		id:   target.BrowserContextID("context-id"),
+		mux:  &sync.Mutex{},
+		tabs: []*Tab{},
+	}
+	browserContext.addTab(tab)
+	if browserContext != tab.BrowserContext() {
+		t.Errorf("Expected the tab to be linked to its browser context")
+	}
+
+	tab.addPopup(popup)
+	if browserContext != popup.BrowserContext() {
+		t.Errorf("Expected the popup to inherit the browser context of its opener")
+	}
+	if tabs := browserContext.Tabs(); 2 != len(tabs) {
+		t.Errorf("Expected 2 tabs, received %d", len(tabs))
+	}
+
+	popup.Close()
+	if tabs := browserContext.Tabs(); 1 != len(tabs) || tab != tabs[0] {
+		t.Errorf("Expected only the opener to remain, received %d tabs", len(tabs))
+	}
+
+	browserContext.disposed = true
+	if err := browserContext.Dispose(context.Background()); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if _, err := browserContext.NewTab(context.Background(), "about:blank"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if "context-id" != browserContext.ID() {
+		t.Errorf("Expected 'context-id', received '%s'", browserContext.ID())
+	}
+}
+
+func TestBrowserContextCookies(t *testing.T) {
+	setCookies := make(chan *storage.SetCookiesParams, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if nil != err {
+			t.Errorf("Expected nil, received '%s'", err)
+			return
+		}
+		defer conn.Close()
+		for {
+			command := struct {
+				ID     int             `json:"id"`
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}{}
+			if err := conn.ReadJSON(&command); nil != err {
+				return
+			}
+			result := map[string]interface{}{}
+			switch command.Method {
+			case "Storage.getCookies":
+				params := &storage.GetCookiesParams{}
+				json.Unmarshal(command.Params, params)
+				if "context-id" != params.BrowserContextID {
+					t.Errorf("Expected 'context-id', received '%s'", params.BrowserContextID)
+				}
+				result["cookies"] = []*network.Cookie{
+					{Name: "a", Domain: ".example.com", Path: "/"},
+					{Name: "b", Domain: "example.com", Path: "/"},
+					{Name: "c", Domain: "www.example.com", Path: "/app", Secure: true},
+					{Name: "d", Domain: "www.example.com", Path: "/application"},
+				}
+			case "Storage.setCookies":
+				params := &storage.SetCookiesParams{}
+				json.Unmarshal(command.Params, params)
+				setCookies <- params
+			}
+			conn.WriteJSON(map[string]interface{}{"id": command.ID, "result": result})
+		}
+	}))
+	defer server.Close()
+
+	chrome := New(&Flags{}, "", "", "", "")
+	chrome.browserURL = "ws://" + strings.TrimPrefix(server.URL, "http://") + "/devtools/browser/browser-id"
+	defer func() { chrome.openBrowserSocket().Stop() }()
+	browserContext := &BrowserContext{
+		chrome: chrome,
+		id:     target.BrowserContextID("context-id"),
+		mux:    &sync.Mutex{},
+		tabs:   []*Tab{},
+	}
+
+	jar := NewBrowserContextCookieJar(browserContext, time.Second)
+	u, _ := url.Parse("https://www.example.com/app/page")
+	cookies := jar.Cookies(u)
+	if 2 != len(cookies) || "a" != cookies[0].Name || "c" != cookies[1].Name {
+		t.Errorf("Expected cookies a and c, received %v", cookies)
+	}
+	u.Scheme = "http"
+	if cookies := jar.Cookies(u); 1 != len(cookies) || "a" != cookies[0].Name {
+		t.Errorf("Expected cookie a, received %v", cookies)
+	}
+
+	u.Scheme = "https"
+	jar.SetCookies(u, []*http.Cookie{{Name: "e", Value: "f"}, {Name: "c", MaxAge: -1}})
+	for _, expected := range []*network.SetCookieParams{
+		{Name: "c", URL: "https://www.example.com/app", Path: "/app", Secure: true, Expires: 1},
+		{Name: "e", Value: "f", URL: "https://www.example.com/app/page"},
+	} {
+		params := <-setCookies
+		if "context-id" != params.BrowserContextID {
+			t.Errorf("Expected 'context-id', received '%s'", params.BrowserContextID)
+		}
+		if 1 != len(params.Cookies) || *expected != *params.Cookies[0] {
+			t.Errorf("Expected %v, received %v", expected, params.Cookies)
+		}
+	}
+}
@@ -1,17 +1,19 @@
 package chrome
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	errs "github.com/bdlm/errors"
 	"github.com/bdlm/log"
+	"github.com/mkenney/go-chrome/tot/socket"
 )
 
 /*
@@ -25,11 +27,14 @@ func New(
 	stderr string,
 ) *Chrome {
 	return &Chrome{
-		flags:   flags,
-		binary:  binary,
-		stderr:  stderr,
-		stdout:  stdout,
-		workdir: workdir,
+		flags:     flags,
+		binary:    binary,
+		mux:       &sync.Mutex{},
+		outputMux: &sync.Mutex{},
+		stderr:    stderr,
+		stdout:    stdout,
+		tabs:      newTabRegistry(),
+		workdir:   workdir,
 	}
 }
 
@@ -40,16 +45,82 @@ type Chrome struct {
 	// flags stores CLI arguments for the Chromium binary.
 	flags ChromiumFlags
 
-	// Optional. binary is the path to the Chromium binary. Defaults to
-	// '/usr/bin/google-chrome'.
+	// browser is the browser-level websocket connection. It is opened on
+	// first use.
+	browser *socket.Socket
+
+	// browserURL is the browser-level websocket URL reported by Chromium on
+	// startup.
+	browserURL string
+
+	// Optional. binary is the path to the Chromium binary. Defaults to the
+	// binary found by FindBinary.
 	binary string
 
+	// closing is set when Close has been called, Chromium exiting after that
+	// is expected.
+	closing bool
+
+	// connected is set when the browser was attached with Connect. Close
+	// only detaches from a connected browser.
+	connected bool
+
+	// discovering is set when target discovery has been enabled.
+	discovering bool
+
+	// httpClient is the HTTP client used for the DevTools HTTP endpoints.
+	httpClient *http.Client
+
+	// exitHandlers are called when Chromium exits unexpectedly.
+	exitHandlers []func(err *ExitError)
+
+	// exited is closed when the Chromium process exits.
+	exited chan struct{}
+
+	// limits holds the resource limits applied on launch.
+	limits *LimitOptions
+
+	// mux protects browser, browserURL, closing, discovering, exitHandlers,
+	// exited, httpClient, outputClosed, stderrBuffer, stdoutBuffer and
+	// version.
+	mux *sync.Mutex
+
+	// output holds the output capture settings.
+	output *OutputOptions
+
+	// outputClosed is closed when both output pipes have been read.
+	outputClosed chan struct{}
+
+	// outputMux serializes writes to the output Writer.
+	outputMux *sync.Mutex
+
 	// Optional. port is the port number the developer tools endpoints will
 	// listen on. Defaults to 9222.
 	//port int
 
-	// tabs is a list of the currently open tabs.
-	tabs []*Tab
+	// profile holds the user data directory settings.
+	profile *ProfileOptions
+
+	// profileDir is the temporary profile directory created for the last
+	// launch. It is removed on Close.
+	profileDir string
+
+	// profileFlag is set when the user-data-dir flag was set for a managed
+	// profile. The flag is removed on Close.
+	profileFlag bool
+
+	// Optional. scheme is the scheme of the DevTools HTTP endpoints, 'http' or
+	// 'https'. Defaults to 'http'.
+	scheme string
+
+	// stderrBuffer holds the last lines Chromium wrote to STDERR.
+	stderrBuffer *outputBuffer
+
+	// stdoutBuffer holds the last lines Chromium wrote to STDOUT.
+	stdoutBuffer *outputBuffer
+
+	// tabs holds the currently open tabs.
+	tabs *tabRegistry
 
 	// version contains Chromium version information.
 	version *Version
@@ -101,41 +172,117 @@ func (chrome *Chrome) Flags() ChromiumFlags {
 /*
 Binary implements Chromium.
 
-Default value is '/usr/bin/google-chrome' for use with the mkenney/chromium-headless
-Docker image.
+The binary is located with FindBinary if it isn't specified. Default value is
+'/usr/bin/google-chrome' for use with the mkenney/chromium-headless Docker image
+if no binary can be found, a warning is logged if CHROME_PATH is set but isn't
+an executable file.
 */
 func (chrome *Chrome) Binary() string {
 	if "" == chrome.binary {
-		chrome.binary = "/usr/bin/google-chrome"
+		binary, err := FindBinary()
+		if nil != err {
+			if "" != os.Getenv("CHROME_PATH") {
+				log.WithFields(log.Fields{
+					"error": err,
+				}).Warn("invalid CHROME_PATH, using /usr/bin/google-chrome")
+			}
+			binary = "/usr/bin/google-chrome"
+		}
+		chrome.binary = binary
 	}
 	return chrome.binary
 }
 
 /*
 Close implements Chromium.
+
+The browser is only detached from if it was attached with Connect.
 */
 func (chrome *Chrome) Close() error {
-	if chrome.process != nil {
+	if chrome.connected {
+		return chrome.detach()
+	}
+	defer chrome.removeProfile()
+	chrome.mux.Lock()
+	chrome.closing = true
+	exited := chrome.exited
+	chrome.mux.Unlock()
+
+	if nil != chrome.process && nil != exited {
 		for _, tab := range chrome.Tabs() {
 			tab.Close()
 		}
-		if err := chrome.process.Signal(os.Interrupt); err != nil {
-			return errs.Wrap(err, 0, "chrome process interrupt failed")
-		}
-		ps, err := chrome.process.Wait()
-		if err != nil {
-			return errs.Wrap(err, 0, "error waiting for process exit, result unknown")
+		if err := chrome.stop(exited); nil != err {
+			return errs.Wrap(err, 0, "chrome process shutdown failed")
 		}
-		log.WithFields(log.Fields{
-			"signal": ps.String(),
-		}).Info("Chromium exited")
 	}
-	if chrome.stdOUTFile != nil {
-		chrome.stdOUTFile.Close()
+	if browser := chrome.openBrowserSocket(); nil != browser {
+		browser.Fail(errs.New(0, "browser closed"))
+	}
+	// The next launch starts a new browser with its own connection, version
+	// and target discovery.
+	chrome.mux.Lock()
+	chrome.browser = nil
+	chrome.browserURL = ""
+	chrome.discovering = false
+	chrome.version = nil
+	outputClosed := chrome.outputClosed
+	chrome.mux.Unlock()
+	if nil != outputClosed {
+		// Let the last output be copied before the output files are closed.
+		select {
+		case <-outputClosed:
+		case <-time.After(time.Second):
+		}
 	}
+	chrome.closeOutput()
 	return nil
 }
 
+/*
+browserSocket returns the browser-level websocket connection, opening it on
+first use. Browser-level commands such as Target.createTarget must be sent on
+this connection.
+*/
+func (chrome *Chrome) browserSocket() (*socket.Socket, error) {
+	if browser := chrome.openBrowserSocket(); nil != browser {
+		return browser, nil
+	}
+	chrome.mux.Lock()
+	browserURL := chrome.browserURL
+	chrome.mux.Unlock()
+	if "" == browserURL {
+		version, err := chrome.Version()
+		if nil != err {
+			return nil, errs.Wrap(err, 0, "could not determine the browser websocket URL")
+		}
+		browserURL = version.WebSocketDebuggerURL
+	}
+	socketURL, err := url.Parse(browserURL)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, fmt.Sprintf("invalid websocket URL '%s'", browserURL))
+	}
+	chrome.secureSocketURL(socketURL)
+
+	chrome.mux.Lock()
+	defer chrome.mux.Unlock()
+	// Another caller may have opened the connection in the meantime.
+	if nil == chrome.browser {
+		chrome.browser = socket.New(socketURL)
+	}
+	return chrome.browser, nil
+}
+
+/*
+openBrowserSocket returns the browser-level websocket connection, or nil if it
+hasn't been opened.
+*/
+func (chrome *Chrome) openBrowserSocket() *socket.Socket {
+	chrome.mux.Lock()
+	defer chrome.mux.Unlock()
+	return chrome.browser
+}
+
 /*
 DebuggingAddress implements Chromium.
 
@@ -164,20 +311,28 @@ func (chrome *Chrome) DebuggingPort() int {
 GetTab implements Chromium.
 */
 func (chrome *Chrome) GetTab(tabID string) (Tabber, error) {
-	var tab Tabber
-	var err error
-	for _, tab = range chrome.Tabs() {
-		if tab.Data().ID == tabID {
-			return tab, nil
-		}
+	if tab, ok := chrome.tabs.get(tabID); ok {
+		return tab, nil
 	}
-	err = errs.New(0, fmt.Sprintf("tab '%s' not found", tabID))
-	return tab, err
+	return nil, errs.New(0, fmt.Sprintf("tab '%s' not found", tabID))
 }
 
 /*
 Launch implements Chromium.
 
+Launch waits up to DefaultLaunchTimeout for Chromium to start, see
+LaunchContext.
+*/
+func (chrome *Chrome) Launch() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultLaunchTimeout)
+	defer cancel()
+	return chrome.LaunchContext(ctx)
+}
+
+/*
+LaunchContext starts the Chromium process and waits until the DevTools endpoint
+is listening and target discovery is enabled, or the context is done.
+
 This implementation makes it's best effort to set a few sane default values if
 they aren't included in the Flags definition:
 
@@ -185,50 +340,74 @@ they aren't included in the Flags definition:
 	remote-debugging-address = "0.0.0.0"
 	remote-debugging-port = 9222
 	port = 9222
-	user-data-dir = os.TempDir() + chrome.Workdir()
+	user-data-dir = a temporary profile, see ProfileOptions
 	chrome.workdir = "headless-chrome"
 	chrome.output = "/dev/stdout"
+
+Set remote-debugging-port to 0 to let Chromium choose a free port, which allows
+several browsers to be launched without coordinating ports. The chosen port is
+read from the DevToolsActivePort file in the user data directory, or from the
+"DevTools listening on" message Chromium writes to STDERR, and Port returns it
+once Chromium has started.
 */
-func (chrome *Chrome) Launch() error {
+func (chrome *Chrome) LaunchContext(ctx context.Context) error {
 	var err error
+	if chrome.connected {
+		return errs.New(0, "cannot launch a browser attached with Connect")
+	}
 
 	// Default values for required parameters
 	chrome.Address()
 	chrome.DebuggingAddress()
 	chrome.DebuggingPort()
 	chrome.Port()
-	if !chrome.Flags().Has("user-data-dir") {
-		chrome.Flags().Set("user-data-dir", os.TempDir())
+	if err = validateLimits(chrome.limits); nil != err {
+		return err
+	}
+	if err = chrome.limitFlags(); nil != err {
+		return err
+	}
+	if err = chrome.Flags().Validate(); nil != err {
+		return err
+	}
+	if err = chrome.prepareProfile(); nil != err {
+		return err
 	}
+	defer func() {
+		if nil != err {
+			chrome.removeProfile()
+		}
+	}()
 
 	if err = os.MkdirAll(chrome.Workdir(), 0700); err != nil {
 		return errs.Wrap(err, 0, fmt.Sprintf("cannot create working directory '%s'", chrome.Workdir()))
 	}
 
-	if "" == chrome.STDERR() {
-		chrome.stdERRFile = os.Stderr
-	} else {
-		chrome.stdERRFile, err = os.OpenFile(
-			chrome.STDERR(),
-			os.O_APPEND|os.O_CREATE|os.O_RDWR,
-			0600,
-		)
-		if err != nil {
-			return errs.Wrap(err, 0, fmt.Sprintf("cannot open error output file '%s'", chrome.STDERR()))
+	if err = chrome.openOutput(); nil != err {
+		return err
+	}
+	defer func() {
+		if nil != err {
+			chrome.closeOutput()
 		}
+	}()
+
+	activePortFile := chrome.devToolsActivePortFile()
+	if err = removeDevToolsActivePort(activePortFile); nil != err {
+		return err
 	}
 
-	if "" == chrome.STDOUT() {
-		chrome.stdOUTFile = os.Stdout
-	} else {
-		chrome.stdOUTFile, err = os.OpenFile(
-			chrome.STDOUT(),
-			os.O_APPEND|os.O_CREATE|os.O_RDWR,
-			0600,
-		)
-		if err != nil {
-			return errs.Wrap(err, 0, fmt.Sprintf("cannot open standard output file '%s'", chrome.STDOUT()))
-		}
+	// The output is read from pipes to be captured, STDERR is also read to
+	// find the DevTools endpoint.
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if nil != err {
+		return errs.Wrap(err, 0, "cannot create standard output pipe")
+	}
+	stderrReader, stderrWriter, err := os.Pipe()
+	if nil != err {
+		stdoutReader.Close()
+		stdoutWriter.Close()
+		return errs.Wrap(err, 0, "cannot create error output pipe")
 	}
 
 	log.WithFields(log.Fields{
@@ -237,37 +416,82 @@ func (chrome *Chrome) Launch() error {
 	}).Info("Starting process")
 	var procAttributes os.ProcAttr
 	procAttributes.Dir = chrome.Workdir()
-	procAttributes.Files = []*os.File{nil, chrome.stdOUTFile, chrome.stdERRFile}
+	procAttributes.Files = []*os.File{nil, stdoutWriter, stderrWriter}
+	procAttributes.Sys = processAttributes()
+	release, err := limitProcess(procAttributes.Sys, chrome.limits)
+	if nil != err {
+		stdoutReader.Close()
+		stdoutWriter.Close()
+		stderrReader.Close()
+		stderrWriter.Close()
+		return err
+	}
 	chrome.process, err = os.StartProcess(
 		chrome.Binary(),
 		chrome.Flags().List(),
 		&procAttributes,
 	)
+	release()
+	stdoutWriter.Close()
+	stderrWriter.Close()
 	if nil != err {
-		chrome.stdOUTFile.Close()
+		stdoutReader.Close()
+		stderrReader.Close()
 		return errs.Wrap(err, 0, "error starting chrome")
 	}
 
-	// Wait up to 10 seconds for Chromium to start
-	for i := 0; i < 10; i++ {
-		time.Sleep(time.Second)
-		if _, err = chrome.Version(); nil == err {
-			break
-		}
+	chrome.mux.Lock()
+	chrome.closing = false
+	select {
+	case <-chrome.exited:
+		chrome.exited = nil
+	default:
+	}
+	if nil == chrome.exited {
+		chrome.exited = make(chan struct{})
 	}
-	if err != nil {
+	chrome.outputClosed = make(chan struct{})
+	exited := chrome.exited
+	outputClosed := chrome.outputClosed
+	chrome.mux.Unlock()
+
+	listening := make(chan string, 1)
+	stdoutClosed := make(chan struct{})
+	stderrClosed := make(chan struct{})
+	go chrome.readSTDOUT(stdoutReader, stdoutClosed)
+	go chrome.readSTDERR(stderrReader, listening, stderrClosed)
+	go func() {
+		<-stdoutClosed
+		<-stderrClosed
+		close(outputClosed)
+	}()
+	go chrome.watch(chrome.process, exited, outputClosed)
+
+	if err = applyLimits(chrome.process, chrome.limits); nil != err {
+		chrome.Close()
+		return err
+	}
+
+	if err = chrome.waitForDevTools(ctx, activePortFile, listening, exited); nil != err {
 		log.Error("Chromium took too long to start")
 		chrome.Close()
 		return errs.Wrap(err, 0, "chromium took too long to start")
 	}
 
+	// The open tabs are kept in sync with the browser targets.
+	if err = chrome.DiscoverTargets(ctx); nil != err {
+		chrome.Close()
+		return err
+	}
+
 	return nil
 }
 
 /*
 Port implements Chromium.
 
-Default value is 9222
+Default value is 9222. When Chromium was launched with an ephemeral debugging
+port, Port returns the port chosen by Chromium.
 */
 func (chrome *Chrome) Port() int {
 	if !chrome.Flags().Has("port") {
@@ -279,39 +503,27 @@ func (chrome *Chrome) Port() int {
 
 /*
 Query implements Chromium.
+
+Query sends a GET request with the DevTools client, see DevTools.
 */
 func (chrome *Chrome) Query(
 	path string,
 	params url.Values,
 	msg interface{}, // Data receiver
 ) (interface{}, error) {
-	if len(params) > 0 {
-		path += fmt.Sprintf("?%s", params.Encode())
+	rawQuery := ""
+	if k := strings.Index(path, "?"); -1 != k {
+		path, rawQuery = path[:k], path[k+1:]
 	}
-
-	uri := fmt.Sprintf("http://%s:%d%s", chrome.Address(), chrome.Port(), path)
-	resp, err := http.Get(uri)
-	if err != nil {
-		return nil, errs.Wrap(err, 0, "get uri failed")
-	}
-	defer resp.Body.Close()
-
-	log.WithFields(log.Fields{
-		"path":   path,
-		"status": resp.Status,
-	}).Debug("querying chrome")
-	if 200 != resp.StatusCode {
-		return nil, errs.New(0, resp.Status)
+	if len(params) > 0 {
+		if "" != rawQuery {
+			rawQuery += "&"
+		}
+		rawQuery += params.Encode()
 	}
-
-	content, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errs.Wrap(err, 0, "read failed")
-	} else if err := json.Unmarshal(content, &msg); err != nil {
-		// it's not JSON so just return it
-		return content, nil
+	if err := chrome.DevTools().do(context.Background(), "GET", path, rawQuery, msg); nil != err {
+		return nil, err
 	}
-
 	return msg, nil
 }
 
@@ -319,14 +531,7 @@ func (chrome *Chrome) Query(
 RemoveTab implements Chromium.
 */
 func (chrome *Chrome) RemoveTab(tab *Tab) {
-	tabs := make([]*Tab, 0)
-	for k, t := range chrome.tabs {
-		if t == tab {
-			chrome.tabs = append(chrome.tabs[:k], chrome.tabs[k+1:]...)
-			break
-		}
-	}
-	chrome.tabs = tabs
+	chrome.tabs.remove(tab)
 }
 
 /*
@@ -347,23 +552,28 @@ func (chrome *Chrome) STDOUT() string {
 Tabs implements Chromium.
 */
 func (chrome *Chrome) Tabs() []*Tab {
-	return chrome.tabs
+	return chrome.tabs.list()
 }
 
 /*
 Version implements Chromium.
 */
 func (chrome *Chrome) Version() (*Version, error) {
-	if nil == chrome.version {
-		if _, err := chrome.Query(
-			"/json/version",
-			url.Values{},
-			&chrome.version,
-		); err != nil {
-			return nil, errs.Wrap(err, 0, "version query failed")
-		}
+	chrome.mux.Lock()
+	version := chrome.version
+	chrome.mux.Unlock()
+	if nil != version {
+		return version, nil
+	}
+	// Errors are returned as is so an *HTTPError can be inspected.
+	version, err := chrome.DevTools().Version(context.Background())
+	if nil != err {
+		return nil, err
 	}
-	return chrome.version, nil
+	chrome.mux.Lock()
+	chrome.version = version
+	chrome.mux.Unlock()
+	return version, nil
 }
 
 /*
@@ -3,6 +3,7 @@ package chrome
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	errs "github.com/bdlm/errors"
@@ -11,9 +12,103 @@ import (
 
 /*
 Flags contains CLI arguments to the Chromium executable.
+
+Values may be nil or true for switches without a value, false to leave a switch
+out, an int, a float, a string, or a []string for comma separated lists such as
+'enable-features'. Positional arguments, such as the URL to open, are set with
+AddArgs.
 */
 type Flags map[string]interface{}
 
+/*
+positionalArgs is the key positional arguments are stored under. It can't be a
+flag name.
+*/
+const positionalArgs = ""
+
+/*
+listFlags holds the flags whose string values are comma separated lists that
+are merged rather than replaced.
+*/
+var listFlags = map[string]bool{
+	"disable-blink-features": true,
+	"disable-features":       true,
+	"enable-blink-features":  true,
+	"enable-features":        true,
+}
+
+/*
+oppositeListFlags maps list flags to the list flag that reverses them. Adding a
+value to one removes it from the other.
+*/
+var oppositeListFlags = map[string]string{
+	"disable-blink-features": "enable-blink-features",
+	"disable-features":       "enable-features",
+	"enable-blink-features":  "disable-blink-features",
+	"enable-features":        "disable-features",
+}
+
+/*
+AddArgs adds positional arguments, which are passed after the flags.
+*/
+func (flags Flags) AddArgs(args ...string) {
+	flags[positionalArgs] = append(flags.Args(), args...)
+}
+
+/*
+Append adds values to a list flag such as 'enable-features'. Values that are
+already present are skipped, and values added to 'enable-features' or
+'enable-blink-features' are removed from the matching 'disable-' flag and the
+other way around.
+*/
+func (flags Flags) Append(arg string, values ...string) error {
+	if positionalArgs == arg {
+		return errs.New(0, "positional arguments must be added with AddArgs")
+	}
+	list, err := flags.list(arg)
+	if nil != err {
+		return err
+	}
+	for _, value := range values {
+		if "" == value || contains(list, value) {
+			continue
+		}
+		list = append(list, value)
+	}
+	flags[arg] = list
+
+	opposite, ok := oppositeListFlags[arg]
+	if !ok || !flags.Has(opposite) {
+		return nil
+	}
+	oppositeList, err := flags.list(opposite)
+	if nil != err {
+		return err
+	}
+	remaining := []string{}
+	for _, value := range oppositeList {
+		if !contains(values, value) {
+			remaining = append(remaining, value)
+		}
+	}
+	if 0 == len(remaining) {
+		flags.Remove(opposite)
+	} else {
+		flags[opposite] = remaining
+	}
+	return nil
+}
+
+/*
+Args returns the positional arguments.
+*/
+func (flags Flags) Args() []string {
+	args, _ := flags[positionalArgs].([]string)
+	result := make([]string, len(args))
+	copy(result, args)
+	return result
+}
+
 /*
 Get implements ChromiumFlags
 */
@@ -44,6 +139,9 @@ func (flags Flags) List() []string {
 
 	orderedFlags := []string{}
 	for arg := range flags {
+		if positionalArgs == arg {
+			continue
+		}
 		orderedFlags = append(orderedFlags, arg)
 	}
 	sort.Strings(orderedFlags)
@@ -54,23 +152,51 @@ func (flags Flags) List() []string {
 			log.Error(err)
 		}
 		switch val.(type) {
+		case bool:
+			if !val.(bool) {
+				continue
+			}
+			arg = fmt.Sprintf("--%s", arg)
+		case float32:
+			arg = fmt.Sprintf("--%s=%s", arg, strconv.FormatFloat(float64(val.(float32)), 'f', -1, 32))
+		case float64:
+			arg = fmt.Sprintf("--%s=%s", arg, strconv.FormatFloat(val.(float64), 'f', -1, 64))
 		case int:
 			arg = fmt.Sprintf("--%s=%d", arg, val.(int))
 		case string:
 			arg = fmt.Sprintf("--%s=%s", arg, val.(string))
+		case []string:
+			if 0 == len(val.([]string)) {
+				continue
+			}
+			arg = fmt.Sprintf("--%s=%s", arg, strings.Join(val.([]string), ","))
 		default:
 			arg = fmt.Sprintf("--%s", arg)
 		}
 		list = append(list, arg)
 	}
 
-	return list
+	return append(list, flags.Args()...)
+}
+
+/*
+Remove implements ChromiumFlags
+*/
+func (flags Flags) Remove(arg string) {
+	delete(flags, arg)
 }
 
 /*
 Set implements ChromiumFlags
+
+Strings set on list flags such as 'enable-features' are split into lists, see
+Append to add to a list instead of replacing it.
 */
 func (flags Flags) Set(arg string, value interface{}) (err error) {
+	if positionalArgs == arg {
+		return errs.New(0, "positional arguments must be added with AddArgs")
+	}
+
 	if nil == value {
 		if _, ok := flags[arg]; !ok {
 			flags[arg] = nil
@@ -79,10 +205,18 @@ func (flags Flags) Set(arg string, value interface{}) (err error) {
 
 	if nil != value {
 		switch value.(type) {
-		case int:
+		case bool, float32, float64, int:
 			flags[arg] = value
 		case string:
-			flags[arg] = value
+			if listFlags[arg] {
+				flags[arg] = splitList(value.(string))
+			} else {
+				flags[arg] = value
+			}
+		case []string:
+			list := make([]string, len(value.([]string)))
+			copy(list, value.([]string))
+			flags[arg] = list
 		default:
 			return errs.New(0, fmt.Sprintf("Invalid data type '%T' for argument %s: %+v", value, arg, value))
 		}
@@ -97,3 +231,102 @@ String implements ChromiumFlags
 func (flags Flags) String() string {
 	return strings.Join(flags.List(), " ")
 }
+
+/*
+Validate implements ChromiumFlags
+
+Validate rejects unsupported values and settings that conflict, such as a
+feature that is both enabled and disabled, or 'headless' with
+'remote-debugging-pipe' and no 'remote-debugging-port' to connect to.
+*/
+func (flags Flags) Validate() error {
+	problems := []string{}
+	for arg, value := range flags {
+		switch value.(type) {
+		case nil, bool, float32, float64, int, string:
+			if positionalArgs == arg {
+				problems = append(problems, "positional arguments must be a []string")
+			}
+		case []string:
+		default:
+			problems = append(problems, fmt.Sprintf("invalid data type '%T' for argument %s", value, arg))
+		}
+	}
+
+	if flags.enabled("headless") && flags.enabled("remote-debugging-pipe") && !flags.Has("remote-debugging-port") {
+		problems = append(problems, "'headless' with 'remote-debugging-pipe' requires 'remote-debugging-port', DevTools is only reachable over the pipe otherwise")
+	}
+
+	for _, arg := range []string{"enable-features", "enable-blink-features"} {
+		enabled, _ := flags.list(arg)
+		disabled, _ := flags.list(oppositeListFlags[arg])
+		for _, value := range enabled {
+			if contains(disabled, value) {
+				problems = append(problems, fmt.Sprintf("'%s' is in both '%s' and '%s'", value, arg, oppositeListFlags[arg]))
+			}
+		}
+	}
+
+	if 0 < len(problems) {
+		sort.Strings(problems)
+		return errs.New(0, fmt.Sprintf("invalid flags: %s", strings.Join(problems, "; ")))
+	}
+	return nil
+}
+
+/*
+enabled reports whether a switch is set and not false.
+*/
+func (flags Flags) enabled(arg string) bool {
+	value, ok := flags[arg]
+	if !ok {
+		return false
+	}
+	if enabled, ok := value.(bool); ok {
+		return enabled
+	}
+	return true
+}
+
+/*
+list returns a copy of the values of a list flag.
+*/
+func (flags Flags) list(arg string) ([]string, error) {
+	switch value := flags[arg].(type) {
+	case nil:
+		return []string{}, nil
+	case string:
+		return splitList(value), nil
+	case []string:
+		list := make([]string, len(value))
+		copy(list, value)
+		return list, nil
+	default:
+		return nil, errs.New(0, fmt.Sprintf("argument %s is not a list: %+v", arg, value))
+	}
+}
+
+/*
+contains reports whether a list contains a value.
+*/
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+splitList splits a comma separated list.
+*/
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); "" != item {
+			list = append(list, item)
+		}
+	}
+	return list
+}
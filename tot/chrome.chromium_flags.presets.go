@@ -0,0 +1,156 @@
+package chrome
+
+import (
+	"fmt"
+	"sort"
+
+	errs "github.com/bdlm/errors"
+)
+
+/*
+Flag preset names, see FlagPreset.
+*/
+const (
+	// PresetContainer disables the sandbox and /dev/shm usage so Chromium runs
+	// as root in a container with a small shared memory partition.
+	PresetContainer = "container"
+
+	// PresetDeterministicRendering disables rendering features that vary
+	// between runs and machines, for stable screenshots.
+	PresetDeterministicRendering = "deterministic-rendering"
+
+	// PresetHeadlessCI runs Chromium headless without background services,
+	// first-run dialogs or extensions.
+	PresetHeadlessCI = "headless-ci"
+
+	// PresetLowMemory limits renderer processes and caches.
+	PresetLowMemory = "low-memory"
+)
+
+/*
+flagPresets holds the flags for each preset.
+*/
+var flagPresets = map[string]Flags{
+	PresetContainer: {
+		"disable-dev-shm-usage": nil,
+		"disable-gpu":           nil,
+		"no-sandbox":            nil,
+	},
+	PresetDeterministicRendering: {
+		"disable-background-timer-throttling":    nil,
+		"disable-backgrounding-occluded-windows": nil,
+		"disable-checker-imaging":                nil,
+		"disable-font-subpixel-positioning":      nil,
+		"disable-image-animation-resync":         nil,
+		"disable-lcd-text":                       nil,
+		"disable-new-content-rendering-timeout":  nil,
+		"disable-partial-raster":                 nil,
+		"disable-renderer-backgrounding":         nil,
+		"disable-skia-runtime-opts":              nil,
+		"disable-threaded-animation":             nil,
+		"disable-threaded-scrolling":             nil,
+		"font-render-hinting":                    "none",
+		"force-color-profile":                    "srgb",
+		"force-device-scale-factor":              1,
+		"hide-scrollbars":                        nil,
+		"run-all-compositor-stages-before-draw":  nil,
+	},
+	PresetHeadlessCI: {
+		"disable-background-networking": nil,
+		"disable-component-update":      nil,
+		"disable-default-apps":          nil,
+		"disable-extensions":            nil,
+		"disable-gpu":                   nil,
+		"disable-sync":                  nil,
+		"headless":                      nil,
+		"hide-scrollbars":               nil,
+		"metrics-recording-only":        nil,
+		"mute-audio":                    nil,
+		"no-default-browser-check":      nil,
+		"no-first-run":                  nil,
+		"password-store":                "basic",
+		"use-mock-keychain":             nil,
+	},
+	PresetLowMemory: {
+		"aggressive-cache-discard":      nil,
+		"disable-background-networking": nil,
+		"disable-dev-shm-usage":         nil,
+		"disable-extensions":            nil,
+		"disk-cache-size":               1,
+		"media-cache-size":              1,
+		"renderer-process-limit":        1,
+	},
+}
+
+/*
+FlagPreset returns a copy of a named set of flags. Presets can be combined with
+each other and with custom flags using Flags.Merge:
+
+	flags := chrome.Flags{"remote-debugging-port": 9222}
+	ci, _ := chrome.FlagPreset(chrome.PresetHeadlessCI)
+	container, _ := chrome.FlagPreset(chrome.PresetContainer)
+	flags.Merge(ci, container)
+*/
+func FlagPreset(name string) (Flags, error) {
+	preset, ok := flagPresets[name]
+	if !ok {
+		return nil, errs.New(0, fmt.Sprintf("unknown flag preset '%s'", name))
+	}
+	flags := Flags{}
+	for arg, value := range preset {
+		flags[arg] = value
+	}
+	return flags, nil
+}
+
+/*
+FlagPresets returns the names of the available flag presets.
+*/
+func FlagPresets() []string {
+	names := make([]string, 0, len(flagPresets))
+	for name := range flagPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+Merge sets the flags from each set of flags in order. Flags that already have
+a value are overwritten, flags without a value are only added, and false turns
+a switch off. Values of list flags such as 'enable-features' and positional
+arguments are added to the existing ones.
+*/
+func (flags Flags) Merge(others ...Flags) error {
+	for _, other := range others {
+		names := make([]string, 0, len(other))
+		for arg := range other {
+			names = append(names, arg)
+		}
+		sort.Strings(names)
+		for _, arg := range names {
+			value := other[arg]
+			var err error
+			switch {
+			case positionalArgs == arg:
+				args, ok := value.([]string)
+				if !ok {
+					err = errs.New(0, fmt.Sprintf("Invalid data type '%T' for positional arguments", value))
+					break
+				}
+				flags.AddArgs(args...)
+			case listFlags[arg]:
+				var values []string
+				if values, err = other.list(arg); nil == err {
+					err = flags.Append(arg, values...)
+				}
+			default:
+				err = flags.Set(arg, value)
+			}
+			if nil != err {
+				return errs.Wrap(err, 0, fmt.Sprintf("could not merge flag '%s'", arg))
+			}
+		}
+	}
+	return nil
+}
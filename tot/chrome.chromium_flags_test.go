@@ -75,7 +75,7 @@ func TestChromiumFlagsSet(t *testing.T) {
 		t.Errorf("Expected nil, received error '%s'", err.Error())
 	}
 
-	err = flags.Set("test-4", false)
+	err = flags.Set("test-4", struct{}{})
 	if nil == err {
 		t.Errorf("Expected error, received nil")
 	}
@@ -104,3 +104,138 @@ func TestChromiumFlagsString(t *testing.T) {
 		t.Errorf("Expected '--test-1 --test-2=string --test-3=1', received '%s'", args)
 	}
 }
+
+func TestChromiumFlagsMerge(t *testing.T) {
+	flags := Flags{
+		"headless":    "old",
+		"no-sandbox":  nil,
+		"window-size": "800,600",
+	}
+	err := flags.Merge(
+		Flags{"headless": nil, "window-size": "1280,720"},
+		Flags{"remote-debugging-port": 9222},
+	)
+	if nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if "old" != flags["headless"] {
+		t.Errorf("Expected 'old', received '%v'", flags["headless"])
+	}
+	if "1280,720" != flags["window-size"] {
+		t.Errorf("Expected '1280,720', received '%v'", flags["window-size"])
+	}
+	if 9222 != flags["remote-debugging-port"] {
+		t.Errorf("Expected 9222, received '%v'", flags["remote-debugging-port"])
+	}
+	if err := flags.Merge(Flags{"invalid": struct{}{}}); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestChromiumFlagsTypes(t *testing.T) {
+	flags := Flags{}
+	flags.Set("disable-gpu", true)
+	flags.Set("headless", false)
+	flags.Set("force-device-scale-factor", 1.5)
+	flags.Set("enable-features", "NetworkService, VizDisplayCompositor")
+	flags.AddArgs("about:blank")
+	if err := flags.Set("", "invalid"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	expected := "--disable-gpu --enable-features=NetworkService,VizDisplayCompositor --force-device-scale-factor=1.5 about:blank"
+	if expected != flags.String() {
+		t.Errorf("Expected '%s', received '%s'", expected, flags.String())
+	}
+
+	flags.Remove("disable-gpu")
+	if flags.Has("disable-gpu") {
+		t.Errorf("Expected 'disable-gpu' to be removed")
+	}
+}
+
+func TestChromiumFlagsAppend(t *testing.T) {
+	flags := Flags{
+		"disable-features": "Translate,NetworkService",
+	}
+	if err := flags.Append("enable-features", "NetworkService", "NetworkService", "AudioService"); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if "--disable-features=Translate --enable-features=NetworkService,AudioService" != flags.String() {
+		t.Errorf("Expected the feature to move to 'enable-features', received '%s'", flags.String())
+	}
+	flags.Append("disable-features", "AudioService")
+	if "--disable-features=Translate,AudioService --enable-features=NetworkService" != flags.String() {
+		t.Errorf("Expected the feature to move to 'disable-features', received '%s'", flags.String())
+	}
+	if err := flags.Append("", "about:blank"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	flags["window-size"] = 1
+	if err := flags.Append("window-size", "800"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestChromiumFlagsMergeLists(t *testing.T) {
+	flags := Flags{
+		"enable-features": []string{"A", "B"},
+		"no-sandbox":      nil,
+	}
+	flags.AddArgs("https://example.com/")
+	other := Flags{
+		"disable-features": "A",
+		"enable-features":  "C",
+		"no-sandbox":       false,
+	}
+	other.AddArgs("about:blank")
+	if err := flags.Merge(other); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	expected := "--disable-features=A --enable-features=B,C https://example.com/ about:blank"
+	if expected != flags.String() {
+		t.Errorf("Expected '%s', received '%s'", expected, flags.String())
+	}
+	if err := flags.Merge(Flags{"": "about:blank"}); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestChromiumFlagsValidate(t *testing.T) {
+	for flags, valid := range map[*Flags]bool{
+		{"headless": nil, "remote-debugging-port": 9222}:                                  true,
+		{"headless": nil, "remote-debugging-pipe": nil}:                                   false,
+		{"headless": nil, "remote-debugging-pipe": nil, "remote-debugging-port": 0}:       true,
+		{"headless": false, "remote-debugging-pipe": nil}:                                 true,
+		{"enable-features": "A,B", "disable-features": []string{"C"}}:                     true,
+		{"enable-features": "A,B", "disable-features": []string{"B"}}:                     false,
+		{"enable-blink-features": []string{"A"}, "disable-blink-features": []string{"A"}}: false,
+		{"window-size": struct{}{}}:                                                       false,
+		{"": "about:blank"}:                                                               false,
+	} {
+		if err := flags.Validate(); valid != (nil == err) {
+			t.Errorf("Expected %v to be valid: %v, received '%v'", *flags, valid, err)
+		}
+	}
+}
+
+func TestFlagPreset(t *testing.T) {
+	if _, err := FlagPreset("unknown"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	for _, name := range FlagPresets() {
+		preset, err := FlagPreset(name)
+		if nil != err {
+			t.Errorf("Expected nil, received '%s'", err)
+		}
+		if 0 == len(preset) {
+			t.Errorf("Expected flags for preset '%s'", name)
+		}
+	}
+
+	preset, _ := FlagPreset(PresetContainer)
+	preset.Set("no-sandbox", "changed")
+	if preset, _ = FlagPreset(PresetContainer); nil != preset["no-sandbox"] {
+		t.Errorf("Expected presets to be copied, received '%v'", preset["no-sandbox"])
+	}
+}
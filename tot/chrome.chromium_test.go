@@ -18,9 +18,6 @@ func TestChromiumNew(t *testing.T) {
 	if "localhost" != chrome.Address() {
 		t.Errorf("Expected 'localhost', received '%s'", chrome.Address())
 	}
-	if "/usr/bin/google-chrome" != chrome.Binary() {
-		t.Errorf("Expected '/usr/bin/google-chrome', received '%s'", chrome.Binary())
-	}
 	if "0.0.0.0" != chrome.DebuggingAddress() {
 		t.Errorf("Expected '0.0.0.0', received '%s'", chrome.DebuggingAddress())
 	}
@@ -0,0 +1,137 @@
+package chrome
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+)
+
+/*
+Connect returns a Chrome attached to an already running browser rather than
+starting a process, for example a browser in a shared container whose lifecycle
+is owned by another service.
+
+endpoint is either the DevTools HTTP endpoint, such as 'http://localhost:9222',
+or the browser websocket URL, such as
+'ws://localhost:9222/devtools/browser/<id>'. Endpoints behind a TLS proxy use
+https:// or wss://, the websockets of a browser reached over https are opened
+with wss. The version is read from /json/version and the open pages from
+/json/list are wrapped as Tabs, target discovery keeps them in sync with the
+browser. Close only detaches from the browser and leaves the browser and its
+pages running.
+*/
+func Connect(ctx context.Context, endpoint string) (*Chrome, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, fmt.Sprintf("invalid endpoint '%s'", endpoint))
+	}
+	browserURL := ""
+	scheme := "http"
+	switch endpointURL.Scheme {
+	case "http":
+	case "https":
+		scheme = "https"
+	case "ws":
+		browserURL = endpoint
+	case "wss":
+		browserURL = endpoint
+		scheme = "https"
+	default:
+		return nil, errs.New(0, fmt.Sprintf("unsupported endpoint '%s', expected an http(s):// or ws(s):// URL", endpoint))
+	}
+	port, err := strconv.Atoi(endpointURL.Port())
+	if nil != err {
+		return nil, errs.New(0, fmt.Sprintf("endpoint '%s' has no port", endpoint))
+	}
+
+	chrome := &Chrome{
+		browserURL: browserURL,
+		connected:  true,
+		flags:      &Flags{},
+		mux:        &sync.Mutex{},
+		scheme:     scheme,
+		tabs:       newTabRegistry(),
+	}
+	chrome.Flags().Set("addr", endpointURL.Hostname())
+	chrome.Flags().Set("port", port)
+
+	version, err := chrome.DevTools().Version(ctx)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "version query failed")
+	}
+	chrome.version = version
+
+	targets, err := chrome.DevTools().List(ctx)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "target list query failed")
+	}
+	for _, data := range targets {
+		// Only pages are wrapped, targets attached to another client have no
+		// websocket URL.
+		if "page" != data.Type || "" == data.WebSocketDebuggerURL {
+			continue
+		}
+		targetURL, err := url.Parse(data.URL)
+		if nil != err {
+			targetURL = &url.URL{}
+		}
+		if _, err := chrome.newTab(targetURL, data); nil != err {
+			chrome.Close()
+			return nil, err
+		}
+	}
+
+	if err := chrome.DiscoverTargets(ctx); nil != err {
+		chrome.Close()
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"browser":  version.Browser,
+		"endpoint": endpoint,
+		"tabs":     len(chrome.Tabs()),
+	}).Info("Connected to Chromium")
+	return chrome, nil
+}
+
+/*
+Connected reports whether the browser was attached with Connect rather than
+launched.
+*/
+func (chrome *Chrome) Connected() bool {
+	return chrome.connected
+}
+
+/*
+secureSocketURL switches a websocket URL reported by the browser to wss when the
+browser is reached over https. Chromium reports ws URLs even behind a TLS proxy.
+*/
+func (chrome *Chrome) secureSocketURL(socketURL *url.URL) {
+	if "https" == chrome.scheme && "ws" == socketURL.Scheme {
+		socketURL.Scheme = "wss"
+	}
+}
+
+/*
+detach closes the websocket connections to a connected browser without closing
+its pages.
+*/
+func (chrome *Chrome) detach() error {
+	for _, tab := range chrome.Tabs() {
+		tab.Socket().Stop()
+		chrome.tabs.remove(tab)
+	}
+	if browser := chrome.openBrowserSocket(); nil != browser {
+		browser.Stop()
+	}
+	log.WithFields(log.Fields{
+		"address": chrome.Address(),
+		"port":    chrome.Port(),
+	}).Info("Detached from Chromium")
+	return nil
+}
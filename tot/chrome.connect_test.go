@@ -0,0 +1,162 @@
+package chrome
+
+import (
+	"context"
+	"fmt"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func serveBrowser(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+		return
+	}
+	defer conn.Close()
+	for {
+		command := struct {
+			ID int `json:"id"`
+		}{}
+		if err := conn.ReadJSON(&command); nil != err {
+			return
+		}
+		conn.WriteJSON(map[string]interface{}{"id": command.ID, "result": map[string]interface{}{}})
+	}
+}
+
+func newDevToolsServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json/version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"Browser": "HeadlessChrome/1.0", "webSocketDebuggerUrl": "ws://%s/devtools/browser/browser-id"}`, r.Host)
+	})
+	mux.HandleFunc("/json/list", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[
+			{"id": "page-1", "type": "page", "url": "https://example.com/", "webSocketDebuggerUrl": "ws://%s/devtools/page/page-1"},
+			{"id": "page-2", "type": "page", "url": "about:blank"},
+			{"id": "worker-1", "type": "service_worker", "url": "https://example.com/sw.js", "webSocketDebuggerUrl": "ws://%s/devtools/page/worker-1"}
+		]`, r.Host, r.Host)
+	})
+	mux.HandleFunc("/json/close/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected Close not to close '%s'", r.URL.Path)
+	})
+	mux.HandleFunc("/devtools/browser/", func(w http.ResponseWriter, r *http.Request) {
+		serveBrowser(t, w, r)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestConnect(t *testing.T) {
+	server := newDevToolsServer(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	for _, endpoint := range []string{
+		server.URL,
+		fmt.Sprintf("ws://%s/devtools/browser/browser-id", host),
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		chrome, err := Connect(ctx, endpoint)
+		if nil != err {
+			t.Fatalf("Expected nil, received '%s'", err)
+		}
+		if !chrome.Connected() {
+			t.Errorf("Expected a connected browser")
+		}
+		if !chrome.discovering {
+			t.Errorf("Expected target discovery to be enabled")
+		}
+		version, err := chrome.Version()
+		if nil != err || "HeadlessChrome/1.0" != version.Browser {
+			t.Errorf("Expected the browser version, received '%v'", version)
+		}
+		tabs := chrome.Tabs()
+		if 1 != len(tabs) || "page-1" != tabs[0].Data().ID {
+			t.Errorf("Expected the open page to be wrapped, received %d tabs", len(tabs))
+		}
+		if strings.HasPrefix(endpoint, "ws://") && endpoint != chrome.browserURL {
+			t.Errorf("Expected '%s', received '%s'", endpoint, chrome.browserURL)
+		}
+		if err := chrome.LaunchContext(ctx); nil == err {
+			t.Errorf("Expected error, received nil")
+		}
+		if err := chrome.Close(); nil != err {
+			t.Errorf("Expected nil, received '%s'", err)
+		}
+		if 0 != len(chrome.Tabs()) {
+			t.Errorf("Expected the tabs to be detached")
+		}
+	}
+
+	for _, endpoint := range []string{
+		"file:///tmp/chrome",
+		"http://localhost",
+		"http://127.0.0.1:1",
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err := Connect(ctx, endpoint); nil == err {
+			t.Errorf("Expected error for '%s', received nil", endpoint)
+		}
+	}
+}
+
+type logWriter chan string
+
+func (writer logWriter) Write(p []byte) (int, error) {
+	select {
+	case writer <- string(p):
+	default:
+	}
+	return len(p), nil
+}
+
+func TestConnectTLS(t *testing.T) {
+	handler := newDevToolsServer(t).Config.Handler
+	requests := make(chan bool, 20)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- nil != r.TLS
+		handler.ServeHTTP(w, r)
+	}))
+	errors := make(logWriter, 20)
+	server.Config.ErrorLog = stdlog.New(errors, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	httpClient := DefaultHTTPClient
+	DefaultHTTPClient = server.Client()
+	defer func() { DefaultHTTPClient = httpClient }()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	for _, endpoint := range []string{
+		server.URL,
+		fmt.Sprintf("wss://%s/devtools/browser/browser-id", host),
+	} {
+		// The websocket dialer doesn't trust the test certificate, so the
+		// connection fails once the browser websocket is opened with wss.
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if _, err := Connect(ctx, endpoint); nil == err {
+			t.Errorf("Expected error, received nil")
+		}
+		for _, path := range []string{"/json/version", "/json/list"} {
+			if !<-requests {
+				t.Errorf("Expected %s to be requested over https", path)
+			}
+		}
+		select {
+		case message := <-errors:
+			if !strings.Contains(message, "TLS handshake error") {
+				t.Errorf("Expected a TLS handshake error, received '%s'", message)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Expected the browser websocket to be opened with wss")
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+)
+
+/*
+DefaultHTTPClient is the HTTP client used for the DevTools HTTP endpoints when
+no client is set with Chrome.SetHTTPClient.
+*/
+var DefaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+/*
+HTTPError is returned when a DevTools HTTP endpoint responds with a status other
+than 200 OK.
+*/
+type HTTPError struct {
+	// Body is the response body, which holds Chromium's error message.
+	Body string
+
+	// Method is the request method.
+	Method string
+
+	// Path is the request path.
+	Path string
+
+	// Status is the response status, such as "404 Not Found".
+	Status string
+
+	// StatusCode is the response status code.
+	StatusCode int
+}
+
+/*
+Error implements error.
+*/
+func (err *HTTPError) Error() string {
+	if "" == err.Body {
+		return fmt.Sprintf("%s %s: %s", err.Method, err.Path, err.Status)
+	}
+	return fmt.Sprintf("%s %s: %s: %s", err.Method, err.Path, err.Status, err.Body)
+}
+
+/*
+Protocol is the protocol description returned by /json/protocol.
+*/
+type Protocol struct {
+	// Domains holds the protocol domains.
+	Domains []*ProtocolDomain `json:"domains"`
+
+	// Version is the protocol version.
+	Version *ProtocolVersion `json:"version"`
+}
+
+/*
+ProtocolDomain describes a protocol domain.
+*/
+type ProtocolDomain struct {
+	// Commands holds the domain's commands.
+	Commands []*ProtocolMember `json:"commands,omitempty"`
+
+	// Dependencies holds the names of the domains this domain depends on.
+	Dependencies []string `json:"dependencies,omitempty"`
+
+	// Deprecated is set for deprecated domains.
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// Description describes the domain.
+	Description string `json:"description,omitempty"`
+
+	// Domain is the domain name, such as "Page".
+	Domain string `json:"domain"`
+
+	// Events holds the domain's events.
+	Events []*ProtocolMember `json:"events,omitempty"`
+
+	// Experimental is set for experimental domains.
+	Experimental bool `json:"experimental,omitempty"`
+
+	// Types holds the domain's type definitions.
+	Types []json.RawMessage `json:"types,omitempty"`
+}
+
+/*
+ProtocolMember describes a command or an event of a protocol domain.
+*/
+type ProtocolMember struct {
+	// Deprecated is set for deprecated members.
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// Description describes the member.
+	Description string `json:"description,omitempty"`
+
+	// Experimental is set for experimental members.
+	Experimental bool `json:"experimental,omitempty"`
+
+	// Name is the member name, such as "navigate".
+	Name string `json:"name"`
+
+	// Parameters holds the parameter definitions.
+	Parameters []json.RawMessage `json:"parameters,omitempty"`
+
+	// Returns holds the return value definitions of a command.
+	Returns []json.RawMessage `json:"returns,omitempty"`
+}
+
+/*
+ProtocolVersion is the protocol version.
+*/
+type ProtocolVersion struct {
+	// Major is the major version.
+	Major string `json:"major"`
+
+	// Minor is the minor version.
+	Minor string `json:"minor"`
+}
+
+/*
+DevToolsClient is a client for the DevTools HTTP endpoints. It is safe for
+concurrent use.
+*/
+type DevToolsClient struct {
+	// client is the HTTP client requests are sent with.
+	client *http.Client
+
+	// endpoint is the DevTools HTTP endpoint, such as http://localhost:9222.
+	endpoint *url.URL
+}
+
+/*
+NewDevToolsClient returns a client for the DevTools HTTP endpoint, such as
+'http://localhost:9222'. DefaultHTTPClient is used if client is nil.
+*/
+func NewDevToolsClient(endpoint string, client *http.Client) (*DevToolsClient, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, fmt.Sprintf("invalid endpoint '%s'", endpoint))
+	}
+	if "http" != endpointURL.Scheme && "https" != endpointURL.Scheme {
+		return nil, errs.New(0, fmt.Sprintf("unsupported endpoint '%s', expected an http:// URL", endpoint))
+	}
+	if nil == client {
+		client = DefaultHTTPClient
+	}
+	return &DevToolsClient{
+		client: client,
+		endpoint: &url.URL{
+			Host:   endpointURL.Host,
+			Scheme: endpointURL.Scheme,
+		},
+	}, nil
+}
+
+/*
+Activate brings a page target to the front with /json/activate/{id}.
+*/
+func (client *DevToolsClient) Activate(ctx context.Context, targetID string) error {
+	return client.do(ctx, "GET", "/json/activate/"+targetID, "", nil)
+}
+
+/*
+Close closes a target with /json/close/{id}.
+*/
+func (client *DevToolsClient) Close(ctx context.Context, targetID string) error {
+	return client.do(ctx, "GET", "/json/close/"+targetID, "", nil)
+}
+
+/*
+List returns the open targets from /json/list.
+*/
+func (client *DevToolsClient) List(ctx context.Context) ([]*TabData, error) {
+	targets := []*TabData{}
+	if err := client.do(ctx, "GET", "/json/list", "", &targets); nil != err {
+		return nil, err
+	}
+	return targets, nil
+}
+
+/*
+New opens a new page target with /json/new. The request is sent with PUT, which
+Chromium requires since version 111.
+*/
+func (client *DevToolsClient) New(ctx context.Context, uri string) (*TabData, error) {
+	data := &TabData{}
+	if err := client.do(ctx, "PUT", "/json/new", url.QueryEscape(uri), data); nil != err {
+		return nil, err
+	}
+	return data, nil
+}
+
+/*
+Protocol returns the protocol description from /json/protocol.
+*/
+func (client *DevToolsClient) Protocol(ctx context.Context) (*Protocol, error) {
+	protocol := &Protocol{}
+	if err := client.do(ctx, "GET", "/json/protocol", "", protocol); nil != err {
+		return nil, err
+	}
+	return protocol, nil
+}
+
+/*
+URL returns the DevTools HTTP endpoint.
+*/
+func (client *DevToolsClient) URL() string {
+	return client.endpoint.String()
+}
+
+/*
+Version returns the browser version from /json/version.
+*/
+func (client *DevToolsClient) Version(ctx context.Context) (*Version, error) {
+	version := &Version{}
+	if err := client.do(ctx, "GET", "/json/version", "", version); nil != err {
+		return nil, err
+	}
+	return version, nil
+}
+
+/*
+do sends a request and decodes the JSON response into msg. The response is
+discarded if msg is nil.
+*/
+func (client *DevToolsClient) do(ctx context.Context, method, path, rawQuery string, msg interface{}) error {
+	requestURL := *client.endpoint
+	requestURL.Path = path
+	requestURL.RawQuery = rawQuery
+	req, err := http.NewRequest(method, requestURL.String(), nil)
+	if nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("invalid request %s %s", method, path))
+	}
+	resp, err := client.client.Do(req.WithContext(ctx))
+	if nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("%s %s failed", method, path))
+	}
+	defer resp.Body.Close()
+
+	log.WithFields(log.Fields{
+		"method": method,
+		"path":   path,
+		"status": resp.Status,
+	}).Debug("querying chrome")
+	content, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("%s %s: read failed", method, path))
+	}
+	if http.StatusOK != resp.StatusCode {
+		return &HTTPError{
+			Body:       string(content),
+			Method:     method,
+			Path:       path,
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+		}
+	}
+	if nil == msg {
+		return nil
+	}
+	if err := json.Unmarshal(content, msg); nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("%s %s: invalid JSON response", method, path))
+	}
+	return nil
+}
+
+/*
+DevTools returns a client for the browser's DevTools HTTP endpoints. Browsers
+attached with an https:// or wss:// endpoint are queried over https.
+*/
+func (chrome *Chrome) DevTools() *DevToolsClient {
+	chrome.mux.Lock()
+	client := chrome.httpClient
+	chrome.mux.Unlock()
+	if nil == client {
+		client = DefaultHTTPClient
+	}
+	scheme := chrome.scheme
+	if "" == scheme {
+		scheme = "http"
+	}
+	return &DevToolsClient{
+		client: client,
+		endpoint: &url.URL{
+			Host:   net.JoinHostPort(chrome.Address(), strconv.Itoa(chrome.Port())),
+			Scheme: scheme,
+		},
+	}
+}
+
+/*
+SetHTTPClient sets the HTTP client used for the DevTools HTTP endpoints, for
+example to change the timeout or the transport.
+*/
+func (chrome *Chrome) SetHTTPClient(client *http.Client) {
+	chrome.mux.Lock()
+	defer chrome.mux.Unlock()
+	chrome.httpClient = client
+}
@@ -0,0 +1,199 @@
+package chrome
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDevToolsClient(t *testing.T) {
+	requests := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- fmt.Sprintf("%s %s", r.Method, r.URL.RequestURI())
+		switch r.URL.Path {
+		case "/json/version":
+			fmt.Fprint(w, `{"Browser": "HeadlessChrome/1.0", "Protocol-Version": "1.3"}`)
+		case "/json/list":
+			fmt.Fprint(w, `[{"id": "page-1", "type": "page"}, {"id": "page-2", "type": "page"}]`)
+		case "/json/new":
+			if "PUT" != r.Method {
+				http.Error(w, "Using unsafe HTTP verb GET to invoke /json/new", http.StatusMethodNotAllowed)
+				return
+			}
+			fmt.Fprintf(w, `{"id": "page-3", "type": "page", "url": "%s"}`, r.URL.RawQuery)
+		case "/json/activate/page-1":
+			fmt.Fprint(w, "Target activated")
+		case "/json/close/page-1":
+			fmt.Fprint(w, "Target is closing")
+		case "/json/protocol":
+			fmt.Fprint(w, `{"version": {"major": "1", "minor": "3"}, "domains": [{"domain": "Page", "commands": [{"name": "navigate"}]}]}`)
+		case "/json/invalid":
+			fmt.Fprint(w, "not json")
+		default:
+			http.Error(w, "No such target id: "+r.URL.Path, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewDevToolsClient(server.URL, nil)
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if server.URL != client.URL() {
+		t.Errorf("Expected '%s', received '%s'", server.URL, client.URL())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	version, err := client.Version(ctx)
+	if nil != err || "HeadlessChrome/1.0" != version.Browser {
+		t.Errorf("Expected the version, received %v, '%v'", version, err)
+	}
+	targets, err := client.List(ctx)
+	if nil != err || 2 != len(targets) || "page-2" != targets[1].ID {
+		t.Errorf("Expected 2 targets, received %v, '%v'", targets, err)
+	}
+	data, err := client.New(ctx, "https://example.com/?a=b")
+	if nil != err || "page-3" != data.ID {
+		t.Errorf("Expected a new target, received %v, '%v'", data, err)
+	}
+	if uri, _ := url.QueryUnescape(data.URL); "https://example.com/?a=b" != uri {
+		t.Errorf("Expected the URL to be passed, received '%s'", data.URL)
+	}
+	if err := client.Activate(ctx, "page-1"); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if err := client.Close(ctx, "page-1"); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	protocol, err := client.Protocol(ctx)
+	if nil != err || "1" != protocol.Version.Major || "navigate" != protocol.Domains[0].Commands[0].Name {
+		t.Errorf("Expected the protocol, received %v, '%v'", protocol, err)
+	}
+
+	for _, expected := range []string{
+		"GET /json/version",
+		"GET /json/list",
+		"PUT /json/new?https%3A%2F%2Fexample.com%2F%3Fa%3Db",
+		"GET /json/activate/page-1",
+		"GET /json/close/page-1",
+		"GET /json/protocol",
+	} {
+		if request := <-requests; expected != request {
+			t.Errorf("Expected '%s', received '%s'", expected, request)
+		}
+	}
+
+	err = client.Close(ctx, "missing")
+	httpErr, ok := err.(*HTTPError)
+	if !ok {
+		t.Fatalf("Expected an *HTTPError, received '%v'", err)
+	}
+	if http.StatusNotFound != httpErr.StatusCode || "/json/close/missing" != httpErr.Path || !strings.Contains(httpErr.Error(), "No such target id") {
+		t.Errorf("Expected a 404 error, received '%s'", httpErr)
+	}
+	<-requests
+
+	if err := client.do(ctx, "GET", "/json/invalid", "", &Version{}); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	<-requests
+
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if _, err := client.Version(canceled); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestNewDevToolsClient(t *testing.T) {
+	for _, endpoint := range []string{
+		"ws://localhost:9222/devtools/browser/browser-id",
+		"localhost:9222",
+		"%",
+	} {
+		if _, err := NewDevToolsClient(endpoint, nil); nil == err {
+			t.Errorf("Expected error for '%s', received nil", endpoint)
+		}
+	}
+
+	httpClient := &http.Client{}
+	client, err := NewDevToolsClient("http://localhost:9222/json", httpClient)
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if httpClient != client.client || "http://localhost:9222" != client.URL() {
+		t.Errorf("Expected the client and endpoint to be used, received '%s'", client.URL())
+	}
+}
+
+func TestChromeDevTools(t *testing.T) {
+	chrome := New(&Flags{"addr": "127.0.0.1", "port": 9333}, "", "", "", "")
+	client := chrome.DevTools()
+	if "http://127.0.0.1:9333" != client.URL() || DefaultHTTPClient != client.client {
+		t.Errorf("Expected the default client for http://127.0.0.1:9333, received '%s'", client.URL())
+	}
+	httpClient := &http.Client{}
+	chrome.SetHTTPClient(httpClient)
+	if httpClient != chrome.DevTools().client {
+		t.Errorf("Expected the HTTP client to be used")
+	}
+	chrome.scheme = "https"
+	if "https://127.0.0.1:9333" != chrome.DevTools().URL() {
+		t.Errorf("Expected https://127.0.0.1:9333, received '%s'", chrome.DevTools().URL())
+	}
+}
+
+func TestChromeHTTPErrors(t *testing.T) {
+	requests := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.URL.Path
+		switch r.URL.Path {
+		case "/json/close/page-1":
+			fmt.Fprint(w, "Target is closing")
+		default:
+			http.Error(w, "No such target id: "+r.URL.Path, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	chrome := New(&Flags{"addr": serverURL.Hostname(), "port": port}, "", "", "", "")
+
+	if _, err := chrome.NewTab("https://example.com/"); nil == err {
+		t.Errorf("Expected error, received nil")
+	} else if _, ok := err.(*HTTPError); !ok {
+		t.Errorf("Expected an *HTTPError, received '%s'", err)
+	}
+	if _, err := chrome.Version(); nil == err {
+		t.Errorf("Expected error, received nil")
+	} else if _, ok := err.(*HTTPError); !ok {
+		t.Errorf("Expected an *HTTPError, received '%s'", err)
+	}
+	<-requests
+	<-requests
+
+	// Tabs are closed with the DevTools client.
+	for _, id := range []string{"page-1", "page-2"} {
+		tab, err := chrome.newTab(&url.URL{}, &TabData{ID: id, WebSocketDebuggerURL: "ws://127.0.0.1:1/devtools/page/" + id})
+		if nil != err {
+			t.Fatalf("Expected nil, received '%s'", err)
+		}
+		_, err = tab.Close()
+		if "/json/close/"+id != <-requests {
+			t.Errorf("Expected the tab to be closed")
+		}
+		if "page-1" == id && nil != err {
+			t.Errorf("Expected nil, received '%s'", err)
+		}
+		if _, ok := err.(*HTTPError); "page-2" == id && !ok {
+			t.Errorf("Expected an *HTTPError, received '%v'", err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package chrome
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	errs "github.com/bdlm/errors"
+)
+
+/*
+DefaultLaunchTimeout is the time Launch waits for Chromium to start.
+*/
+var DefaultLaunchTimeout = 10 * time.Second
+
+/*
+devToolsListening is the message Chromium writes to STDERR when the DevTools
+endpoint is ready, followed by the browser websocket URL.
+*/
+const devToolsListening = "DevTools listening on "
+
+/*
+devToolsPollInterval is how often the DevToolsActivePort file is checked during
+startup.
+*/
+const devToolsPollInterval = 50 * time.Millisecond
+
+/*
+devToolsActivePortFile returns the path of the file Chromium writes the
+DevTools port and browser path to.
+*/
+func (chrome *Chrome) devToolsActivePortFile() string {
+	userDataDir, _ := chrome.Flags().Get("user-data-dir")
+	dir, _ := userDataDir.(string)
+	return filepath.Join(dir, "DevToolsActivePort")
+}
+
+/*
+readSTDERR reads Chromium's STDERR output and reports the browser websocket URL
+once Chromium is listening. exited is closed when STDERR is closed.
+*/
+func (chrome *Chrome) readSTDERR(reader io.ReadCloser, listening chan<- string, exited chan<- struct{}) {
+	defer close(exited)
+	chrome.mux.Lock()
+	buffer := chrome.stderrBuffer
+	chrome.mux.Unlock()
+
+	found := false
+	chrome.readOutput("stderr", reader, chrome.stdERRFile, buffer, func(line string) {
+		if found {
+			return
+		}
+		if k := strings.Index(line, devToolsListening); -1 != k {
+			found = true
+			listening <- strings.TrimSpace(line[k+len(devToolsListening):])
+		}
+	})
+}
+
+/*
+setBrowserURL records the browser websocket URL and the port Chromium is
+listening on. The host Chromium reports, such as 0.0.0.0, is replaced with the
+address the browser is reached on.
+*/
+func (chrome *Chrome) setBrowserURL(browserURL string) error {
+	socketURL, err := url.Parse(browserURL)
+	if nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("invalid websocket URL '%s'", browserURL))
+	}
+	port, err := strconv.Atoi(socketURL.Port())
+	if nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("invalid websocket URL '%s'", browserURL))
+	}
+	chrome.Flags().Set("port", port)
+	socketURL.Host = net.JoinHostPort(chrome.Address(), socketURL.Port())
+	chrome.mux.Lock()
+	chrome.browserURL = socketURL.String()
+	chrome.mux.Unlock()
+	return nil
+}
+
+/*
+waitForDevTools waits until Chromium reports the DevTools endpoint on STDERR or
+in the DevToolsActivePort file.
+*/
+func (chrome *Chrome) waitForDevTools(
+	ctx context.Context,
+	activePortFile string,
+	listening <-chan string,
+	exited <-chan struct{},
+) error {
+	ticker := time.NewTicker(devToolsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case browserURL := <-listening:
+			return chrome.setBrowserURL(browserURL)
+		case <-ticker.C:
+			if browserURL, err := readDevToolsActivePort(activePortFile, chrome.Address()); nil == err {
+				return chrome.setBrowserURL(browserURL)
+			}
+		case <-exited:
+			// The endpoint may have been reported just before exiting.
+			select {
+			case browserURL := <-listening:
+				return chrome.setBrowserURL(browserURL)
+			default:
+			}
+			return errs.New(0, chrome.withSTDERR("chromium exited before the DevTools endpoint was ready"))
+		case <-ctx.Done():
+			return errs.Wrap(ctx.Err(), 0, chrome.withSTDERR("DevTools endpoint not ready"))
+		}
+	}
+}
+
+/*
+withSTDERR appends the last lines Chromium wrote to STDERR to an error message.
+*/
+func (chrome *Chrome) withSTDERR(msg string) string {
+	if lines := chrome.STDERRLines(); 0 < len(lines) {
+		msg = fmt.Sprintf("%s: %s", msg, strings.Join(lines, "\n"))
+	}
+	return msg
+}
+
+/*
+readDevToolsActivePort reads the DevToolsActivePort file written by Chromium
+and returns the browser websocket URL. The file contains the port on the first
+line and the browser path on the second.
+*/
+func readDevToolsActivePort(path, host string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if 2 != len(lines) {
+		return "", errs.New(0, fmt.Sprintf("incomplete DevToolsActivePort file '%s'", path))
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if nil != err || 0 >= port {
+		return "", errs.New(0, fmt.Sprintf("invalid port in DevToolsActivePort file '%s'", path))
+	}
+	return fmt.Sprintf(
+		"ws://%s%s",
+		net.JoinHostPort(host, strconv.Itoa(port)),
+		strings.TrimSpace(lines[1]),
+	), nil
+}
+
+/*
+removeDevToolsActivePort removes a DevToolsActivePort file left by a previous
+process, which would report the wrong port.
+*/
+func removeDevToolsActivePort(path string) error {
+	if err := os.Remove(path); nil != err && !os.IsNotExist(err) {
+		return errs.Wrap(err, 0, fmt.Sprintf("cannot remove '%s'", path))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package chrome
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadDevToolsActivePort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-chrome-devtools-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "DevToolsActivePort")
+
+	if _, err := readDevToolsActivePort(path, "localhost"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	ioutil.WriteFile(path, []byte("41234\n"), 0600)
+	if _, err := readDevToolsActivePort(path, "localhost"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	ioutil.WriteFile(path, []byte("41234\n/devtools/browser/browser-id\n"), 0600)
+	browserURL, err := readDevToolsActivePort(path, "localhost")
+	if nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if "ws://localhost:41234/devtools/browser/browser-id" != browserURL {
+		t.Errorf("Expected 'ws://localhost:41234/devtools/browser/browser-id', received '%s'", browserURL)
+	}
+
+	if err := removeDevToolsActivePort(path); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if err := removeDevToolsActivePort(path); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+}
+
+func TestChromeReadSTDERR(t *testing.T) {
+	output, err := ioutil.TempFile("", "go-chrome-stderr-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	chrome := New(&Flags{}, "", "", "", "")
+	chrome.stdERRFile = output
+	stderr := "[0101/000000.000000:WARNING:startup.cc(1)] warning\n" +
+		"\n" +
+		"DevTools listening on ws://127.0.0.1:41234/devtools/browser/browser-id\n" +
+		"DevTools listening on ws://127.0.0.1:1/devtools/browser/other-id\n" +
+		"last line"
+
+	listening := make(chan string, 1)
+	exited := make(chan struct{})
+	chrome.readSTDERR(ioutil.NopCloser(strings.NewReader(stderr)), listening, exited)
+
+	if browserURL := <-listening; "ws://127.0.0.1:41234/devtools/browser/browser-id" != browserURL {
+		t.Errorf("Expected 'ws://127.0.0.1:41234/devtools/browser/browser-id', received '%s'", browserURL)
+	}
+	select {
+	case <-exited:
+	default:
+		t.Errorf("Expected exited to be closed")
+	}
+	if data, _ := ioutil.ReadFile(output.Name()); stderr != string(data) {
+		t.Errorf("Expected the error output to be copied, received '%s'", string(data))
+	}
+}
+
+func TestChromeWaitForDevTools(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-chrome-devtools-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "DevToolsActivePort")
+
+	chrome := New(&Flags{"remote-debugging-port": 0}, "", "", "", "")
+	listening := make(chan string, 1)
+	exited := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The host Chromium listens on is replaced with the browser address.
+	listening <- "ws://0.0.0.0:41234/devtools/browser/browser-id"
+	if err := chrome.waitForDevTools(ctx, path, listening, exited); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if 41234 != chrome.Port() {
+		t.Errorf("Expected 41234, received %d", chrome.Port())
+	}
+	if "ws://localhost:41234/devtools/browser/browser-id" != chrome.browserURL {
+		t.Errorf("Expected the browser URL to be set, received '%s'", chrome.browserURL)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ioutil.WriteFile(path, []byte("41235\n/devtools/browser/browser-id"), 0600)
+	}()
+	if err := chrome.waitForDevTools(ctx, path, listening, exited); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if 41235 != chrome.Port() {
+		t.Errorf("Expected 41235, received %d", chrome.Port())
+	}
+	if "ws://localhost:41235/devtools/browser/browser-id" != chrome.browserURL {
+		t.Errorf("Expected the browser URL to be set, received '%s'", chrome.browserURL)
+	}
+	os.Remove(path)
+
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelTimeout()
+	if err := chrome.waitForDevTools(timeout, path, listening, exited); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	close(exited)
+	if err := chrome.waitForDevTools(ctx, path, listening, exited); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
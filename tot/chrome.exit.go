@@ -0,0 +1,195 @@
+package chrome
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+DefaultShutdownTimeout is the time Close waits for Chromium to exit after each
+shutdown step: Browser.close, SIGTERM and SIGKILL.
+*/
+var DefaultShutdownTimeout = 5 * time.Second
+
+/*
+ExitError describes an unexpected Chromium exit.
+*/
+type ExitError struct {
+	// PID is the process ID of the browser process.
+	PID int
+
+	// State is the exit status, such as "exit status 1" or "signal: killed".
+	State string
+
+	// STDERR holds the last lines Chromium wrote to STDERR.
+	STDERR []string
+}
+
+/*
+Error implements error.
+*/
+func (err *ExitError) Error() string {
+	msg := fmt.Sprintf("chromium (pid %d) exited unexpectedly: %s", err.PID, err.State)
+	if 0 < len(err.STDERR) {
+		msg = fmt.Sprintf("%s\n%s", msg, strings.Join(err.STDERR, "\n"))
+	}
+	return msg
+}
+
+/*
+Exited returns a channel that is closed when the Chromium process exits.
+*/
+func (chrome *Chrome) Exited() <-chan struct{} {
+	chrome.mux.Lock()
+	defer chrome.mux.Unlock()
+	if nil == chrome.exited {
+		chrome.exited = make(chan struct{})
+	}
+	return chrome.exited
+}
+
+/*
+OnUnexpectedExit adds a callback that is called when Chromium exits without
+Close being called, for example when it crashes or is killed. Open sockets are
+failed with a socket.ErrorCodeClosed error before the callbacks are called.
+*/
+func (chrome *Chrome) OnUnexpectedExit(callback func(err *ExitError)) {
+	chrome.mux.Lock()
+	defer chrome.mux.Unlock()
+	chrome.exitHandlers = append(chrome.exitHandlers, callback)
+}
+
+/*
+closeBrowser asks Chromium to close gracefully with Browser.close.
+*/
+func (chrome *Chrome) closeBrowser() error {
+	socket, err := chrome.browserSocket()
+	if nil != err {
+		return errs.Wrap(err, 0, "browser connection failed")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+	resultChan := socket.Browser().Close()
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Browser.close failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "Browser.close failed")
+	}
+	return nil
+}
+
+/*
+stop shuts Chromium down, first with Browser.close, then SIGTERM and finally
+SIGKILL, waiting up to DefaultShutdownTimeout after each step. The rest of the
+process group is killed once the browser process has exited.
+*/
+func (chrome *Chrome) stop(exited <-chan struct{}) error {
+	steps := []struct {
+		name string
+		stop func() error
+	}{
+		{"Browser.close", chrome.closeBrowser},
+		{"SIGTERM", func() error { return terminateProcess(chrome.process) }},
+		{"SIGKILL", func() error { return killProcess(chrome.process) }},
+	}
+	for _, step := range steps {
+		select {
+		case <-exited:
+		default:
+			if err := step.stop(); nil != err {
+				log.WithFields(log.Fields{
+					"error": err,
+					"step":  step.name,
+				}).Debug("Chromium shutdown step failed")
+			}
+		}
+		select {
+		case <-exited:
+			// Child processes may outlive the browser process.
+			killProcess(chrome.process)
+			log.WithFields(log.Fields{
+				"step": step.name,
+			}).Info("Chromium exited")
+			return nil
+		case <-time.After(DefaultShutdownTimeout):
+		}
+	}
+	return errs.New(0, fmt.Sprintf("chromium (pid %d) did not exit", chrome.process.Pid))
+}
+
+/*
+watch waits for the Chromium process to exit. Unexpected exits fail the open
+sockets and are reported to the OnUnexpectedExit callbacks.
+*/
+func (chrome *Chrome) watch(process *os.Process, exited chan struct{}, outputClosed <-chan struct{}) {
+	state, err := process.Wait()
+
+	// Let the last output be read, child processes may keep the pipes open.
+	select {
+	case <-outputClosed:
+	case <-time.After(100 * time.Millisecond):
+	}
+	exitErr := &ExitError{
+		PID:    process.Pid,
+		STDERR: chrome.STDERRLines(),
+	}
+	if nil != err {
+		exitErr.State = err.Error()
+	} else {
+		exitErr.State = state.String()
+	}
+
+	chrome.mux.Lock()
+	closing := chrome.closing
+	handlers := make([]func(*ExitError), len(chrome.exitHandlers))
+	copy(handlers, chrome.exitHandlers)
+	chrome.mux.Unlock()
+	close(exited)
+	if closing {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"pid":   exitErr.PID,
+		"state": exitErr.State,
+	}).Error("Chromium exited unexpectedly")
+	if browser := chrome.openBrowserSocket(); nil != browser {
+		browser.Fail(exitErr)
+	}
+	for _, tab := range chrome.Tabs() {
+		failSocket(tab.Socket(), exitErr)
+	}
+	for _, handler := range handlers {
+		handler(exitErr)
+	}
+}
+
+/*
+socketFailer is implemented by sockets that can fail their pending commands,
+such as socket.Socket.
+*/
+type socketFailer interface {
+	Fail(err error) error
+}
+
+/*
+failSocket fails the pending and future commands of a socket, or stops it if it
+can't fail them.
+*/
+func failSocket(sock socket.Socketer, err error) error {
+	if failer, ok := sock.(socketFailer); ok {
+		return failer.Fail(err)
+	}
+	return sock.Stop()
+}
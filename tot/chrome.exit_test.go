@@ -0,0 +1,37 @@
+package chrome
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExitError(t *testing.T) {
+	err := &ExitError{PID: 10, State: "exit status 1"}
+	if "chromium (pid 10) exited unexpectedly: exit status 1" != err.Error() {
+		t.Errorf("Expected the exit state, received '%s'", err.Error())
+	}
+	err.STDERR = []string{"first", "second"}
+	if !strings.HasSuffix(err.Error(), "exit status 1\nfirst\nsecond") {
+		t.Errorf("Expected the error output, received '%s'", err.Error())
+	}
+}
+
+func TestChromeCloseResetsBrowser(t *testing.T) {
+	chrome := New(&Flags{}, "", "", "", "")
+	chrome.setBrowserURL("ws://localhost:9222/devtools/browser/1")
+	browser, err := chrome.browserSocket()
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	chrome.version = &Version{Browser: "Chrome/1.0"}
+	chrome.discovering = true
+
+	chrome.Close()
+	if nil != chrome.openBrowserSocket() || "" != chrome.browserURL || nil != chrome.version || chrome.discovering {
+		t.Errorf("Expected the browser connection and version to be reset")
+	}
+	result := <-browser.Browser().Close()
+	if nil == result.Err {
+		t.Errorf("Expected the old connection to be failed")
+	}
+}
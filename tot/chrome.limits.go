@@ -0,0 +1,93 @@
+package chrome
+
+import (
+	"fmt"
+	"strings"
+
+	errs "github.com/bdlm/errors"
+)
+
+/*
+LimitOptions holds the resource limits applied to a launched browser. Limits
+are only supported on Linux.
+
+The browser is started in the cgroup, the memory limit and niceness are set
+right after it starts with prlimit and setpriority, on the processes it
+already started too. Its zygote, renderer and GPU processes inherit them.
+*/
+type LimitOptions struct {
+	// CGroup is a cgroup v2 directory the browser process is started in,
+	// such as '/sys/fs/cgroup/render/tenant-1'. The directory must exist and
+	// be writable, its controllers enforce the limits for the whole process
+	// tree.
+	CGroup string
+
+	// JSHeap is the maximum size in megabytes of the V8 heap of each renderer,
+	// passed as '--js-flags=--max-old-space-size'.
+	JSHeap int
+
+	// Memory is the maximum size in bytes of the data segment of each
+	// process (RLIMIT_DATA). The address space isn't limited because V8
+	// reserves far more address space than it uses.
+	Memory uint64
+
+	// Nice is the CPU niceness of the browser, from -20 (highest priority) to
+	// 19 (lowest priority). Zero leaves the priority unchanged. Lowering the
+	// niceness requires CAP_SYS_NICE, the launch fails without it.
+	Nice int
+}
+
+/*
+SetLimits sets the resource limits applied by the next launch.
+*/
+func (chrome *Chrome) SetLimits(options *LimitOptions) {
+	chrome.limits = options
+}
+
+/*
+RSS returns the resident memory in bytes of the browser process and all its
+child processes, read from /proc. RSS is only supported on Linux.
+*/
+func (chrome *Chrome) RSS() (uint64, error) {
+	if nil == chrome.process {
+		return 0, errs.New(0, "chromium is not running")
+	}
+	return processTreeRSS(chrome.process.Pid)
+}
+
+/*
+limitFlags adds the JS heap limit to the js-flags flag.
+*/
+func (chrome *Chrome) limitFlags() error {
+	if nil == chrome.limits || 0 >= chrome.limits.JSHeap {
+		return nil
+	}
+	heapFlag := fmt.Sprintf("--max-old-space-size=%d", chrome.limits.JSHeap)
+	jsFlags := []string{}
+	if value, err := chrome.Flags().Get("js-flags"); nil == err {
+		if existing, ok := value.(string); ok {
+			for _, flag := range strings.Fields(existing) {
+				if !strings.HasPrefix(flag, "--max-old-space-size=") {
+					jsFlags = append(jsFlags, flag)
+				}
+			}
+		}
+	}
+	return chrome.Flags().Set("js-flags", strings.Join(append(jsFlags, heapFlag), " "))
+}
+
+/*
+validateLimits checks the resource limits before launching.
+*/
+func validateLimits(options *LimitOptions) error {
+	if nil == options {
+		return nil
+	}
+	if options.Nice < -20 || options.Nice > 19 {
+		return errs.New(0, fmt.Sprintf("invalid niceness %d, expected -20 to 19", options.Nice))
+	}
+	if options.JSHeap < 0 {
+		return errs.New(0, fmt.Sprintf("invalid JS heap limit %d", options.JSHeap))
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+//go:build linux && go1.20
+// +build linux,go1.20
+
+package chrome
+
+import (
+	"fmt"
+	"syscall"
+
+	errs "github.com/bdlm/errors"
+)
+
+/*
+moveToCGroup does nothing, the process was started in the cgroup.
+*/
+func moveToCGroup(pid int, dir string) error {
+	return nil
+}
+
+/*
+setCGroup starts the process directly in a cgroup, see CLONE_INTO_CGROUP. It
+returns a function closing the cgroup directory once the process started.
+*/
+func setCGroup(attributes *syscall.SysProcAttr, dir string) (func(), error) {
+	fd, err := syscall.Open(dir, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, fmt.Sprintf("cannot open cgroup '%s'", dir))
+	}
+	attributes.UseCgroupFD = true
+	attributes.CgroupFD = fd
+	return func() { syscall.Close(fd) }, nil
+}
@@ -0,0 +1,37 @@
+//go:build linux && !go1.20
+// +build linux,!go1.20
+
+package chrome
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	errs "github.com/bdlm/errors"
+)
+
+/*
+moveToCGroup moves a started process into a cgroup, starting a process in a
+cgroup requires Go 1.20.
+*/
+func moveToCGroup(pid int, dir string) error {
+	procs := filepath.Join(dir, "cgroup.procs")
+	if err := ioutil.WriteFile(procs, []byte(strconv.Itoa(pid)), 0644); nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("cannot move chromium into cgroup '%s'", dir))
+	}
+	return nil
+}
+
+/*
+setCGroup checks the cgroup exists, the process is moved into it once started.
+*/
+func setCGroup(attributes *syscall.SysProcAttr, dir string) (func(), error) {
+	if _, err := os.Stat(filepath.Join(dir, "cgroup.procs")); nil != err {
+		return nil, errs.Wrap(err, 0, fmt.Sprintf("cannot open cgroup '%s'", dir))
+	}
+	return func() {}, nil
+}
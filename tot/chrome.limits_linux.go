@@ -0,0 +1,166 @@
+//go:build linux
+// +build linux
+
+package chrome
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	errs "github.com/bdlm/errors"
+)
+
+/*
+procDir is the mount point of the proc filesystem.
+*/
+var procDir = "/proc"
+
+/*
+limitProcess sets up the resource limits applied before a browser starts. The
+browser is started in the cgroup, it returns a function releasing the
+resources held until the process started.
+*/
+func limitProcess(attributes *syscall.SysProcAttr, options *LimitOptions) (func(), error) {
+	if nil == options || "" == options.CGroup {
+		return func() {}, nil
+	}
+	return setCGroup(attributes, options.CGroup)
+}
+
+/*
+applyLimits applies the resource limits to a started browser. The niceness is
+set on the browser process group, and the memory limit on the browser and the
+processes it already started, the processes it starts later inherit both.
+*/
+func applyLimits(process *os.Process, options *LimitOptions) error {
+	if nil == options {
+		return nil
+	}
+	if "" != options.CGroup {
+		if err := moveToCGroup(process.Pid, options.CGroup); nil != err {
+			return err
+		}
+	}
+	if 0 != options.Nice {
+		if err := syscall.Setpriority(syscall.PRIO_PGRP, process.Pid, options.Nice); nil != err {
+			return errs.Wrap(err, 0, fmt.Sprintf("cannot set the chromium niceness to %d", options.Nice))
+		}
+	}
+	if 0 < options.Memory {
+		pids, err := processTree(process.Pid)
+		if nil != err {
+			return err
+		}
+		limit := syscall.Rlimit{Cur: options.Memory, Max: options.Memory}
+		for _, pid := range pids {
+			if _, _, errno := syscall.RawSyscall6(
+				syscall.SYS_PRLIMIT64,
+				uintptr(pid),
+				uintptr(syscall.RLIMIT_DATA),
+				uintptr(unsafe.Pointer(&limit)),
+				0, 0, 0,
+			); 0 != errno && (syscall.ESRCH != errno || process.Pid == pid) {
+				return errs.Wrap(errno, 0, "cannot set the chromium memory limit")
+			}
+		}
+	}
+	return nil
+}
+
+/*
+processTree returns a process and its descendants, the process first.
+*/
+func processTree(pid int) ([]int, error) {
+	entries, err := ioutil.ReadDir(procDir)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, fmt.Sprintf("cannot read '%s'", procDir))
+	}
+	children := map[int][]int{}
+	for _, entry := range entries {
+		child, err := strconv.Atoi(entry.Name())
+		if nil != err {
+			continue
+		}
+		parent, err := processParent(child)
+		if nil != err {
+			// The process exited.
+			continue
+		}
+		children[parent] = append(children[parent], child)
+	}
+
+	pids := []int{pid}
+	for k := 0; k < len(pids); k++ {
+		pids = append(pids, children[pids[k]]...)
+	}
+	return pids, nil
+}
+
+/*
+processTreeRSS returns the resident memory in bytes of a process and its
+descendants.
+*/
+func processTreeRSS(pid int) (uint64, error) {
+	pids, err := processTree(pid)
+	if nil != err {
+		return 0, err
+	}
+
+	var total uint64
+	for _, child := range pids {
+		rss, err := processRSS(child)
+		if nil != err {
+			if pid == child {
+				return 0, err
+			}
+			continue
+		}
+		total += rss
+	}
+	return total, nil
+}
+
+/*
+processParent returns the parent PID of a process from /proc/<pid>/stat.
+*/
+func processParent(pid int) (int, error) {
+	data, err := ioutil.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "stat"))
+	if nil != err {
+		return 0, err
+	}
+	// The command name may contain spaces and parentheses, the fields after
+	// it start with the state and the parent PID.
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 2 {
+		return 0, errs.New(0, fmt.Sprintf("invalid stat for process %d", pid))
+	}
+	return strconv.Atoi(fields[1])
+}
+
+/*
+processRSS returns the resident memory in bytes of a process from
+/proc/<pid>/statm.
+*/
+func processRSS(pid int) (uint64, error) {
+	path := filepath.Join(procDir, strconv.Itoa(pid), "statm")
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		return 0, errs.Wrap(err, 0, fmt.Sprintf("cannot read '%s'", path))
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, errs.New(0, fmt.Sprintf("invalid '%s'", path))
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if nil != err {
+		return 0, errs.Wrap(err, 0, fmt.Sprintf("invalid '%s'", path))
+	}
+	return pages * uint64(os.Getpagesize()), nil
+}
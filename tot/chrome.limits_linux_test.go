@@ -0,0 +1,184 @@
+//go:build linux
+// +build linux
+
+package chrome
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestProcessTreeRSS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-chrome-proc-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	process := func(pid, ppid, pages int) {
+		path := filepath.Join(dir, strconv.Itoa(pid))
+		os.MkdirAll(path, 0700)
+		ioutil.WriteFile(filepath.Join(path, "stat"), []byte(strconv.Itoa(pid)+" (chrome (type) x) S "+strconv.Itoa(ppid)+" 1 1 0"), 0600)
+		ioutil.WriteFile(filepath.Join(path, "statm"), []byte("1000 "+strconv.Itoa(pages)+" 10 1 0 100 0"), 0600)
+	}
+	process(1, 0, 1)
+	process(10, 1, 2)
+	process(11, 10, 3)
+	process(12, 11, 4)
+	process(20, 1, 100)
+	os.MkdirAll(filepath.Join(dir, "self"), 0700)
+
+	defer func(dir string) { procDir = dir }(procDir)
+	procDir = dir
+	rss, err := processTreeRSS(10)
+	if nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if expected := uint64(9 * os.Getpagesize()); expected != rss {
+		t.Errorf("Expected %d, received %d", expected, rss)
+	}
+	if _, err := processTreeRSS(30); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestApplyLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-chrome-limits-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	pidFile := filepath.Join(dir, "pid")
+
+	if release, err := limitProcess(processAttributes(), &LimitOptions{CGroup: filepath.Join(dir, "missing")}); nil == err || nil != release {
+		t.Errorf("Expected error, received nil")
+	}
+
+	// The browser forks a child before the limits are applied, and another
+	// one after.
+	process := startProcess(t, "sleep 10 & echo $! > "+pidFile+"; sleep 0.5; sleep 10 & echo $! >> "+pidFile+"; wait")
+	defer func() {
+		signalProcessGroup(process, syscall.SIGKILL)
+		process.Wait()
+	}()
+	waitForPID(t, pidFile)
+	if err := applyLimits(process, nil); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if err := applyLimits(process, &LimitOptions{
+		Memory: 1 << 30,
+		Nice:   19,
+	}); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+
+	var children []string
+	for a := 0; a < 100 && len(children) < 2; a++ {
+		time.Sleep(20 * time.Millisecond)
+		data, _ := ioutil.ReadFile(pidFile)
+		children = strings.Fields(string(data))
+	}
+	if 2 != len(children) {
+		t.Fatalf("Expected 2 children, received %v", children)
+	}
+	for _, pid := range append([]string{strconv.Itoa(process.Pid)}, children...) {
+		limits, _ := ioutil.ReadFile(filepath.Join("/proc", pid, "limits"))
+		found := false
+		for _, line := range strings.Split(string(limits), "\n") {
+			if strings.HasPrefix(line, "Max data size") {
+				found = strings.Contains(line, "1073741824")
+			}
+		}
+		if !found {
+			t.Errorf("Expected the memory limit to be set on process %s, received '%s'", pid, string(limits))
+		}
+		// getpriority returns 20 - niceness.
+		child, _ := strconv.Atoi(pid)
+		if priority, err := syscall.Getpriority(syscall.PRIO_PROCESS, child); nil != err || 1 != priority {
+			t.Errorf("Expected niceness 19 for process %s, received %d (%v)", pid, 20-priority, err)
+		}
+	}
+}
+
+func TestLimitProcessCGroup(t *testing.T) {
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); nil != err {
+		t.Skip("cgroup v2 is not mounted on /sys/fs/cgroup")
+	}
+	cgroup, err := ioutil.TempDir("/sys/fs/cgroup", "go-chrome-")
+	if nil != err {
+		t.Skipf("cannot create a cgroup: %s", err)
+	}
+	defer os.Remove(cgroup)
+	dir, err := ioutil.TempDir("", "go-chrome-limits-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	pidFile := filepath.Join(dir, "pid")
+
+	attributes := processAttributes()
+	release, err := limitProcess(attributes, &LimitOptions{CGroup: cgroup})
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	process, err := os.StartProcess(
+		"/bin/sh",
+		[]string{"/bin/sh", "-c", "sleep 0.5; sleep 10 & echo $! > " + pidFile + "; wait"},
+		&os.ProcAttr{Sys: attributes},
+	)
+	release()
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer func() {
+		signalProcessGroup(process, syscall.SIGKILL)
+		process.Wait()
+	}()
+	if err := applyLimits(process, &LimitOptions{CGroup: cgroup}); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+
+	for _, pid := range []int{process.Pid, waitForPID(t, pidFile)} {
+		data, _ := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cgroup"))
+		if !strings.Contains(string(data), "::/"+filepath.Base(cgroup)) {
+			t.Errorf("Expected process %d in the cgroup, received '%s'", pid, string(data))
+		}
+	}
+}
+
+func waitForPID(t *testing.T, path string) int {
+	for a := 0; a < 100; a++ {
+		data, _ := ioutil.ReadFile(path)
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); nil == err {
+			return pid
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("Expected a PID to be written to '%s'", path)
+	return 0
+}
+
+func TestPoolBloated(t *testing.T) {
+	self, _ := os.FindProcess(os.Getpid())
+	browser := &pooledBrowser{chrome: New(&Flags{}, "", "", "", "")}
+	browser.chrome.process = self
+
+	pool := newPool(&PoolOptions{})
+	defer pool.Close()
+	if pool.bloated(browser) {
+		t.Errorf("Expected no memory limit")
+	}
+	pool.options.MaxRSS = 1
+	if !pool.bloated(browser) {
+		t.Errorf("Expected the browser to exceed the memory limit")
+	}
+	pool.options.MaxRSS = 1 << 50
+	if pool.bloated(browser) {
+		t.Errorf("Expected the browser not to exceed the memory limit")
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !linux
+// +build !linux
+
+package chrome
+
+import (
+	"os"
+	"syscall"
+
+	errs "github.com/bdlm/errors"
+)
+
+/*
+applyLimits does nothing, limitProcess rejects the limits.
+*/
+func applyLimits(process *os.Process, options *LimitOptions) error {
+	return nil
+}
+
+/*
+limitProcess returns an error if resource limits are set, they are only
+supported on Linux.
+*/
+func limitProcess(attributes *syscall.SysProcAttr, options *LimitOptions) (func(), error) {
+	if nil == options || (LimitOptions{JSHeap: options.JSHeap}) == *options {
+		return func() {}, nil
+	}
+	return nil, errs.New(0, "resource limits are only supported on Linux")
+}
+
+/*
+processTreeRSS returns an error, /proc is only available on Linux.
+*/
+func processTreeRSS(pid int) (uint64, error) {
+	return 0, errs.New(0, "RSS is only supported on Linux")
+}
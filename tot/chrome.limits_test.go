@@ -0,0 +1,47 @@
+package chrome
+
+import (
+	"testing"
+)
+
+func TestChromeLimitFlags(t *testing.T) {
+	chrome := New(&Flags{"js-flags": "--expose-gc --max-old-space-size=64"}, "", "", "", "")
+	if err := chrome.limitFlags(); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if value, _ := chrome.Flags().Get("js-flags"); "--expose-gc --max-old-space-size=64" != value {
+		t.Errorf("Expected the flags to be unchanged, received '%v'", value)
+	}
+
+	chrome.SetLimits(&LimitOptions{JSHeap: 512})
+	if err := chrome.limitFlags(); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if value, _ := chrome.Flags().Get("js-flags"); "--expose-gc --max-old-space-size=512" != value {
+		t.Errorf("Expected '--expose-gc --max-old-space-size=512', received '%v'", value)
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	for options, valid := range map[*LimitOptions]bool{
+		nil:               true,
+		{}:                true,
+		{Nice: 19}:        true,
+		{Nice: -21}:       false,
+		{Nice: 20}:        false,
+		{JSHeap: -1}:      false,
+		{JSHeap: 1024}:    true,
+		{Memory: 1 << 30}: true,
+	} {
+		if err := validateLimits(options); valid != (nil == err) {
+			t.Errorf("Expected %+v to be valid: %v, received '%v'", options, valid, err)
+		}
+	}
+}
+
+func TestChromeRSS(t *testing.T) {
+	chrome := New(&Flags{}, "", "", "", "")
+	if _, err := chrome.RSS(); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
@@ -0,0 +1,334 @@
+package chrome
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+)
+
+/*
+DefaultOutputLines is the number of lines of each output stream kept in memory
+when OutputOptions.Lines isn't set.
+*/
+var DefaultOutputLines = 50
+
+/*
+logLinePattern matches Chromium log lines such as
+'[1234:5678:0101/000000.000000:WARNING:startup.cc(1)] message'. The process and
+thread IDs are optional.
+*/
+var logLinePattern = regexp.MustCompile(`^\[(?:(\d+):(\d+):)?(\d{4}/\d{6}\.\d+):([A-Z]+[0-9]*):([^\]]+)\((\d+)\)\] ?(.*)$`)
+
+/*
+OutputOptions holds the settings for capturing Chromium's STDOUT and STDERR
+output.
+
+The output is always read from pipes and kept in memory, see STDOUTLines and
+STDERRLines. It is also copied to the STDOUT and STDERR files passed to New or,
+if no file and no Writer is set, to the system STDOUT and STDERR.
+*/
+type OutputOptions struct {
+	// Lines is the number of lines of each stream kept in memory. Defaults to
+	// DefaultOutputLines.
+	Lines int
+
+	// Log writes Chromium log lines to the logger as structured entries with
+	// the level, source file and line, process and thread ID of the message.
+	Log bool
+
+	// Writer receives a copy of both streams.
+	Writer io.Writer
+}
+
+/*
+LogLine is a parsed Chromium log line.
+*/
+type LogLine struct {
+	// File is the source file that logged the message.
+	File string
+
+	// Level is the log level, such as INFO, WARNING, ERROR, FATAL or VERBOSE1.
+	Level string
+
+	// Line is the source line that logged the message.
+	Line int
+
+	// Message is the log message.
+	Message string
+
+	// PID is the ID of the logging process, if present.
+	PID int
+
+	// TID is the ID of the logging thread, if present.
+	TID int
+
+	// Time is the timestamp in MMDD/HHMMSS.microseconds format.
+	Time string
+}
+
+/*
+ParseLogLine parses a Chromium log line. The second return value is false if
+the line isn't a log line.
+*/
+func ParseLogLine(line string) (*LogLine, bool) {
+	matches := logLinePattern.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if nil == matches {
+		return nil, false
+	}
+	logLine := &LogLine{
+		File:    matches[5],
+		Level:   matches[4],
+		Message: matches[7],
+		Time:    matches[3],
+	}
+	logLine.PID, _ = strconv.Atoi(matches[1])
+	logLine.TID, _ = strconv.Atoi(matches[2])
+	logLine.Line, _ = strconv.Atoi(matches[6])
+	return logLine, true
+}
+
+/*
+SetOutput sets the output capture settings used by the next launch.
+*/
+func (chrome *Chrome) SetOutput(options *OutputOptions) {
+	chrome.output = options
+}
+
+/*
+STDERRLines returns the last lines Chromium wrote to STDERR.
+*/
+func (chrome *Chrome) STDERRLines() []string {
+	chrome.mux.Lock()
+	buffer := chrome.stderrBuffer
+	chrome.mux.Unlock()
+	return buffer.Lines()
+}
+
+/*
+STDOUTLines returns the last lines Chromium wrote to STDOUT.
+*/
+func (chrome *Chrome) STDOUTLines() []string {
+	chrome.mux.Lock()
+	buffer := chrome.stdoutBuffer
+	chrome.mux.Unlock()
+	return buffer.Lines()
+}
+
+/*
+closeOutput closes the output files. The system STDOUT and STDERR are left
+open.
+*/
+func (chrome *Chrome) closeOutput() {
+	for _, file := range []*os.File{chrome.stdOUTFile, chrome.stdERRFile} {
+		if nil != file && os.Stdout != file && os.Stderr != file {
+			file.Close()
+		}
+	}
+	chrome.stdOUTFile = nil
+	chrome.stdERRFile = nil
+}
+
+/*
+logOutput writes a Chromium log line to the logger. Other output is logged at
+the debug level.
+*/
+func logOutput(stream, line string) {
+	logLine, ok := ParseLogLine(line)
+	if !ok {
+		log.WithFields(log.Fields{
+			"stream": stream,
+		}).Debug(strings.TrimRight(line, "\r\n"))
+		return
+	}
+	entry := log.WithFields(log.Fields{
+		"file":   logLine.File,
+		"level":  logLine.Level,
+		"line":   logLine.Line,
+		"pid":    logLine.PID,
+		"stream": stream,
+		"tid":    logLine.TID,
+		"time":   logLine.Time,
+	})
+	switch logLine.Level {
+	case "INFO":
+		entry.Info(logLine.Message)
+	case "WARNING":
+		entry.Warn(logLine.Message)
+	case "ERROR", "FATAL":
+		entry.Error(logLine.Message)
+	default:
+		entry.Debug(logLine.Message)
+	}
+}
+
+/*
+openOutput opens the output files and creates the output buffers for a launch.
+*/
+func (chrome *Chrome) openOutput() (err error) {
+	options := chrome.output
+	if nil == options {
+		options = &OutputOptions{}
+	}
+	lines := options.Lines
+	if 0 >= lines {
+		lines = DefaultOutputLines
+	}
+	chrome.mux.Lock()
+	chrome.stderrBuffer = newOutputBuffer(lines)
+	chrome.stdoutBuffer = newOutputBuffer(lines)
+	chrome.mux.Unlock()
+
+	open := func(path string, std *os.File) (*os.File, error) {
+		if "" != path {
+			return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+		}
+		if nil == options.Writer {
+			return std, nil
+		}
+		return nil, nil
+	}
+	if chrome.stdERRFile, err = open(chrome.STDERR(), os.Stderr); nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("cannot open error output file '%s'", chrome.STDERR()))
+	}
+	if chrome.stdOUTFile, err = open(chrome.STDOUT(), os.Stdout); nil != err {
+		chrome.closeOutput()
+		return errs.Wrap(err, 0, fmt.Sprintf("cannot open standard output file '%s'", chrome.STDOUT()))
+	}
+	return nil
+}
+
+/*
+readOutput reads an output stream line by line until it is closed. Each line is
+kept in the buffer, copied to the file and the Writer, logged if enabled and
+passed to handle.
+*/
+func (chrome *Chrome) readOutput(
+	stream string,
+	reader io.ReadCloser,
+	file *os.File,
+	buffer *outputBuffer,
+	handle func(line string),
+) {
+	defer reader.Close()
+
+	options := chrome.output
+	if nil == options {
+		options = &OutputOptions{}
+	}
+	lines := bufio.NewReader(reader)
+	for {
+		line, err := lines.ReadString('\n')
+		if "" != line {
+			buffer.add(strings.TrimRight(line, "\r\n"))
+			if nil != file {
+				file.WriteString(line)
+			}
+			if nil != options.Writer {
+				chrome.writeOutput(options.Writer, line)
+			}
+			if options.Log {
+				logOutput(stream, line)
+			}
+			if nil != handle {
+				handle(line)
+			}
+		}
+		if nil != err {
+			if io.EOF != err {
+				log.WithFields(log.Fields{
+					"error":  err,
+					"stream": stream,
+				}).Warn("could not read Chromium output")
+			}
+			return
+		}
+	}
+}
+
+/*
+readSTDOUT reads Chromium's STDOUT output. done is closed when STDOUT is closed.
+*/
+func (chrome *Chrome) readSTDOUT(reader io.ReadCloser, done chan<- struct{}) {
+	defer close(done)
+	chrome.mux.Lock()
+	buffer := chrome.stdoutBuffer
+	chrome.mux.Unlock()
+	chrome.readOutput("stdout", reader, chrome.stdOUTFile, buffer, nil)
+}
+
+/*
+writeOutput copies a line to the Writer. Lines from both streams are written
+one at a time.
+*/
+func (chrome *Chrome) writeOutput(writer io.Writer, line string) {
+	chrome.outputMux.Lock()
+	defer chrome.outputMux.Unlock()
+	if _, err := io.WriteString(writer, line); nil != err {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("could not copy Chromium output")
+	}
+}
+
+/*
+outputBuffer keeps the last lines of an output stream.
+*/
+type outputBuffer struct {
+	// lines holds the kept lines.
+	lines []string
+
+	// mux protects lines.
+	mux *sync.Mutex
+
+	// size is the number of lines kept.
+	size int
+}
+
+/*
+newOutputBuffer returns an outputBuffer that keeps size lines.
+*/
+func newOutputBuffer(size int) *outputBuffer {
+	return &outputBuffer{
+		lines: make([]string, 0, size),
+		mux:   &sync.Mutex{},
+		size:  size,
+	}
+}
+
+/*
+add adds a line, dropping the oldest line when the buffer is full.
+*/
+func (buffer *outputBuffer) add(line string) {
+	if nil == buffer {
+		return
+	}
+	buffer.mux.Lock()
+	defer buffer.mux.Unlock()
+	if len(buffer.lines) == buffer.size {
+		copy(buffer.lines, buffer.lines[1:])
+		buffer.lines = buffer.lines[:len(buffer.lines)-1]
+	}
+	buffer.lines = append(buffer.lines, line)
+}
+
+/*
+Lines returns a copy of the kept lines, oldest first.
+*/
+func (buffer *outputBuffer) Lines() []string {
+	if nil == buffer {
+		return []string{}
+	}
+	buffer.mux.Lock()
+	defer buffer.mux.Unlock()
+	lines := make([]string, len(buffer.lines))
+	copy(lines, buffer.lines)
+	return lines
+}
@@ -0,0 +1,127 @@
+package chrome
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLine(t *testing.T) {
+	logLine, ok := ParseLogLine("[1234:5678:0101/101010.123456:WARNING:startup_browser_creator.cc(42)] the message\n")
+	if !ok {
+		t.Fatalf("Expected a log line")
+	}
+	expected := LogLine{
+		File:    "startup_browser_creator.cc",
+		Level:   "WARNING",
+		Line:    42,
+		Message: "the message",
+		PID:     1234,
+		TID:     5678,
+		Time:    "0101/101010.123456",
+	}
+	if expected != *logLine {
+		t.Errorf("Expected %+v, received %+v", expected, *logLine)
+	}
+
+	logLine, ok = ParseLogLine("[0101/101010.123456:VERBOSE1:gpu_init.cc(7)] verbose")
+	if !ok || "VERBOSE1" != logLine.Level || 0 != logLine.PID || "verbose" != logLine.Message {
+		t.Errorf("Expected a log line without a PID, received %+v", logLine)
+	}
+
+	for _, line := range []string{
+		"",
+		"DevTools listening on ws://127.0.0.1:9222/devtools/browser/browser-id",
+		"[0101/101010:INFO:file.cc(1)] no microseconds",
+	} {
+		if _, ok := ParseLogLine(line); ok {
+			t.Errorf("Expected '%s' not to be a log line", line)
+		}
+	}
+}
+
+func TestOutputBuffer(t *testing.T) {
+	buffer := newOutputBuffer(3)
+	for a := 0; a < 5; a++ {
+		buffer.add(fmt.Sprintf("line %d", a))
+	}
+	if lines := buffer.Lines(); "line 2,line 3,line 4" != strings.Join(lines, ",") {
+		t.Errorf("Expected the last 3 lines, received %v", lines)
+	}
+
+	buffer = nil
+	buffer.add("line")
+	if 0 != len(buffer.Lines()) {
+		t.Errorf("Expected no lines")
+	}
+}
+
+func TestChromeReadOutput(t *testing.T) {
+	output, err := ioutil.TempFile("", "go-chrome-stdout-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	writer := &bytes.Buffer{}
+	chrome := New(&Flags{}, "", "", "", "")
+	chrome.SetOutput(&OutputOptions{Lines: 2, Log: true, Writer: writer})
+	if err := chrome.openOutput(); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if nil != chrome.stdOUTFile || nil != chrome.stdERRFile {
+		t.Errorf("Expected the system output not to be used with a Writer")
+	}
+
+	stdout := "first\n[1:2:0101/000000.000000:ERROR:gpu.cc(1)] failed\nlast"
+	handled := []string{}
+	chrome.readOutput("stdout", ioutil.NopCloser(strings.NewReader(stdout)), output, chrome.stdoutBuffer, func(line string) {
+		handled = append(handled, line)
+	})
+	if 3 != len(handled) {
+		t.Errorf("Expected 3 lines to be handled, received %d", len(handled))
+	}
+	if lines := chrome.STDOUTLines(); 2 != len(lines) || "last" != lines[1] {
+		t.Errorf("Expected the last 2 lines, received %v", lines)
+	}
+	if stdout != writer.String() {
+		t.Errorf("Expected the output to be copied to the Writer, received '%s'", writer.String())
+	}
+	if data, _ := ioutil.ReadFile(output.Name()); stdout != string(data) {
+		t.Errorf("Expected the output to be copied to the file, received '%s'", string(data))
+	}
+}
+
+func TestChromeCloseOutput(t *testing.T) {
+	output, err := ioutil.TempFile("", "go-chrome-stderr-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	output.Close()
+	defer os.Remove(output.Name())
+
+	chrome := New(&Flags{}, "", "", "", output.Name())
+	if err := chrome.openOutput(); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if os.Stdout != chrome.stdOUTFile {
+		t.Errorf("Expected the system STDOUT to be used")
+	}
+	stderr := chrome.stdERRFile
+	chrome.closeOutput()
+	if _, err := stderr.WriteString("closed"); nil == err {
+		t.Errorf("Expected the error output file to be closed")
+	}
+	if _, err := os.Stdout.Stat(); nil != err {
+		t.Errorf("Expected the system STDOUT to be left open, received '%s'", err)
+	}
+
+	chrome = New(&Flags{}, "", "", "", "/nonexistent/stderr.log")
+	if err := chrome.openOutput(); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
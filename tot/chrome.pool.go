@@ -0,0 +1,622 @@
+package chrome
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+	"github.com/mkenney/go-chrome/tot/inspector"
+	"github.com/mkenney/go-chrome/tot/memory"
+)
+
+/*
+poolRetryDelay is the delay between attempts to launch a replacement browser.
+*/
+const poolRetryDelay = time.Second
+
+/*
+PoolOptions holds the settings for a Pool.
+*/
+type PoolOptions struct {
+	// Browsers is the number of browsers kept running. Defaults to 1.
+	Browsers int
+
+	// HealthCheckInterval is how often each browser is checked with
+	// Browser.getVersion. Browsers that fail the check are recycled. Defaults
+	// to 30 seconds, a negative value disables health checks.
+	HealthCheckInterval time.Duration
+
+	// HealthCheckTimeout is how long a health check or a memory measurement
+	// may take. Defaults to 5 seconds.
+	HealthCheckTimeout time.Duration
+
+	// Isolated opens each leased tab in its own browser context, which is
+	// disposed along with its cookies and storage when the lease is released.
+	Isolated bool
+
+	// MaxConcurrency is the maximum number of leases held at a time. Lease
+	// blocks until a lease is released. Defaults to Browsers.
+	MaxConcurrency int
+
+	// MaxDOMNodes recycles a browser when a released tab's renderer reports
+	// more DOM nodes than this with Memory.getDOMCounters. Leaked nodes outlive
+	// the pages that created them, so growth is a sign of a leaking renderer.
+	// Zero disables the check.
+	MaxDOMNodes int
+
+	// MaxRSS recycles a browser when the resident memory of its process tree
+	// exceeds this many bytes, checked with each health check. RSS is only
+	// available on Linux. Zero disables the check.
+	MaxRSS uint64
+
+	// MaxUses recycles a browser after it has served this many leases. Zero
+	// disables the limit.
+	MaxUses int
+
+	// New returns a browser to be launched by the pool. Each browser needs its
+	// own remote debugging port and profile, use an ephemeral port and the
+	// default temporary profile. Required.
+	New func() (*Chrome, error)
+}
+
+/*
+NewPool launches a pool of browsers and keeps them running until Close is
+called. Tabs are handed out with Lease and browsers are replaced when they fail
+a health check, crash, or reach their use or memory limits:
+
+	pool, err := chrome.NewPool(ctx, &chrome.PoolOptions{
+		Browsers:       4,
+		MaxConcurrency: 16,
+		MaxUses:        100,
+		New: func() (*chrome.Chrome, error) {
+			return chrome.New(&chrome.Flags{
+				"headless":              nil,
+				"remote-debugging-port": 0,
+			}, "", "", "", ""), nil
+		},
+	})
+	...
+	defer pool.Close()
+
+	lease, err := pool.Lease(ctx)
+	...
+	defer lease.Release()
+	lease.Tab().Page().Navigate(...)
+*/
+func NewPool(ctx context.Context, options *PoolOptions) (*Pool, error) {
+	if nil == options || nil == options.New {
+		return nil, errs.New(0, "a browser constructor is required")
+	}
+	opts := *options
+	if opts.Browsers < 1 {
+		opts.Browsers = 1
+	}
+	if 0 == opts.HealthCheckInterval {
+		opts.HealthCheckInterval = 30 * time.Second
+	}
+	if opts.HealthCheckTimeout <= 0 {
+		opts.HealthCheckTimeout = 5 * time.Second
+	}
+	if opts.MaxConcurrency < 1 {
+		opts.MaxConcurrency = opts.Browsers
+	}
+
+	pool := newPool(&opts)
+	for a := 0; a < opts.Browsers; a++ {
+		if err := ctx.Err(); nil != err {
+			pool.Close()
+			return nil, errs.Wrap(err, 0, "pool startup interrupted")
+		}
+		browser, err := pool.launch(ctx)
+		if nil != err {
+			pool.Close()
+			return nil, err
+		}
+		pool.add(browser)
+	}
+	if opts.HealthCheckInterval > 0 {
+		go pool.healthCheck()
+	}
+	return pool, nil
+}
+
+/*
+newPool returns an empty pool.
+*/
+func newPool(options *PoolOptions) *Pool {
+	return &Pool{
+		browsers: []*pooledBrowser{},
+		changed:  make(chan struct{}),
+		done:     make(chan struct{}),
+		mux:      &sync.Mutex{},
+		options:  options,
+		slots:    make(chan struct{}, options.MaxConcurrency),
+	}
+}
+
+/*
+Pool keeps a set of browsers running and leases tabs from them.
+*/
+type Pool struct {
+	// browsers holds the running browsers, including retired browsers that
+	// still have leases.
+	browsers []*pooledBrowser
+
+	// changed is closed and replaced when a browser is added to the pool.
+	changed chan struct{}
+
+	// closed is set when the pool has been closed.
+	closed bool
+
+	// done is closed when the pool is closed.
+	done chan struct{}
+
+	// mux protects browsers, changed, closed and the browser counters.
+	mux *sync.Mutex
+
+	// options holds the pool settings.
+	options *PoolOptions
+
+	// slots holds a value for each lease, limiting concurrency.
+	slots chan struct{}
+}
+
+/*
+pooledBrowser is a browser managed by a Pool.
+*/
+type pooledBrowser struct {
+	// chrome is the browser.
+	chrome *Chrome
+
+	// leases is the number of open leases.
+	leases int
+
+	// retired is set when the browser should be recycled once its leases
+	// have been released.
+	retired bool
+
+	// uses is the number of leases the browser has served.
+	uses int
+}
+
+/*
+Close closes all browsers in the pool. Open leases are not closed, but fail
+once their browser is gone.
+*/
+func (pool *Pool) Close() error {
+	pool.mux.Lock()
+	if pool.closed {
+		pool.mux.Unlock()
+		return nil
+	}
+	pool.closed = true
+	close(pool.done)
+	browsers := pool.browsers
+	pool.browsers = []*pooledBrowser{}
+	pool.notify()
+	pool.mux.Unlock()
+
+	var closeErr error
+	for _, browser := range browsers {
+		if err := browser.chrome.Close(); nil != err && nil == closeErr {
+			closeErr = err
+		}
+	}
+	if nil != closeErr {
+		return errs.Wrap(closeErr, 0, "could not close all pooled browsers")
+	}
+	return nil
+}
+
+/*
+Lease opens a tab in the least busy browser. It blocks until the number of open
+leases is below PoolOptions.MaxConcurrency and a browser is available. The lease
+must be released when it is no longer needed.
+*/
+func (pool *Pool) Lease(ctx context.Context) (*Lease, error) {
+	select {
+	case pool.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, errs.Wrap(ctx.Err(), 0, "no lease available")
+	}
+
+	browser, err := pool.acquire(ctx)
+	if nil != err {
+		<-pool.slots
+		return nil, err
+	}
+
+	lease := &Lease{
+		browser: browser,
+		once:    &sync.Once{},
+		pool:    pool,
+	}
+	if err := lease.open(ctx); nil != err {
+		lease.Release()
+		return nil, err
+	}
+	return lease, nil
+}
+
+/*
+acquire reserves the running browser with the fewest leases, waiting for a
+replacement if all browsers are being recycled.
+*/
+func (pool *Pool) acquire(ctx context.Context) (*pooledBrowser, error) {
+	for {
+		pool.mux.Lock()
+		if pool.closed {
+			pool.mux.Unlock()
+			return nil, errs.New(0, "pool is closed")
+		}
+		var browser *pooledBrowser
+		for _, b := range pool.browsers {
+			if !b.retired && (nil == browser || b.leases < browser.leases) {
+				browser = b
+			}
+		}
+		if nil != browser {
+			browser.leases++
+			browser.uses++
+			if 0 < pool.options.MaxUses && browser.uses >= pool.options.MaxUses {
+				pool.retire(browser, "use limit reached")
+			}
+			pool.mux.Unlock()
+			return browser, nil
+		}
+		changed := pool.changed
+		pool.mux.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, errs.Wrap(ctx.Err(), 0, "no browser available")
+		}
+	}
+}
+
+/*
+add adds a running browser to the pool and wakes waiting leases. The browser is
+closed if the pool has been closed.
+*/
+func (pool *Pool) add(browser *pooledBrowser) {
+	pool.mux.Lock()
+	if pool.closed {
+		pool.mux.Unlock()
+		browser.chrome.Close()
+		return
+	}
+	pool.browsers = append(pool.browsers, browser)
+	pool.notify()
+	pool.mux.Unlock()
+}
+
+/*
+check reports whether a browser responds to Browser.getVersion.
+*/
+func (pool *Pool) check(browser *pooledBrowser) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pool.options.HealthCheckTimeout)
+	defer cancel()
+
+	socket, err := browser.chrome.browserSocket()
+	if nil != err {
+		return errs.Wrap(err, 0, "browser connection failed")
+	}
+	resultChan := socket.Browser().GetVersion()
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Browser.getVersion failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "Browser.getVersion failed")
+	}
+	return nil
+}
+
+/*
+bloated reports whether a browser's process tree uses more resident memory than
+the MaxRSS limit.
+*/
+func (pool *Pool) bloated(browser *pooledBrowser) bool {
+	if 0 == pool.options.MaxRSS {
+		return false
+	}
+	rss, err := browser.chrome.RSS()
+	if nil != err {
+		log.WithFields(log.Fields{
+			"error": err,
+			"port":  browser.chrome.Port(),
+		}).Warn("could not measure pooled browser memory")
+		return false
+	}
+	return rss > pool.options.MaxRSS
+}
+
+/*
+healthCheck checks the running browsers until the pool is closed.
+*/
+func (pool *Pool) healthCheck() {
+	ticker := time.NewTicker(pool.options.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-pool.done:
+			return
+		}
+
+		pool.mux.Lock()
+		browsers := make([]*pooledBrowser, 0, len(pool.browsers))
+		for _, browser := range pool.browsers {
+			if !browser.retired {
+				browsers = append(browsers, browser)
+			}
+		}
+		pool.mux.Unlock()
+
+		for _, browser := range browsers {
+			if err := pool.check(browser); nil != err {
+				log.WithFields(log.Fields{
+					"error": err,
+					"port":  browser.chrome.Port(),
+				}).Warn("pooled browser failed a health check")
+				pool.mux.Lock()
+				pool.retire(browser, "health check failed")
+				pool.mux.Unlock()
+				continue
+			}
+			if pool.bloated(browser) {
+				pool.mux.Lock()
+				pool.retire(browser, "memory limit reached")
+				pool.mux.Unlock()
+			}
+		}
+	}
+}
+
+/*
+launch creates and launches a browser.
+*/
+func (pool *Pool) launch(ctx context.Context) (*pooledBrowser, error) {
+	chrome, err := pool.options.New()
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "could not create a pooled browser")
+	}
+	if err := chrome.LaunchContext(ctx); nil != err {
+		return nil, errs.Wrap(err, 0, "could not launch a pooled browser")
+	}
+	// Open the browser connection before the browser is shared.
+	if _, err := chrome.browserSocket(); nil != err {
+		chrome.Close()
+		return nil, errs.Wrap(err, 0, "could not connect to a pooled browser")
+	}
+	browser := &pooledBrowser{chrome: chrome}
+	chrome.OnUnexpectedExit(func(err *ExitError) {
+		pool.mux.Lock()
+		defer pool.mux.Unlock()
+		pool.retire(browser, "browser exited")
+	})
+	return browser, nil
+}
+
+/*
+notify wakes goroutines waiting for a browser. The caller must hold the lock.
+*/
+func (pool *Pool) notify() {
+	close(pool.changed)
+	pool.changed = make(chan struct{})
+}
+
+/*
+recycle closes a retired browser and launches its replacement, retrying until it
+starts or the pool is closed.
+*/
+func (pool *Pool) recycle(browser *pooledBrowser) {
+	if err := browser.chrome.Close(); nil != err {
+		log.WithFields(log.Fields{
+			"error": err,
+			"port":  browser.chrome.Port(),
+		}).Warn("could not close pooled browser")
+	}
+	for {
+		select {
+		case <-pool.done:
+			return
+		default:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultLaunchTimeout)
+		replacement, err := pool.launch(ctx)
+		cancel()
+		if nil == err {
+			pool.add(replacement)
+			return
+		}
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("could not replace pooled browser")
+		select {
+		case <-pool.done:
+			return
+		case <-time.After(poolRetryDelay):
+		}
+	}
+}
+
+/*
+release returns a leased browser and recycles it if it has been retired and has
+no more leases.
+*/
+func (pool *Pool) release(browser *pooledBrowser, retire string) {
+	pool.mux.Lock()
+	browser.leases--
+	if "" != retire {
+		pool.retire(browser, retire)
+	} else {
+		pool.remove(browser)
+	}
+	pool.mux.Unlock()
+	<-pool.slots
+}
+
+/*
+remove removes a retired browser that has no leases from the pool and recycles
+it. The caller must hold the lock.
+*/
+func (pool *Pool) remove(browser *pooledBrowser) {
+	if !browser.retired || 0 < browser.leases {
+		return
+	}
+	for k, b := range pool.browsers {
+		if b == browser {
+			pool.browsers = append(pool.browsers[:k], pool.browsers[k+1:]...)
+			go pool.recycle(browser)
+			return
+		}
+	}
+}
+
+/*
+retire stops a browser from receiving new leases so it is recycled once its
+leases have been released. The caller must hold the lock.
+*/
+func (pool *Pool) retire(browser *pooledBrowser, reason string) {
+	if !browser.retired {
+		browser.retired = true
+		log.WithFields(log.Fields{
+			"port":   browser.chrome.Port(),
+			"reason": reason,
+			"uses":   browser.uses,
+		}).Info("recycling pooled browser")
+	}
+	pool.remove(browser)
+}
+
+/*
+Lease is a tab leased from a Pool.
+*/
+type Lease struct {
+	// browser is the pooled browser the tab belongs to.
+	browser *pooledBrowser
+
+	// browserContext is the browser context the tab was opened in when the
+	// pool is isolated.
+	browserContext *BrowserContext
+
+	// once ensures the lease is released once.
+	once *sync.Once
+
+	// pool is the pool the lease was taken from.
+	pool *Pool
+
+	// tab is the leased tab.
+	tab *Tab
+}
+
+/*
+Chrome returns the browser the tab belongs to.
+*/
+func (lease *Lease) Chrome() *Chrome {
+	return lease.browser.chrome
+}
+
+/*
+Release closes the leased tab and returns its browser to the pool. The browser
+is recycled once its leases are released if a tab crashed or its renderer uses
+too much memory. Calling Release more than once has no effect.
+*/
+func (lease *Lease) Release() error {
+	var closeErr error
+	lease.once.Do(func() {
+		retire := ""
+		if nil != lease.tab {
+			if lease.leaking() {
+				retire = "DOM node limit reached"
+			}
+			if _, err := lease.tab.Close(); nil != err {
+				closeErr = errs.Wrap(err, 0, "could not close leased tab")
+			}
+		}
+		if nil != lease.browserContext {
+			ctx, cancel := context.WithTimeout(context.Background(), lease.pool.options.HealthCheckTimeout)
+			if err := lease.browserContext.Dispose(ctx); nil != err && nil == closeErr {
+				closeErr = errs.Wrap(err, 0, "could not dispose leased browser context")
+			}
+			cancel()
+		}
+		lease.pool.release(lease.browser, retire)
+	})
+	return closeErr
+}
+
+/*
+Tab returns the leased tab.
+*/
+func (lease *Lease) Tab() *Tab {
+	return lease.tab
+}
+
+/*
+leaking reports whether the tab's renderer holds more DOM nodes than allowed.
+*/
+func (lease *Lease) leaking() bool {
+	if 0 >= lease.pool.options.MaxDOMNodes {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lease.pool.options.HealthCheckTimeout)
+	defer cancel()
+
+	resultChan := lease.tab.Memory().GetDOMCounters(&memory.GetDOMCountersParams{})
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			log.WithFields(log.Fields{
+				"error": result.Err,
+			}).Warn("Memory.getDOMCounters failed")
+			return false
+		}
+		return result.Nodes > lease.pool.options.MaxDOMNodes
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return false
+	}
+}
+
+/*
+open opens the leased tab and watches it for renderer crashes.
+*/
+func (lease *Lease) open(ctx context.Context) error {
+	var err error
+	if lease.pool.options.Isolated {
+		lease.browserContext, err = lease.browser.chrome.NewBrowserContext(ctx, nil)
+		if nil != err {
+			return err
+		}
+		lease.tab, err = lease.browserContext.NewTab(ctx, "about:blank")
+	} else {
+		lease.tab, err = lease.browser.chrome.NewTab("about:blank")
+	}
+	if nil != err {
+		return err
+	}
+
+	lease.tab.Inspector().OnTargetCrashed(func(event *inspector.TargetCrashedEvent) {
+		lease.pool.mux.Lock()
+		defer lease.pool.mux.Unlock()
+		lease.pool.retire(lease.browser, "target crashed")
+	})
+	resultChan := lease.tab.Inspector().Enable()
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Inspector.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "Inspector.enable failed")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package chrome
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewPool(t *testing.T) {
+	if _, err := NewPool(context.Background(), nil); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if _, err := NewPool(context.Background(), &PoolOptions{
+		New: func() (*Chrome, error) {
+			return nil, errors.New("no browser")
+		},
+	}); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestPoolAcquire(t *testing.T) {
+	pool := newPool(&PoolOptions{
+		MaxConcurrency: 2,
+		MaxUses:        2,
+		New: func() (*Chrome, error) {
+			return nil, errors.New("no browser")
+		},
+	})
+	defer pool.Close()
+	first := &pooledBrowser{chrome: New(&Flags{}, "", "", "", "")}
+	second := &pooledBrowser{chrome: New(&Flags{}, "", "", "", "")}
+	pool.browsers = []*pooledBrowser{first, second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	browser, err := pool.acquire(ctx)
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if first != browser {
+		t.Errorf("Expected the first browser")
+	}
+	browser, err = pool.acquire(ctx)
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if second != browser {
+		t.Errorf("Expected the least busy browser")
+	}
+
+	pool.slots <- struct{}{}
+	pool.release(first, "")
+	if 0 != first.leases || 1 != first.uses {
+		t.Errorf("Expected 0 leases and 1 use, received %d and %d", first.leases, first.uses)
+	}
+	if 2 != len(pool.browsers) {
+		t.Errorf("Expected 2 browsers, received %d", len(pool.browsers))
+	}
+
+	// The first browser reaches its use limit and is recycled once released.
+	if browser, _ = pool.acquire(ctx); first != browser || !first.retired {
+		t.Errorf("Expected the first browser to be retired")
+	}
+	pool.slots <- struct{}{}
+	pool.release(first, "")
+	if 1 != len(pool.browsers) || second != pool.browsers[0] {
+		t.Errorf("Expected the retired browser to be removed")
+	}
+
+	// A crashed tab retires its browser.
+	pool.slots <- struct{}{}
+	pool.release(second, "target crashed")
+	if 0 != len(pool.browsers) {
+		t.Errorf("Expected no browsers, received %d", len(pool.browsers))
+	}
+	if _, err = pool.acquire(ctx); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestPoolLease(t *testing.T) {
+	pool := newPool(&PoolOptions{
+		MaxConcurrency: 1,
+		New: func() (*Chrome, error) {
+			return nil, errors.New("no browser")
+		},
+	})
+	pool.browsers = []*pooledBrowser{{chrome: New(&Flags{}, "", "", "", "")}}
+	pool.slots <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Lease(ctx); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if 0 != pool.browsers[0].leases {
+		t.Errorf("Expected no leases, received %d", pool.browsers[0].leases)
+	}
+
+	pool.Close()
+	<-pool.slots
+	if _, err := pool.Lease(context.Background()); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if 0 != len(pool.slots) {
+		t.Errorf("Expected the lease slot to be returned")
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+// +build !windows
+
+package chrome
+
+import (
+	"os"
+	"syscall"
+)
+
+/*
+processAttributes returns the attributes Chromium is started with. Chromium is
+started in its own process group so its zygote, renderer and GPU processes can
+be stopped with it.
+*/
+func processAttributes() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+/*
+killProcess sends SIGKILL to the Chromium process group.
+*/
+func killProcess(process *os.Process) error {
+	return signalProcessGroup(process, syscall.SIGKILL)
+}
+
+/*
+processRunning reports whether a process with the given PID exists.
+*/
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return nil == err || syscall.EPERM == err
+}
+
+/*
+signalProcessGroup sends a signal to the process group led by a process, or to
+the process itself if it doesn't lead a group.
+*/
+func signalProcessGroup(process *os.Process, signal syscall.Signal) error {
+	if err := syscall.Kill(-process.Pid, signal); nil == err {
+		return nil
+	}
+	return process.Signal(signal)
+}
+
+/*
+terminateProcess sends SIGTERM to the Chromium process group.
+*/
+func terminateProcess(process *os.Process) error {
+	return signalProcessGroup(process, syscall.SIGTERM)
+}
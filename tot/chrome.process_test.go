@@ -0,0 +1,133 @@
+//go:build !windows
+// +build !windows
+
+package chrome
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startProcess(t *testing.T, script string) *os.Process {
+	process, err := os.StartProcess(
+		"/bin/sh",
+		[]string{"/bin/sh", "-c", script},
+		&os.ProcAttr{Sys: processAttributes()},
+	)
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	return process
+}
+
+func TestChromeWatch(t *testing.T) {
+	chrome := New(&Flags{}, "", "", "", "")
+	chrome.stderrBuffer = newOutputBuffer(DefaultOutputLines)
+	chrome.stderrBuffer.add("fatal error")
+	received := make(chan *ExitError, 1)
+	chrome.OnUnexpectedExit(func(err *ExitError) {
+		received <- err
+	})
+
+	chrome.Exited()
+	exited := chrome.exited
+	stderrClosed := make(chan struct{})
+	close(stderrClosed)
+	go chrome.watch(startProcess(t, "exit 3"), exited, stderrClosed)
+
+	select {
+	case err := <-received:
+		if "exit status 3" != err.State {
+			t.Errorf("Expected 'exit status 3', received '%s'", err.State)
+		}
+		if 1 != len(err.STDERR) || "fatal error" != err.STDERR[0] {
+			t.Errorf("Expected the error output, received %v", err.STDERR)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected an unexpected exit to be reported")
+	}
+	select {
+	case <-exited:
+	default:
+		t.Errorf("Expected exited to be closed")
+	}
+}
+
+func TestChromeCloseKillsProcess(t *testing.T) {
+	timeout := DefaultShutdownTimeout
+	DefaultShutdownTimeout = 100 * time.Millisecond
+	defer func() { DefaultShutdownTimeout = timeout }()
+
+	chrome := New(&Flags{}, "", "", "", "")
+	chrome.browserURL = "ws://127.0.0.1:1/devtools/browser/browser-id"
+	chrome.OnUnexpectedExit(func(err *ExitError) {
+		t.Errorf("Expected Close not to be reported, received '%s'", err)
+	})
+	chrome.process = startProcess(t, `trap "" INT TERM; while true; do sleep 1; done`)
+	chrome.Exited()
+	exited := chrome.exited
+	stderrClosed := make(chan struct{})
+	close(stderrClosed)
+	go chrome.watch(chrome.process, exited, stderrClosed)
+
+	if err := chrome.Close(); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	select {
+	case <-exited:
+	default:
+		t.Errorf("Expected the process to be killed")
+	}
+}
+
+func TestChromeLaunchCapturesOutput(t *testing.T) {
+	timeout := DefaultShutdownTimeout
+	DefaultShutdownTimeout = 100 * time.Millisecond
+	defer func() { DefaultShutdownTimeout = timeout }()
+
+	dir, err := ioutil.TempDir("", "go-chrome-binary-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	server := newDevToolsServer(t)
+	defer server.Close()
+	binary := filepath.Join(dir, "chromium")
+	ioutil.WriteFile(binary, []byte(`#!/bin/sh
+echo "started"
+echo "[1:2:0101/000000.000000:WARNING:startup.cc(1)] warning" >&2
+echo "DevTools listening on ws://`+strings.TrimPrefix(server.URL, "http://")+`/devtools/browser/browser-id" >&2
+exec sleep 10
+`), 0700)
+
+	writer := &bytes.Buffer{}
+	chrome := New(&Flags{"remote-debugging-port": 0}, binary, dir, "", "")
+	chrome.SetOutput(&OutputOptions{Writer: writer})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := chrome.LaunchContext(ctx); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if !chrome.discovering {
+		t.Errorf("Expected target discovery to be enabled")
+	}
+	if err := chrome.Close(); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+
+	if lines := chrome.STDOUTLines(); 1 != len(lines) || "started" != lines[0] {
+		t.Errorf("Expected the STDOUT output, received %v", lines)
+	}
+	if lines := chrome.STDERRLines(); 2 != len(lines) {
+		t.Errorf("Expected the STDERR output, received %v", lines)
+	}
+	if !strings.Contains(writer.String(), "started\n") || !strings.Contains(writer.String(), "DevTools listening") {
+		t.Errorf("Expected the output to be copied to the Writer, received '%s'", writer.String())
+	}
+}
@@ -0,0 +1,59 @@
+//go:build windows
+// +build windows
+
+package chrome
+
+import (
+	"os"
+	"syscall"
+)
+
+/*
+processAttributes returns the attributes Chromium is started with.
+*/
+func processAttributes() *syscall.SysProcAttr {
+	return nil
+}
+
+/*
+killProcess kills the Chromium process.
+*/
+func killProcess(process *os.Process) error {
+	return process.Kill()
+}
+
+/*
+processQueryLimitedInformation is the PROCESS_QUERY_LIMITED_INFORMATION access
+right, which syscall doesn't define.
+*/
+const processQueryLimitedInformation = 0x1000
+
+/*
+processStillActive is the exit code GetExitCodeProcess reports for a running
+process.
+*/
+const processStillActive = 259
+
+/*
+processRunning reports whether a process with the given PID exists.
+*/
+func processRunning(pid int) bool {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if nil != err {
+		// The process exists but belongs to another user.
+		return syscall.ERROR_ACCESS_DENIED == err
+	}
+	defer syscall.CloseHandle(handle)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(handle, &code); nil != err {
+		return true
+	}
+	return processStillActive == code
+}
+
+/*
+terminateProcess kills the Chromium process, Windows has no termination signal.
+*/
+func terminateProcess(process *os.Process) error {
+	return process.Kill()
+}
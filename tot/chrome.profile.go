@@ -0,0 +1,259 @@
+package chrome
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+)
+
+/*
+profilePrefix is the name prefix of temporary profile directories.
+*/
+const profilePrefix = "go-chrome-profile-"
+
+/*
+profileOwnerFile is the file in a temporary profile directory that holds the
+PID of the process that created it.
+*/
+const profileOwnerFile = ".go-chrome-owner"
+
+/*
+profileStaleAge is the age after which a temporary profile directory without an
+owner file is considered stale.
+*/
+const profileStaleAge = time.Hour
+
+/*
+profileSkipFiles holds files in a template profile that belong to the process
+using it and are not copied.
+*/
+var profileSkipFiles = map[string]bool{
+	"DevToolsActivePort": true,
+	"SingletonCookie":    true,
+	"SingletonLock":      true,
+	"SingletonSocket":    true,
+	profileOwnerFile:     true,
+}
+
+/*
+ProfileOptions holds the settings for the Chromium user data directory.
+
+By default each launch uses a fresh temporary profile that is deleted on Close.
+The profile isn't managed if the user-data-dir flag is set.
+*/
+type ProfileOptions struct {
+	// Path persists the profile to a named directory that is kept on Close.
+	// The directory is created if it doesn't exist.
+	Path string
+
+	// Template is a profile directory that is copied into the new profile, for
+	// example to start with saved logins or settings. When Path is set the
+	// template is only copied if the directory is empty.
+	Template string
+}
+
+/*
+SetProfile sets the profile settings used by the next launch.
+*/
+func (chrome *Chrome) SetProfile(options *ProfileOptions) {
+	chrome.profile = options
+}
+
+/*
+prepareProfile sets the user-data-dir flag to a new temporary profile or the
+persisted profile path. Stale temporary profiles are removed first.
+*/
+func (chrome *Chrome) prepareProfile() error {
+	if chrome.Flags().Has("user-data-dir") {
+		return nil
+	}
+	options := chrome.profile
+	if nil == options {
+		options = &ProfileOptions{}
+	}
+
+	if "" != options.Path {
+		if err := os.MkdirAll(options.Path, 0700); nil != err {
+			return errs.Wrap(err, 0, fmt.Sprintf("cannot create profile directory '%s'", options.Path))
+		}
+		if "" != options.Template {
+			entries, err := ioutil.ReadDir(options.Path)
+			if nil != err {
+				return errs.Wrap(err, 0, fmt.Sprintf("cannot read profile directory '%s'", options.Path))
+			}
+			if 0 == len(entries) {
+				if err := copyProfile(options.Template, options.Path); nil != err {
+					return err
+				}
+			}
+		}
+		return chrome.setProfileFlag(options.Path)
+	}
+
+	removeStaleProfiles(os.TempDir())
+	dir, err := ioutil.TempDir("", profilePrefix)
+	if nil != err {
+		return errs.Wrap(err, 0, "cannot create temporary profile directory")
+	}
+	chrome.profileDir = dir
+	owner := filepath.Join(dir, profileOwnerFile)
+	if err := ioutil.WriteFile(owner, []byte(strconv.Itoa(os.Getpid())), 0600); nil != err {
+		chrome.removeProfile()
+		return errs.Wrap(err, 0, fmt.Sprintf("cannot write '%s'", owner))
+	}
+	if "" != options.Template {
+		if err := copyProfile(options.Template, dir); nil != err {
+			chrome.removeProfile()
+			return err
+		}
+	}
+	return chrome.setProfileFlag(dir)
+}
+
+/*
+removeProfile deletes the temporary profile created for the last launch and
+removes the user-data-dir flag of a managed profile, so the next launch gets a
+new profile.
+*/
+func (chrome *Chrome) removeProfile() {
+	if chrome.profileFlag {
+		chrome.Flags().Remove("user-data-dir")
+		chrome.profileFlag = false
+	}
+	if "" == chrome.profileDir {
+		return
+	}
+	if err := os.RemoveAll(chrome.profileDir); nil != err {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  chrome.profileDir,
+		}).Warn("could not remove temporary profile")
+		return
+	}
+	chrome.profileDir = ""
+}
+
+/*
+setProfileFlag sets the user-data-dir flag to a managed profile directory.
+*/
+func (chrome *Chrome) setProfileFlag(dir string) error {
+	if err := chrome.Flags().Set("user-data-dir", dir); nil != err {
+		return err
+	}
+	chrome.profileFlag = true
+	return nil
+}
+
+/*
+copyProfile copies a template profile directory.
+*/
+func copyProfile(template, dir string) error {
+	info, err := os.Stat(template)
+	if nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("cannot read template profile '%s'", template))
+	}
+	if !info.IsDir() {
+		return errs.New(0, fmt.Sprintf("template profile '%s' is not a directory", template))
+	}
+
+	err = filepath.Walk(template, func(path string, info os.FileInfo, err error) error {
+		if nil != err {
+			return err
+		}
+		rel, err := filepath.Rel(template, path)
+		if nil != err {
+			return err
+		}
+		if profileSkipFiles[info.Name()] {
+			return nil
+		}
+		target := filepath.Join(dir, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0700)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		// Sockets, links and other special files are not part of a profile.
+		return nil
+	})
+	if nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("cannot copy template profile '%s'", template))
+	}
+	return nil
+}
+
+/*
+copyFile copies a regular file.
+*/
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if nil != err {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if nil != err {
+		return err
+	}
+	if _, err := io.Copy(out, in); nil != err {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+/*
+removeStaleProfiles deletes temporary profile directories whose owner process
+has exited, such as profiles left by a crashed program.
+*/
+func removeStaleProfiles(tempDir string) {
+	entries, err := ioutil.ReadDir(tempDir)
+	if nil != err {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), profilePrefix) {
+			continue
+		}
+		dir := filepath.Join(tempDir, entry.Name())
+		if !profileStale(dir, entry.ModTime()) {
+			continue
+		}
+		if err := os.RemoveAll(dir); nil != err {
+			log.WithFields(log.Fields{
+				"error": err,
+				"path":  dir,
+			}).Warn("could not remove stale profile")
+			continue
+		}
+		log.WithFields(log.Fields{
+			"path": dir,
+		}).Info("removed stale profile")
+	}
+}
+
+/*
+profileStale reports whether the process that created a temporary profile has
+exited. Profiles without an owner file are stale once they are older than
+profileStaleAge.
+*/
+func profileStale(dir string, modified time.Time) bool {
+	data, err := ioutil.ReadFile(filepath.Join(dir, profileOwnerFile))
+	if nil != err {
+		return time.Since(modified) > profileStaleAge
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if nil != err {
+		return true
+	}
+	return !processRunning(pid)
+}
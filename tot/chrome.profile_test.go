@@ -0,0 +1,144 @@
+package chrome
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChromePrepareProfile(t *testing.T) {
+	template, err := ioutil.TempDir("", "go-chrome-template-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(template)
+	os.MkdirAll(filepath.Join(template, "Default"), 0700)
+	ioutil.WriteFile(filepath.Join(template, "Default", "Preferences"), []byte("{}"), 0600)
+	ioutil.WriteFile(filepath.Join(template, "SingletonLock"), []byte("host-1"), 0600)
+
+	// A temporary profile is created from the template and removed.
+	chrome := New(&Flags{}, "", "", "", "")
+	chrome.SetProfile(&ProfileOptions{Template: template})
+	if err := chrome.prepareProfile(); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	value, _ := chrome.Flags().Get("user-data-dir")
+	dir := value.(string)
+	if dir != chrome.profileDir || !strings.HasPrefix(filepath.Base(dir), profilePrefix) {
+		t.Errorf("Expected a temporary profile, received '%s'", dir)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, profileOwnerFile)); strconv.Itoa(os.Getpid()) != string(data) {
+		t.Errorf("Expected the owner PID, received '%s'", string(data))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Default", "Preferences")); nil != err {
+		t.Errorf("Expected the template to be copied, received '%s'", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "SingletonLock")); nil == err {
+		t.Errorf("Expected SingletonLock not to be copied")
+	}
+	chrome.removeProfile()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the profile to be removed")
+	}
+	if chrome.Flags().Has("user-data-dir") {
+		t.Errorf("Expected the user-data-dir flag to be removed")
+	}
+
+	// The next launch gets a new temporary profile.
+	if err := chrome.prepareProfile(); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if value, _ := chrome.Flags().Get("user-data-dir"); dir == value || chrome.profileDir != value {
+		t.Errorf("Expected a new temporary profile, received '%v'", value)
+	}
+	chrome.removeProfile()
+
+	// A persisted profile is only initialized from the template once and kept.
+	dir = filepath.Join(template, "persisted")
+	chrome = New(&Flags{}, "", "", "", "")
+	chrome.SetProfile(&ProfileOptions{Path: dir, Template: filepath.Join(template, "Default")})
+	if err := chrome.prepareProfile(); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if value, _ := chrome.Flags().Get("user-data-dir"); dir != value {
+		t.Errorf("Expected '%s', received '%v'", dir, value)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Preferences")); nil != err {
+		t.Errorf("Expected the template to be copied, received '%s'", err)
+	}
+	os.Remove(filepath.Join(dir, "Preferences"))
+	ioutil.WriteFile(filepath.Join(dir, "Local State"), []byte("{}"), 0600)
+	chrome = New(&Flags{}, "", "", "", "")
+	chrome.SetProfile(&ProfileOptions{Path: dir, Template: filepath.Join(template, "Default")})
+	chrome.prepareProfile()
+	if _, err := os.Stat(filepath.Join(dir, "Preferences")); nil == err {
+		t.Errorf("Expected the template not to be copied into an existing profile")
+	}
+	chrome.removeProfile()
+	if _, err := os.Stat(dir); nil != err {
+		t.Errorf("Expected the persisted profile to be kept, received '%s'", err)
+	}
+
+	// An explicit user-data-dir flag is left alone.
+	chrome = New(&Flags{"user-data-dir": template}, "", "", "", "")
+	if err := chrome.prepareProfile(); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if "" != chrome.profileDir {
+		t.Errorf("Expected no temporary profile, received '%s'", chrome.profileDir)
+	}
+	chrome.removeProfile()
+	if value, _ := chrome.Flags().Get("user-data-dir"); template != value {
+		t.Errorf("Expected '%s', received '%v'", template, value)
+	}
+
+	chrome = New(&Flags{}, "", "", "", "")
+	chrome.SetProfile(&ProfileOptions{Template: filepath.Join(template, "missing")})
+	if err := chrome.prepareProfile(); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if "" != chrome.profileDir {
+		t.Errorf("Expected the temporary profile to be removed, received '%s'", chrome.profileDir)
+	}
+}
+
+func TestRemoveStaleProfiles(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "go-chrome-profiles-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	profile := func(name, owner string, age time.Duration) string {
+		dir := filepath.Join(tempDir, name)
+		os.MkdirAll(dir, 0700)
+		if "" != owner {
+			ioutil.WriteFile(filepath.Join(dir, profileOwnerFile), []byte(owner), 0600)
+		}
+		modified := time.Now().Add(-age)
+		os.Chtimes(dir, modified, modified)
+		return dir
+	}
+	running := profile(profilePrefix+"running", strconv.Itoa(os.Getpid()), 0)
+	exited := profile(profilePrefix+"exited", "999999999", 0)
+	starting := profile(profilePrefix+"starting", "", 0)
+	abandoned := profile(profilePrefix+"abandoned", "", 2*profileStaleAge)
+	other := profile("other", "999999999", 2*profileStaleAge)
+
+	removeStaleProfiles(tempDir)
+	for dir, kept := range map[string]bool{
+		running:   true,
+		exited:    false,
+		starting:  true,
+		abandoned: false,
+		other:     true,
+	} {
+		if _, err := os.Stat(dir); kept != (nil == err) {
+			t.Errorf("Expected %s to be kept: %v", filepath.Base(dir), kept)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package chrome
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bdlm/log"
+)
+
+/*
+tabFeedBuffer is the number of tab changes buffered for each WatchTabs feed.
+*/
+const tabFeedBuffer = 64
+
+/*
+TabChangeType is the type of a TabChange.
+*/
+type TabChangeType string
+
+/*
+Tab change types.
+*/
+const (
+	// TabAdded is sent when a tab is opened or discovered.
+	TabAdded TabChangeType = "added"
+
+	// TabChanged is sent when the URL, title or type of a tab changes.
+	TabChanged TabChangeType = "changed"
+
+	// TabRemoved is sent when a tab is closed.
+	TabRemoved TabChangeType = "removed"
+)
+
+/*
+TabChange describes a change to the open tabs, see Chrome.WatchTabs.
+*/
+type TabChange struct {
+	// Data is the tab metadata at the time of the change.
+	Data TabData
+
+	// Tab is the tab that changed.
+	Tab *Tab
+
+	// Type is the type of the change.
+	Type TabChangeType
+}
+
+/*
+FindTabs returns the open tabs whose metadata matches.
+*/
+func (chrome *Chrome) FindTabs(match func(data *TabData) bool) []*Tab {
+	tabs := []*Tab{}
+	for _, tab := range chrome.Tabs() {
+		if match(tab.Data()) {
+			tabs = append(tabs, tab)
+		}
+	}
+	return tabs
+}
+
+/*
+TabsByTitle returns the open tabs with the given title.
+*/
+func (chrome *Chrome) TabsByTitle(title string) []*Tab {
+	return chrome.FindTabs(func(data *TabData) bool {
+		return title == data.Title
+	})
+}
+
+/*
+TabsByType returns the open tabs of the given target type, such as "page".
+*/
+func (chrome *Chrome) TabsByType(targetType string) []*Tab {
+	return chrome.FindTabs(func(data *TabData) bool {
+		return targetType == data.Type
+	})
+}
+
+/*
+TabsByURL returns the open tabs with the given URL.
+*/
+func (chrome *Chrome) TabsByURL(uri string) []*Tab {
+	return chrome.FindTabs(func(data *TabData) bool {
+		return uri == data.URL
+	})
+}
+
+/*
+WatchTabs returns a feed of changes to the open tabs, including tabs opened by
+other clients and changes to their URLs and titles, see DiscoverTargets. The
+channel is closed when ctx is done.
+
+Changes are dropped if the feed isn't read and its buffer is full.
+*/
+func (chrome *Chrome) WatchTabs(ctx context.Context) <-chan *TabChange {
+	feed := make(chan *TabChange, tabFeedBuffer)
+	chrome.tabs.watch(feed)
+	go func() {
+		<-ctx.Done()
+		chrome.tabs.unwatch(feed)
+	}()
+	return feed
+}
+
+/*
+tabRegistry holds the open tabs keyed by target ID. It is safe for concurrent
+use.
+*/
+type tabRegistry struct {
+	// feeds holds the WatchTabs feeds.
+	feeds map[chan *TabChange]bool
+
+	// ids maps target IDs to tabs.
+	ids map[string]*Tab
+
+	// mux protects feeds, ids and tabs.
+	mux *sync.Mutex
+
+	// tabs holds the tabs in the order they were added.
+	tabs []*Tab
+}
+
+/*
+newTabRegistry returns an empty tab registry.
+*/
+func newTabRegistry() *tabRegistry {
+	return &tabRegistry{
+		feeds: map[chan *TabChange]bool{},
+		ids:   map[string]*Tab{},
+		mux:   &sync.Mutex{},
+	}
+}
+
+/*
+add adds a tab. If a tab with the same target ID is already registered, that
+tab is returned instead.
+*/
+func (registry *tabRegistry) add(tab *Tab) *Tab {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	id := tab.Data().ID
+	if existing, ok := registry.ids[id]; ok && "" != id {
+		return existing
+	}
+	if "" != id {
+		registry.ids[id] = tab
+	}
+	registry.tabs = append(registry.tabs, tab)
+	registry.notify(TabAdded, tab)
+	return tab
+}
+
+/*
+get returns the tab with a target ID.
+*/
+func (registry *tabRegistry) get(id string) (*Tab, bool) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	tab, ok := registry.ids[id]
+	return tab, ok
+}
+
+/*
+list returns the tabs in the order they were added, or nil if there are none.
+*/
+func (registry *tabRegistry) list() []*Tab {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	if 0 == len(registry.tabs) {
+		return nil
+	}
+	tabs := make([]*Tab, len(registry.tabs))
+	copy(tabs, registry.tabs)
+	return tabs
+}
+
+/*
+notify sends a change to the feeds. The caller must hold the lock.
+*/
+func (registry *tabRegistry) notify(changeType TabChangeType, tab *Tab) {
+	if 0 == len(registry.feeds) {
+		return
+	}
+	change := &TabChange{
+		Data: *tab.Data(),
+		Tab:  tab,
+		Type: changeType,
+	}
+	for feed := range registry.feeds {
+		select {
+		case feed <- change:
+		default:
+			log.WithFields(log.Fields{
+				"tab":  change.Data.ID,
+				"type": changeType,
+			}).Warn("tab feed is full, dropping change")
+		}
+	}
+}
+
+/*
+remove removes a tab and reports whether it was registered.
+*/
+func (registry *tabRegistry) remove(tab *Tab) bool {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	for k, t := range registry.tabs {
+		if t == tab {
+			registry.tabs = append(registry.tabs[:k], registry.tabs[k+1:]...)
+			if id := tab.Data().ID; tab == registry.ids[id] {
+				delete(registry.ids, id)
+			}
+			registry.notify(TabRemoved, tab)
+			return true
+		}
+	}
+	return false
+}
+
+/*
+unwatch removes and closes a feed.
+*/
+func (registry *tabRegistry) unwatch(feed chan *TabChange) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	delete(registry.feeds, feed)
+	close(feed)
+}
+
+/*
+update sets the URL, title and type of a tab.
+*/
+func (registry *tabRegistry) update(tab *Tab, uri, title, targetType string) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	data := *tab.Data()
+	if uri == data.URL && title == data.Title && targetType == data.Type {
+		return
+	}
+	data.URL = uri
+	data.Title = title
+	data.Type = targetType
+	tab.setData(&data)
+	registry.notify(TabChanged, tab)
+}
+
+/*
+watch adds a feed.
+*/
+func (registry *tabRegistry) watch(feed chan *TabChange) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	registry.feeds[feed] = true
+}
@@ -0,0 +1,147 @@
+package chrome
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/target"
+)
+
+func TestChromeRemoveTab(t *testing.T) {
+	chrome := New(&Flags{}, "", "", "", "")
+	for a := 0; a < 3; a++ {
+		chrome.targetCreated(&target.Info{ID: target.ID(fmt.Sprintf("tab-%d", a)), Type: "page"})
+	}
+	tabs := chrome.Tabs()
+
+	// Removing a tab keeps the others.
+	chrome.RemoveTab(tabs[1])
+	chrome.RemoveTab(tabs[1])
+	if remaining := chrome.Tabs(); 2 != len(remaining) || tabs[0] != remaining[0] || tabs[2] != remaining[1] {
+		t.Errorf("Expected tabs 0 and 2, received %v", remaining)
+	}
+	if _, err := chrome.GetTab("tab-1"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if tab, err := chrome.GetTab("tab-2"); nil != err || tabs[2] != tab {
+		t.Errorf("Expected tab 2, received '%v'", err)
+	}
+}
+
+func TestChromeTabsConcurrent(t *testing.T) {
+	chrome := New(&Flags{}, "", "", "", "")
+	wg := &sync.WaitGroup{}
+	for a := 0; a < 50; a++ {
+		wg.Add(1)
+		go func(a int) {
+			defer wg.Done()
+			info := &target.Info{ID: target.ID(fmt.Sprintf("tab-%d", a%10)), Type: "page"}
+			chrome.targetCreated(info)
+			chrome.targetInfoChanged(&target.Info{ID: info.ID, Type: "page", Title: "title"})
+			if 0 == a%2 {
+				chrome.targetDestroyed(info.ID)
+			}
+			chrome.Tabs()
+		}(a)
+	}
+	wg.Wait()
+	for _, tab := range chrome.Tabs() {
+		if registered, err := chrome.GetTab(tab.Data().ID); nil != err || tab != registered {
+			t.Errorf("Expected tab '%s' to be registered", tab.Data().ID)
+		}
+	}
+}
+
+func TestChromeTabLookups(t *testing.T) {
+	chrome := New(&Flags{}, "", "", "", "")
+	chrome.targetCreated(&target.Info{ID: "a", Type: "page", URL: "https://a.example.com/", Title: "A"})
+	chrome.targetCreated(&target.Info{ID: "b", Type: "page", URL: "https://b.example.com/", Title: "B"})
+	chrome.targetInfoChanged(&target.Info{ID: "b", Type: "page", URL: "https://a.example.com/", Title: "A"})
+	chrome.targetInfoChanged(&target.Info{ID: "unknown", Type: "page", URL: "https://a.example.com/"})
+
+	if tabs := chrome.TabsByURL("https://a.example.com/"); 2 != len(tabs) {
+		t.Errorf("Expected 2 tabs, received %d", len(tabs))
+	}
+	if tabs := chrome.TabsByURL("https://b.example.com/"); 0 != len(tabs) {
+		t.Errorf("Expected no tabs, received %d", len(tabs))
+	}
+	if tabs := chrome.TabsByTitle("A"); 2 != len(tabs) {
+		t.Errorf("Expected 2 tabs, received %d", len(tabs))
+	}
+	if tabs := chrome.TabsByType("page"); 2 != len(tabs) {
+		t.Errorf("Expected 2 tabs, received %d", len(tabs))
+	}
+	if tabs := chrome.TabsByType("iframe"); 0 != len(tabs) {
+		t.Errorf("Expected no tabs, received %d", len(tabs))
+	}
+
+	chrome.targetDestroyed("a")
+	chrome.targetDestroyed("unknown")
+	if tabs := chrome.TabsByTitle("A"); 1 != len(tabs) || "b" != tabs[0].Data().ID {
+		t.Errorf("Expected tab 'b', received %v", tabs)
+	}
+}
+
+func TestChromeWatchTabs(t *testing.T) {
+	chrome := New(&Flags{}, "", "", "", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	feed := chrome.WatchTabs(ctx)
+
+	chrome.targetCreated(&target.Info{ID: "a", Type: "page", URL: "about:blank"})
+	chrome.targetInfoChanged(&target.Info{ID: "a", Type: "page", URL: "about:blank"})
+	chrome.targetInfoChanged(&target.Info{ID: "a", Type: "page", URL: "https://example.com/"})
+	chrome.targetDestroyed("a")
+
+	expected := []struct {
+		changeType TabChangeType
+		url        string
+	}{
+		{TabAdded, "about:blank"},
+		{TabChanged, "https://example.com/"},
+		{TabRemoved, "https://example.com/"},
+	}
+	for _, e := range expected {
+		select {
+		case change := <-feed:
+			if e.changeType != change.Type {
+				t.Errorf("Expected '%s', received '%s'", e.changeType, change.Type)
+			}
+			if e.url != change.Data.URL {
+				t.Errorf("Expected '%s', received '%s'", e.url, change.Data.URL)
+			}
+			if "a" != change.Tab.Data().ID {
+				t.Errorf("Expected 'a', received '%s'", change.Tab.Data().ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a '%s' change, received none", e.changeType)
+		}
+	}
+
+	cancel()
+	select {
+	case change, ok := <-feed:
+		if ok {
+			t.Errorf("Expected the feed to be closed, received '%s'", change.Type)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the feed to be closed")
+	}
+	chrome.targetCreated(&target.Info{ID: "b", Type: "page"})
+}
+
+func TestTabRegistryFullFeed(t *testing.T) {
+	chrome := New(&Flags{}, "", "", "", "")
+	feed := chrome.WatchTabs(context.Background())
+	for a := 0; a < tabFeedBuffer+10; a++ {
+		chrome.targetCreated(&target.Info{ID: target.ID(fmt.Sprintf("tab-%d", a)), Type: "page"})
+	}
+	if tabFeedBuffer != len(feed) {
+		t.Errorf("Expected %d changes, received %d", tabFeedBuffer, len(feed))
+	}
+	if tabs := chrome.Tabs(); tabFeedBuffer+10 != len(tabs) {
+		t.Errorf("Expected %d tabs, received %d", tabFeedBuffer+10, len(tabs))
+	}
+}
@@ -34,3 +34,13 @@ EXPERIMENTAL
 https://chromedevtools.github.io/devtools-protocol/tot/Emulation/#type-VirtualTimePolicy
 */
 type VirtualTimePolicy string
+
+const (
+	// VirtualTimePolicyAdvance represents the "advance" value.
+	VirtualTimePolicyAdvance VirtualTimePolicy = "advance"
+	// VirtualTimePolicyPause represents the "pause" value.
+	VirtualTimePolicyPause VirtualTimePolicy = "pause"
+	// VirtualTimePolicyPauseIfNetworkFetchesPending represents the
+	// "pauseIfNetworkFetchesPending" value.
+	VirtualTimePolicyPauseIfNetworkFetchesPending VirtualTimePolicy = "pauseIfNetworkFetchesPending"
+)
@@ -0,0 +1,35 @@
+package inspector
+
+/*
+DetachedEvent represents Inspector.detached event data.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Inspector/#event-detached
+*/
+type DetachedEvent struct {
+	// The reason why connection has been terminated.
+	Reason string `json:"reason"`
+
+	// Error information related to this event
+	Err error `json:"-"`
+}
+
+/*
+TargetCrashedEvent represents Inspector.targetCrashed event data.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Inspector/#event-targetCrashed
+*/
+type TargetCrashedEvent struct {
+	// Error information related to this event
+	Err error `json:"-"`
+}
+
+/*
+TargetReloadedAfterCrashEvent represents Inspector.targetReloadedAfterCrash
+event data.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Inspector/#event-targetReloadedAfterCrash
+*/
+type TargetReloadedAfterCrashEvent struct {
+	// Error information related to this event
+	Err error `json:"-"`
+}
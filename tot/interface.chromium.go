@@ -49,7 +49,8 @@ type Chromium interface {
 	Port() int
 
 	// Query queries the developer tools endpoints and returns JSON data in the
-	// provided struct.
+	// provided struct. Responses other than 200 OK return an *HTTPError and the
+	// response is discarded if msg is nil.
 	Query(path string, params url.Values, msg interface{}) (interface{}, error)
 
 	// STDERR returns a string defining the location to write STDERR output.
@@ -14,10 +14,16 @@ type ChromiumFlags interface {
 	// List returns an array of each flag for use in os.StartProcess
 	List() []string
 
+	// Remove removes a flag.
+	Remove(flag string)
+
 	// Set sets a flag's values.
 	Set(flag string, values interface{}) error
 
 	// String implments Stringer. It returns the set parameters formatted to be
 	// passed to the command line.
 	String() string
+
+	// Validate returns an error if the flags are invalid or conflict.
+	Validate() error
 }
@@ -17,6 +17,15 @@ GetDOMCountersResult represents the result of calls to Memory.getDOMCounters.
 https://chromedevtools.github.io/devtools-protocol/tot/Memory/#method-getDOMCounters
 */
 type GetDOMCountersResult struct {
+	// Number of documents in the renderer process.
+	Documents int `json:"documents"`
+
+	// Number of DOM nodes in the renderer process.
+	Nodes int `json:"nodes"`
+
+	// Number of JavaScript event listeners in the renderer process.
+	JsEventListeners int `json:"jsEventListeners"`
+
 	// Error information related to executing this method
 	Err error `json:"-"`
 }
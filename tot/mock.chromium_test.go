@@ -1,10 +1,12 @@
 package chrome
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
 
 	errs "github.com/bdlm/errors"
 	"github.com/bdlm/log"
@@ -132,6 +134,13 @@ func (chrome *MockChrome) DebuggingPort() int {
 	return value.(int)
 }
 
+/*
+DiscoverTargets mocks Chrome.DiscoverTargets.
+*/
+func (chrome *MockChrome) DiscoverTargets(ctx context.Context) error {
+	return nil
+}
+
 /*
 GetTab implements Chromium.
 */
@@ -319,6 +328,7 @@ func (chrome *MockChrome) NewTab(uri string) (*Tab, error) {
 			URL:                  "",
 			WebSocketDebuggerURL: "",
 		},
+		mux: &sync.Mutex{},
 		url: targetURL,
 	}
 
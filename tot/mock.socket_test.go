@@ -1,14 +1,18 @@
 package chrome
 
 import (
+	"encoding/json"
 	"net/url"
+	"sync"
 
 	"github.com/mkenney/go-chrome/tot/socket"
 )
 
 func NewMockSocket(url *url.URL) *MockSocket {
 	mockSocket := &MockSocket{
-		url: url,
+		handlers: socket.NewEventHandlerMap(),
+		mux:      &sync.Mutex{},
+		url:      url,
 	}
 
 	mockSocket.accessibility = &socket.AccessibilityProtocol{Socket: mockSocket}
@@ -31,6 +35,7 @@ func NewMockSocket(url *url.URL) *MockSocket {
 	mockSocket.heapProfiler = &socket.HeapProfilerProtocol{Socket: mockSocket}
 	mockSocket.indexedDB = &socket.IndexedDBProtocol{Socket: mockSocket}
 	mockSocket.input = &socket.InputProtocol{Socket: mockSocket}
+	mockSocket.inspector = &socket.InspectorProtocol{Socket: mockSocket}
 	mockSocket.io = &socket.IOProtocol{Socket: mockSocket}
 	mockSocket.layerTree = &socket.LayerTreeProtocol{Socket: mockSocket}
 	mockSocket.log = &socket.LogProtocol{Socket: mockSocket}
@@ -60,6 +65,19 @@ type MockSocket struct {
 	url       *url.URL
 	commandID int
 
+	// commands holds the commands sent through the socket.
+	commands []socket.Commander
+
+	// handlers holds the event handlers added to the socket.
+	handlers *socket.EventHandlerMap
+
+	// mux protects commandID, commands and respond.
+	mux *sync.Mutex
+
+	// respond returns the response to a command. Commands are left unanswered
+	// if respond is nil.
+	respond func(command socket.Commander) *socket.Response
+
 	// Protocol interfaces for the API.
 	accessibility        *socket.AccessibilityProtocol
 	animation            *socket.AnimationProtocol
@@ -81,6 +99,7 @@ type MockSocket struct {
 	heapProfiler         *socket.HeapProfilerProtocol
 	indexedDB            *socket.IndexedDBProtocol
 	input                *socket.InputProtocol
+	inspector            *socket.InspectorProtocol
 	io                   *socket.IOProtocol
 	layerTree            *socket.LayerTreeProtocol
 	log                  *socket.LogProtocol
@@ -107,12 +126,64 @@ AddEventHandler is a Socketer implementation.
 func (socket *MockSocket) AddEventHandler(
 	handler socket.EventHandler,
 ) {
+	socket.handlers.Add(handler)
+}
+
+/*
+Commands returns the commands sent through the socket for a method.
+*/
+func (mockSocket *MockSocket) Commands(method string) []socket.Commander {
+	mockSocket.mux.Lock()
+	defer mockSocket.mux.Unlock()
+	commands := []socket.Commander{}
+	for _, command := range mockSocket.commands {
+		if method == command.Method() {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+/*
+Emit delivers an event to the event handlers.
+*/
+func (mockSocket *MockSocket) Emit(name string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if nil != err {
+		return err
+	}
+	mockSocket.handlers.Lock()
+	handlers, err := mockSocket.handlers.Get(name)
+	handlers = append([]socket.EventHandler{}, handlers...)
+	mockSocket.handlers.Unlock()
+	if nil != err {
+		return err
+	}
+	for _, handler := range handlers {
+		handler.Handle(&socket.Response{
+			Method: name,
+			Params: data,
+		})
+	}
+	return nil
+}
+
+/*
+SetResponder sets the function that answers the commands sent through the
+socket.
+*/
+func (socket *MockSocket) SetResponder(respond func(command socket.Commander) *socket.Response) {
+	socket.mux.Lock()
+	defer socket.mux.Unlock()
+	socket.respond = respond
 }
 
 /*
 CurCommandID is a Socketer implementation.
 */
 func (socket *MockSocket) CurCommandID() int {
+	socket.mux.Lock()
+	defer socket.mux.Unlock()
 	id := socket.commandID
 	return id
 }
@@ -130,6 +201,8 @@ func (socket *MockSocket) Listen() {
 NextCommandID generates and returns the next command ID.
 */
 func (socket *MockSocket) NextCommandID() int {
+	socket.mux.Lock()
+	defer socket.mux.Unlock()
 	socket.commandID++
 	return socket.commandID
 }
@@ -140,6 +213,7 @@ RemoveEventHandler is a Socketer implementation.
 func (socket *MockSocket) RemoveEventHandler(
 	handler socket.EventHandler,
 ) error {
+	socket.handlers.Remove(handler)
 	return nil
 }
 
@@ -147,9 +221,25 @@ func (socket *MockSocket) RemoveEventHandler(
 SendCommand is a Socketer implementation.
 */
 func (socket *MockSocket) SendCommand(command socket.Commander) chan *socket.Response {
+	socket.mux.Lock()
+	socket.commands = append(socket.commands, command)
+	respond := socket.respond
+	socket.mux.Unlock()
+	if nil != respond {
+		go func() {
+			command.Respond(respond(command))
+		}()
+	}
 	return command.Response()
 }
 
+/*
+Fail is a Socketer implementation.
+*/
+func (socket *MockSocket) Fail(err error) error {
+	return nil
+}
+
 /*
 Stop is a Socketer implementation.
 */
@@ -304,6 +394,13 @@ func (socket *MockSocket) Input() *socket.InputProtocol {
 	return socket.input
 }
 
+/*
+Inspector is a Protocoller implementation.
+*/
+func (socket *MockSocket) Inspector() *socket.InspectorProtocol {
+	return socket.inspector
+}
+
 /*
 IO is a Protocoller implementation.
 */
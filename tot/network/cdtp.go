@@ -411,7 +411,7 @@ type Cookie struct {
 	Path string `json:"path"`
 
 	// Cookie expiration date as the number of seconds since the UNIX epoch.
-	Expires int64 `json:"expires"`
+	Expires float64 `json:"expires"`
 
 	// Cookie size.
 	Size int `json:"size"`
@@ -428,6 +428,7 @@ type Cookie struct {
 	// Optional. Cookie SameSite type. Allowed values:
 	//	- CookieSameSite.Strict
 	//	- CookieSameSite.Lax
+	//	- CookieSameSite.None
 	SameSite CookieSameSiteEnum `json:"sameSite,omitempty"`
 }
 
@@ -491,6 +491,7 @@ type SetCookieParams struct {
 	// Optional. Cookie SameSite type. Allowed values:
 	//	- CookieSameSite.Strict
 	//	- CookieSameSite.Lax
+	//	- CookieSameSite.None
 	SameSite CookieSameSiteEnum `json:"sameSite,omitempty"`
 
 	// Optional. Cookie expiration date, session cookie if not set.
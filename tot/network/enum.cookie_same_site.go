@@ -8,6 +8,7 @@ import (
 type cookieSameSiteEnum struct {
 	Strict CookieSameSiteEnum
 	Lax    CookieSameSiteEnum
+	None   CookieSameSiteEnum
 }
 
 /*
@@ -16,12 +17,14 @@ CookieSameSite provides named acces to the CookieSameSiteEnum values.
 var CookieSameSite = cookieSameSiteEnum{
 	Strict: cookieSameSiteStrict,
 	Lax:    cookieSameSiteLax,
+	None:   cookieSameSiteNone,
 }
 
 /*
 CookieSameSiteEnum represents the cookie's 'SameSite' status. Allowed values:
 	- CookieSameSite.Strict "Strict"
 	- CookieSameSite.Lax    "Lax"
+	- CookieSameSite.None   "None"
 
 https://tools.ietf.org/html/draft-west-first-party-cookies
 
@@ -70,10 +73,13 @@ const (
 	cookieSameSiteStrict CookieSameSiteEnum = iota + 1
 	// cookieSameSiteLax represents the "Lax" value.
 	cookieSameSiteLax
+	// cookieSameSiteNone represents the "None" value.
+	cookieSameSiteNone
 )
 
 var _cookieSameSiteEnums = map[CookieSameSiteEnum]string{
 	CookieSameSiteEnum(0): "",
 	cookieSameSiteStrict:  "Strict",
 	cookieSameSiteLax:     "Lax",
+	cookieSameSiteNone:    "None",
 }
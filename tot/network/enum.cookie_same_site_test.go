@@ -53,4 +53,17 @@ func TestEnumCookieSameSite(t *testing.T) {
 	if CookieSameSite.Lax != enum {
 		t.Errorf("Expcected %d, got %d", CookieSameSite.Lax, enum)
 	}
+
+	enum = CookieSameSite.None
+	result, err = json.Marshal(enum)
+	if nil != err {
+		t.Errorf("Expected nil, got error")
+	}
+	if `"None"` != string(result) {
+		t.Errorf("Expected '\"None\"', got '%s'", result)
+	}
+	json.Unmarshal([]byte(`"None"`), &enum)
+	if CookieSameSite.None != enum {
+		t.Errorf("Expcected %d, got %d", CookieSameSite.None, enum)
+	}
 }
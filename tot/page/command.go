@@ -605,11 +605,12 @@ type SetDownloadBehaviorParams struct {
 	// behavior if available (otherwise deny). Allowed values:
 	//	- Behavior.Deny
 	//	- Behavior.Allow
+	//	- Behavior.AllowAndName
 	//	- Behavior.Default
 	Behavior BehaviorEnum `json:"behavior"`
 
 	// Optional. The default path to save downloaded files to. This is required
-	// if behavior is set to 'allow'.
+	// if behavior is set to 'allow' or 'allowAndName'.
 	DownloadPath string `json:"downloadPath,omitempty"`
 }
 
@@ -6,26 +6,29 @@ import (
 )
 
 type behaviorEnum struct {
-	Deny    BehaviorEnum
-	Allow   BehaviorEnum
-	Default BehaviorEnum
+	Deny         BehaviorEnum
+	Allow        BehaviorEnum
+	Default      BehaviorEnum
+	AllowAndName BehaviorEnum
 }
 
 /*
 Behavior provides named acces to the BehaviorEnum values.
 */
 var Behavior = behaviorEnum{
-	Deny:    behaviorDeny,
-	Allow:   behaviorAllow,
-	Default: behaviorDefault,
+	Deny:         behaviorDeny,
+	Allow:        behaviorAllow,
+	Default:      behaviorDefault,
+	AllowAndName: behaviorAllowAndName,
 }
 
 /*
 BehaviorEnum represents whether to allow all or deny all download requests, or
 use default Chrome behavior if available (otherwise deny). Allowed values:
-	- Behavior.Deny    "deny"
-	- Behavior.Allow   "allow"
-	- Behavior.Default "default"
+	- Behavior.Deny         "deny"
+	- Behavior.Allow        "allow"
+	- Behavior.Default      "default"
+	- Behavior.AllowAndName "allowAndName"
 
 https://chromedevtools.github.io/devtools-protocol/tot/Page/#method-setDownloadBehavior
 */
@@ -74,10 +77,13 @@ const (
 	behaviorAllow
 	// behaviorDefault represents the "default" value.
 	behaviorDefault
+	// behaviorAllowAndName represents the "allowAndName" value.
+	behaviorAllowAndName
 )
 
 var _behaviorEnums = map[BehaviorEnum]string{
-	behaviorDeny:    "deny",
-	behaviorAllow:   "allow",
-	behaviorDefault: "default",
+	behaviorDeny:         "deny",
+	behaviorAllow:        "allow",
+	behaviorDefault:      "default",
+	behaviorAllowAndName: "allowAndName",
 }
@@ -49,6 +49,19 @@ func TestEnumBehavior(t *testing.T) {
 		t.Errorf("Expcected %d, got %d", Behavior.Allow, enum)
 	}
 
+	enum = Behavior.AllowAndName
+	result, err = json.Marshal(enum)
+	if nil != err {
+		t.Errorf("Expected nil, got error")
+	}
+	if `"allowAndName"` != string(result) {
+		t.Errorf("Expected '\"allowAndName\"', got '%s'", result)
+	}
+	json.Unmarshal([]byte(`"allowAndName"`), &enum)
+	if Behavior.AllowAndName != enum {
+		t.Errorf("Expcected %d, got %d", Behavior.AllowAndName, enum)
+	}
+
 	enum = Behavior.Default
 	result, err = json.Marshal(enum)
 	if nil != err {
@@ -0,0 +1,82 @@
+package page
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type downloadStateEnum struct {
+	InProgress DownloadStateEnum
+	Completed  DownloadStateEnum
+	Canceled   DownloadStateEnum
+}
+
+/*
+DownloadState provides named acces to the DownloadStateEnum values.
+*/
+var DownloadState = downloadStateEnum{
+	InProgress: downloadStateInProgress,
+	Completed:  downloadStateCompleted,
+	Canceled:   downloadStateCanceled,
+}
+
+/*
+DownloadStateEnum represents the download status. Allowed values:
+	- DownloadState.InProgress "inProgress"
+	- DownloadState.Completed  "completed"
+	- DownloadState.Canceled   "canceled"
+
+https://chromedevtools.github.io/devtools-protocol/tot/Page/#event-downloadProgress
+*/
+type DownloadStateEnum int
+
+/*
+String implements Stringer
+*/
+func (enum DownloadStateEnum) String() string {
+	return _downloadStateEnums[enum]
+}
+
+/*
+MarshalJSON implements json.Marshaler
+*/
+func (enum DownloadStateEnum) MarshalJSON() ([]byte, error) {
+	return json.Marshal(enum.String())
+}
+
+/*
+UnmarshalJSON implements json.Unmarshaler
+*/
+func (enum *DownloadStateEnum) UnmarshalJSON(bytes []byte) error {
+	var err error
+	var val string
+
+	err = json.Unmarshal(bytes, &val)
+	if nil != err {
+		return err
+	}
+
+	for k, v := range _downloadStateEnums {
+		if v == val {
+			*enum = k
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is not a valid type value", bytes)
+}
+
+const (
+	// downloadStateInProgress represents the "inProgress" value.
+	downloadStateInProgress DownloadStateEnum = iota + 1
+	// downloadStateCompleted represents the "completed" value.
+	downloadStateCompleted
+	// downloadStateCanceled represents the "canceled" value.
+	downloadStateCanceled
+)
+
+var _downloadStateEnums = map[DownloadStateEnum]string{
+	downloadStateInProgress: "inProgress",
+	downloadStateCompleted:  "completed",
+	downloadStateCanceled:   "canceled",
+}
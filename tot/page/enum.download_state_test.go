@@ -0,0 +1,64 @@
+package page
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnumDownloadState(t *testing.T) {
+	var enum DownloadStateEnum
+	var err error
+	var result []byte
+
+	err = json.Unmarshal([]byte(`""`), &enum)
+	if nil == err {
+		t.Errorf("Expected error, got nil")
+	}
+
+	result, err = json.Marshal(enum)
+	if nil != err {
+		t.Errorf("Expected nil, got error")
+	}
+	if `""` != string(result) {
+		t.Errorf("Expected empty JSON string, got '%s'", result)
+	}
+
+	enum = DownloadState.InProgress
+	result, err = json.Marshal(enum)
+	if nil != err {
+		t.Errorf("Expected nil, got error")
+	}
+	if `"inProgress"` != string(result) {
+		t.Errorf("Expected '\"inProgress\"', got '%s'", result)
+	}
+	json.Unmarshal([]byte(`"inProgress"`), &enum)
+	if DownloadState.InProgress != enum {
+		t.Errorf("Expcected %d, got %d", DownloadState.InProgress, enum)
+	}
+
+	enum = DownloadState.Completed
+	result, err = json.Marshal(enum)
+	if nil != err {
+		t.Errorf("Expected nil, got error")
+	}
+	if `"completed"` != string(result) {
+		t.Errorf("Expected '\"completed\"', got '%s'", result)
+	}
+	json.Unmarshal([]byte(`"completed"`), &enum)
+	if DownloadState.Completed != enum {
+		t.Errorf("Expcected %d, got %d", DownloadState.Completed, enum)
+	}
+
+	enum = DownloadState.Canceled
+	result, err = json.Marshal(enum)
+	if nil != err {
+		t.Errorf("Expected nil, got error")
+	}
+	if `"canceled"` != string(result) {
+		t.Errorf("Expected '\"canceled\"', got '%s'", result)
+	}
+	json.Unmarshal([]byte(`"canceled"`), &enum)
+	if DownloadState.Canceled != enum {
+		t.Errorf("Expcected %d, got %d", DownloadState.Canceled, enum)
+	}
+}
@@ -16,6 +16,54 @@ type DOMContentEventFiredEvent struct {
 	Err error `json:"-"`
 }
 
+/*
+DownloadProgressEvent represents Page.downloadProgress event data.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Page/#event-downloadProgress
+*/
+type DownloadProgressEvent struct {
+	// Global unique identifier of the download.
+	GUID string `json:"guid"`
+
+	// Total expected bytes to download.
+	TotalBytes float64 `json:"totalBytes"`
+
+	// Total bytes received.
+	ReceivedBytes float64 `json:"receivedBytes"`
+
+	// Download status. Allowed values:
+	//	- DownloadState.InProgress
+	//	- DownloadState.Completed
+	//	- DownloadState.Canceled
+	State DownloadStateEnum `json:"state"`
+
+	// Error information related to this event
+	Err error `json:"-"`
+}
+
+/*
+DownloadWillBeginEvent represents Page.downloadWillBegin event data.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Page/#event-downloadWillBegin
+*/
+type DownloadWillBeginEvent struct {
+	// ID of the frame that caused the download to begin.
+	FrameID FrameID `json:"frameId"`
+
+	// Global unique identifier of the download.
+	GUID string `json:"guid"`
+
+	// URL of the resource being downloaded.
+	URL string `json:"url"`
+
+	// Suggested file name of the resource (the actual name of the file saved on
+	// disk may differ).
+	SuggestedFilename string `json:"suggestedFilename"`
+
+	// Error information related to this event
+	Err error `json:"-"`
+}
+
 /*
 FrameAttachedEvent represents Page.frameAttached event data.
 
@@ -295,7 +295,7 @@ type ExecutionContextDescription struct {
 	Name string `json:"name"`
 
 	// Optional. Embedder-specific auxiliary data.
-	AuxData map[string]string `json:"auxData,omitempty"`
+	AuxData map[string]interface{} `json:"auxData,omitempty"`
 }
 
 /*
@@ -340,7 +340,7 @@ Timestamp is the number of milliseconds since epoch.
 
 https://chromedevtools.github.io/devtools-protocol/tot/Runtime/#type-Timestamp
 */
-type Timestamp int
+type Timestamp float64
 
 /*
 CallFrame is a stack entry for runtime errors and assertions.
@@ -1,5 +1,30 @@
 package runtime
 
+/*
+AddBindingParams represents Runtime.addBinding parameters.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Runtime/#method-addBinding
+*/
+type AddBindingParams struct {
+	// Name of the binding function.
+	Name string `json:"name"`
+
+	// Optional. If specified, the binding is only exposed to the specified
+	// execution context, otherwise it is exposed to all existing and future
+	// execution contexts.
+	ExecutionContextID ExecutionContextID `json:"executionContextId,omitempty"`
+}
+
+/*
+AddBindingResult represents the result of calls to Runtime.addBinding.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Runtime/#method-addBinding
+*/
+type AddBindingResult struct {
+	// Error information related to executing this method
+	Err error `json:"-"`
+}
+
 /*
 AwaitPromiseParams represents Runtime.awaitPromise parameters.
 
@@ -349,6 +374,26 @@ type ReleaseObjectGroupResult struct {
 	Err error `json:"-"`
 }
 
+/*
+RemoveBindingParams represents Runtime.removeBinding parameters.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Runtime/#method-removeBinding
+*/
+type RemoveBindingParams struct {
+	// Name of the binding function.
+	Name string `json:"name"`
+}
+
+/*
+RemoveBindingResult represents the result of calls to Runtime.removeBinding.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Runtime/#method-removeBinding
+*/
+type RemoveBindingResult struct {
+	// Error information related to executing this method
+	Err error `json:"-"`
+}
+
 /*
 RunIfWaitingForDebuggerResult represents the result of calls to Runtime.runIfWaitingForDebugger.
 
@@ -1,5 +1,24 @@
 package runtime
 
+/*
+BindingCalledEvent represents Runtime.bindingCalled event data.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Runtime/#event-bindingCalled
+*/
+type BindingCalledEvent struct {
+	// Name of the binding function.
+	Name string `json:"name"`
+
+	// The string passed to the binding function.
+	Payload string `json:"payload"`
+
+	// Identifier of the context where the call was made.
+	ExecutionContextID ExecutionContextID `json:"executionContextId"`
+
+	// Error information related to this event
+	Err error `json:"-"`
+}
+
 /*
 ConsoleAPICalledEvent represents Runtime.consoleAPICalled event data.
 
@@ -370,7 +370,7 @@ func (protocol *EmulationProtocol) SetVirtualTimePolicy(
 	params *emulation.SetVirtualTimePolicyParams,
 ) <-chan *emulation.SetVirtualTimePolicyResult {
 	resultChan := make(chan *emulation.SetVirtualTimePolicyResult)
-	command := NewCommand(protocol.Socket, "Emulation.SetVirtualTimePolicy", nil)
+	command := NewCommand(protocol.Socket, "Emulation.setVirtualTimePolicy", params)
 	result := &emulation.SetVirtualTimePolicyResult{}
 
 	go func() {
@@ -0,0 +1,132 @@
+package socket
+
+import (
+	"encoding/json"
+
+	"github.com/mkenney/go-chrome/tot/inspector"
+)
+
+/*
+InspectorProtocol provides a namespace for the Chrome Inspector protocol
+methods.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Inspector/ EXPERIMENTAL.
+*/
+type InspectorProtocol struct {
+	Socket Socketer
+}
+
+/*
+Disable disables inspector domain notifications.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Inspector/#method-disable
+*/
+func (protocol *InspectorProtocol) Disable() <-chan *inspector.DisableResult {
+	resultChan := make(chan *inspector.DisableResult)
+	command := NewCommand(protocol.Socket, "Inspector.disable", nil)
+	result := &inspector.DisableResult{}
+
+	go func() {
+		response := <-protocol.Socket.SendCommand(command)
+		if nil != response.Error && 0 != response.Error.Code {
+			result.Err = response.Error
+		}
+		resultChan <- result
+		close(resultChan)
+	}()
+
+	return resultChan
+}
+
+/*
+Enable enables inspector domain notifications.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Inspector/#method-enable
+*/
+func (protocol *InspectorProtocol) Enable() <-chan *inspector.EnableResult {
+	resultChan := make(chan *inspector.EnableResult)
+	command := NewCommand(protocol.Socket, "Inspector.enable", nil)
+	result := &inspector.EnableResult{}
+
+	go func() {
+		response := <-protocol.Socket.SendCommand(command)
+		if nil != response.Error && 0 != response.Error.Code {
+			result.Err = response.Error
+		}
+		resultChan <- result
+		close(resultChan)
+	}()
+
+	return resultChan
+}
+
+/*
+OnDetached adds a handler to the Inspector.detached event. Inspector.detached
+fires when remote debugging connection is about to be terminated. Contains
+detach reason.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Inspector/#event-detached
+*/
+func (protocol *InspectorProtocol) OnDetached(
+	callback func(event *inspector.DetachedEvent),
+) {
+	handler := NewEventHandler(
+		"Inspector.detached",
+		func(response *Response) {
+			event := &inspector.DetachedEvent{}
+			json.Unmarshal([]byte(response.Params), event)
+			if nil != response.Error && 0 != response.Error.Code {
+				event.Err = response.Error
+			}
+			callback(event)
+		},
+	)
+	protocol.Socket.AddEventHandler(handler)
+}
+
+/*
+OnTargetCrashed adds a handler to the Inspector.targetCrashed event.
+Inspector.targetCrashed fires when debugging target has crashed.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Inspector/#event-targetCrashed
+*/
+func (protocol *InspectorProtocol) OnTargetCrashed(
+	callback func(event *inspector.TargetCrashedEvent),
+) {
+	handler := NewEventHandler(
+		"Inspector.targetCrashed",
+		func(response *Response) {
+			event := &inspector.TargetCrashedEvent{}
+			json.Unmarshal([]byte(response.Params), event)
+			if nil != response.Error && 0 != response.Error.Code {
+				event.Err = response.Error
+			}
+			callback(event)
+		},
+	)
+	protocol.Socket.AddEventHandler(handler)
+}
+
+/*
+OnTargetReloadedAfterCrash adds a handler to the
+Inspector.targetReloadedAfterCrash event. Inspector.targetReloadedAfterCrash
+fires when debugging target has reloaded after crash.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Inspector/#event-targetReloadedAfterCrash
+*/
+func (protocol *InspectorProtocol) OnTargetReloadedAfterCrash(
+	callback func(event *inspector.TargetReloadedAfterCrashEvent),
+) {
+	handler := NewEventHandler(
+		"Inspector.targetReloadedAfterCrash",
+		func(response *Response) {
+			event := &inspector.TargetReloadedAfterCrashEvent{}
+			json.Unmarshal([]byte(response.Params), event)
+			if nil != response.Error && 0 != response.Error.Code {
+				event.Err = response.Error
+			}
+			callback(event)
+		},
+	)
+	protocol.Socket.AddEventHandler(handler)
+}
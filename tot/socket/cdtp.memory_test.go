@@ -20,7 +20,11 @@ func TestMemoryGetDOMCounters(t *testing.T) {
 		JsEventListeners: 1,
 	}
 	resultChan := mockSocket.Memory().GetDOMCounters(params)
-	mockResult := &memory.GetDOMCountersResult{}
+	mockResult := &memory.GetDOMCountersResult{
+		Documents:        1,
+		Nodes:            2,
+		JsEventListeners: 3,
+	}
 	mockResultBytes, _ := json.Marshal(mockResult)
 	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
 		ID:     mockSocket.CurCommandID(),
@@ -31,6 +35,9 @@ func TestMemoryGetDOMCounters(t *testing.T) {
 	if nil != result.Err {
 		t.Errorf("Expected nil, got error: '%s'", result.Err.Error())
 	}
+	if mockResult.Nodes != result.Nodes {
+		t.Errorf("Expected %d, got %d", mockResult.Nodes, result.Nodes)
+	}
 
 	resultChan = mockSocket.Memory().GetDOMCounters(params)
 	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
@@ -411,7 +411,7 @@ func TestNetworkGetAllCookies(t *testing.T) {
 			Value:    "value",
 			Domain:   "domain",
 			Path:     "/",
-			Expires:  time.Now().Unix() + 10,
+			Expires:  float64(time.Now().Unix() + 10),
 			Size:     1,
 			HTTPOnly: true,
 			Secure:   true,
@@ -506,7 +506,7 @@ func TestNetworkGetCookies(t *testing.T) {
 			Value:    "value",
 			Domain:   "domain",
 			Path:     "/",
-			Expires:  time.Now().Unix() + 10,
+			Expires:  float64(time.Now().Unix() + 10),
 			Size:     1,
 			HTTPOnly: true,
 			Secure:   true,
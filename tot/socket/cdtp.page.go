@@ -806,6 +806,55 @@ func (protocol *PageProtocol) OnDOMContentEventFired(
 	protocol.Socket.AddEventHandler(handler)
 }
 
+/*
+OnDownloadProgress adds a handler to the Page.downloadProgress event.
+Page.downloadProgress fires when download progress is updated, the last call
+has a completed or canceled state.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Page/#event-downloadProgress
+EXPERIMENTAL.
+*/
+func (protocol *PageProtocol) OnDownloadProgress(
+	callback func(event *page.DownloadProgressEvent),
+) {
+	handler := NewEventHandler(
+		"Page.downloadProgress",
+		func(response *Response) {
+			event := &page.DownloadProgressEvent{}
+			json.Unmarshal([]byte(response.Params), event)
+			if nil != response.Error && 0 != response.Error.Code {
+				event.Err = response.Error
+			}
+			callback(event)
+		},
+	)
+	protocol.Socket.AddEventHandler(handler)
+}
+
+/*
+OnDownloadWillBegin adds a handler to the Page.downloadWillBegin event.
+Page.downloadWillBegin fires when a page is about to start a download.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Page/#event-downloadWillBegin
+EXPERIMENTAL.
+*/
+func (protocol *PageProtocol) OnDownloadWillBegin(
+	callback func(event *page.DownloadWillBeginEvent),
+) {
+	handler := NewEventHandler(
+		"Page.downloadWillBegin",
+		func(response *Response) {
+			event := &page.DownloadWillBeginEvent{}
+			json.Unmarshal([]byte(response.Params), event)
+			if nil != response.Error && 0 != response.Error.Code {
+				event.Err = response.Error
+			}
+			callback(event)
+		},
+	)
+	protocol.Socket.AddEventHandler(handler)
+}
+
 /*
 OnFrameAttached adds a handler to the Page.frameAttached event. Page.frameAttached
 fires when a frame has been attached to its parent.
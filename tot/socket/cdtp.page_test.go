@@ -1336,6 +1336,112 @@ func TestPageOnDOMContentEventFired(t *testing.T) {
 	}
 }
 
+func TestPageOnDownloadProgress(t *testing.T) {
+	socketURL, _ := url.Parse("https://test:9222/TestPageOnDownloadProgress")
+	mockSocket := NewMock(socketURL)
+	mockSocket.Listen()
+	defer mockSocket.Stop()
+
+	resultChan := make(chan *page.DownloadProgressEvent)
+	mockSocket.Page().OnDownloadProgress(func(eventData *page.DownloadProgressEvent) {
+		resultChan <- eventData
+	})
+	mockResult := &page.DownloadProgressEvent{
+		GUID:          "download-guid",
+		TotalBytes:    1024,
+		ReceivedBytes: 1024,
+		State:         page.DownloadState.Completed,
+	}
+	mockResultBytes, _ := json.Marshal(mockResult)
+	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
+		ID:     0,
+		Error:  &Error{},
+		Method: "Page.downloadProgress",
+		Params: mockResultBytes,
+	})
+	result := <-resultChan
+	if mockResult.Err != result.Err {
+		t.Errorf("Expected '%v', got: '%v'", mockResult, result)
+	}
+	if mockResult.GUID != result.GUID {
+		t.Errorf("Expected %s, got %s", mockResult.GUID, result.GUID)
+	}
+	if mockResult.State != result.State {
+		t.Errorf("Expected %s, got %s", mockResult.State, result.State)
+	}
+
+	resultChan = make(chan *page.DownloadProgressEvent)
+	mockSocket.Page().OnDownloadProgress(func(eventData *page.DownloadProgressEvent) {
+		resultChan <- eventData
+	})
+	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
+		ID: 0,
+		Error: &Error{
+			Code:    1,
+			Data:    []byte(`"error data"`),
+			Message: "error message",
+		},
+		Method: "Page.downloadProgress",
+	})
+	result = <-resultChan
+	if nil == result.Err {
+		t.Errorf("Expected error, got success")
+	}
+}
+
+func TestPageOnDownloadWillBegin(t *testing.T) {
+	socketURL, _ := url.Parse("https://test:9222/TestPageOnDownloadWillBegin")
+	mockSocket := NewMock(socketURL)
+	mockSocket.Listen()
+	defer mockSocket.Stop()
+
+	resultChan := make(chan *page.DownloadWillBeginEvent)
+	mockSocket.Page().OnDownloadWillBegin(func(eventData *page.DownloadWillBeginEvent) {
+		resultChan <- eventData
+	})
+	mockResult := &page.DownloadWillBeginEvent{
+		FrameID:           page.FrameID("frame-id"),
+		GUID:              "download-guid",
+		URL:               "http://some.url/report.csv",
+		SuggestedFilename: "report.csv",
+	}
+	mockResultBytes, _ := json.Marshal(mockResult)
+	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
+		ID:     0,
+		Error:  &Error{},
+		Method: "Page.downloadWillBegin",
+		Params: mockResultBytes,
+	})
+	result := <-resultChan
+	if mockResult.Err != result.Err {
+		t.Errorf("Expected '%v', got: '%v'", mockResult, result)
+	}
+	if mockResult.GUID != result.GUID {
+		t.Errorf("Expected %s, got %s", mockResult.GUID, result.GUID)
+	}
+	if mockResult.SuggestedFilename != result.SuggestedFilename {
+		t.Errorf("Expected %s, got %s", mockResult.SuggestedFilename, result.SuggestedFilename)
+	}
+
+	resultChan = make(chan *page.DownloadWillBeginEvent)
+	mockSocket.Page().OnDownloadWillBegin(func(eventData *page.DownloadWillBeginEvent) {
+		resultChan <- eventData
+	})
+	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
+		ID: 0,
+		Error: &Error{
+			Code:    1,
+			Data:    []byte(`"error data"`),
+			Message: "error message",
+		},
+		Method: "Page.downloadWillBegin",
+	})
+	result = <-resultChan
+	if nil == result.Err {
+		t.Errorf("Expected error, got success")
+	}
+}
+
 func TestPageOnFrameAttached(t *testing.T) {
 	socketURL, _ := url.Parse("https://test:9222/TestPageOnFrameAttached")
 	mockSocket := NewMock(socketURL)
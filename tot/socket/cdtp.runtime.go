@@ -21,6 +21,32 @@ type RuntimeProtocol struct {
 	Socket Socketer
 }
 
+/*
+AddBinding adds a binding function to the global object of execution contexts.
+Calling the binding function with a string argument fires the
+Runtime.bindingCalled event.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Runtime/#method-addBinding
+*/
+func (protocol *RuntimeProtocol) AddBinding(
+	params *runtime.AddBindingParams,
+) <-chan *runtime.AddBindingResult {
+	resultChan := make(chan *runtime.AddBindingResult)
+	command := NewCommand(protocol.Socket, "Runtime.addBinding", params)
+	result := &runtime.AddBindingResult{}
+
+	go func() {
+		response := <-protocol.Socket.SendCommand(command)
+		if nil != response.Error && 0 != response.Error.Code {
+			result.Err = response.Error
+		}
+		resultChan <- result
+		close(resultChan)
+	}()
+
+	return resultChan
+}
+
 /*
 AwaitPromise adds handler to promise with given promise object ID.
 
@@ -322,6 +348,32 @@ func (protocol *RuntimeProtocol) ReleaseObjectGroup(
 	return resultChan
 }
 
+/*
+RemoveBinding removes a binding function added with AddBinding. The binding
+function remains in execution contexts that already exist but no longer fires
+the Runtime.bindingCalled event.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Runtime/#method-removeBinding
+*/
+func (protocol *RuntimeProtocol) RemoveBinding(
+	params *runtime.RemoveBindingParams,
+) <-chan *runtime.RemoveBindingResult {
+	resultChan := make(chan *runtime.RemoveBindingResult)
+	command := NewCommand(protocol.Socket, "Runtime.removeBinding", params)
+	result := &runtime.RemoveBindingResult{}
+
+	go func() {
+		response := <-protocol.Socket.SendCommand(command)
+		if nil != response.Error && 0 != response.Error.Code {
+			result.Err = response.Error
+		}
+		resultChan <- result
+		close(resultChan)
+	}()
+
+	return resultChan
+}
+
 /*
 RunIfWaitingForDebugger tells inspected instance to run if it was waiting for
 debugger to attach.
@@ -396,6 +448,30 @@ func (protocol *RuntimeProtocol) SetCustomObjectFormatterEnabled(
 	return resultChan
 }
 
+/*
+OnBindingCalled adds a handler to the Runtime.bindingCalled event.
+Runtime.bindingCalled fires when a binding function added with AddBinding is
+called.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Runtime/#event-bindingCalled
+*/
+func (protocol *RuntimeProtocol) OnBindingCalled(
+	callback func(event *runtime.BindingCalledEvent),
+) {
+	handler := NewEventHandler(
+		"Runtime.bindingCalled",
+		func(response *Response) {
+			event := &runtime.BindingCalledEvent{}
+			json.Unmarshal([]byte(response.Params), event)
+			if nil != response.Error && 0 != response.Error.Code {
+				event.Err = response.Error
+			}
+			callback(event)
+		},
+	)
+	protocol.Socket.AddEventHandler(handler)
+}
+
 /*
 OnConsoleAPICalled adds a handler to the Runtime.consoleAPICalled event.
 Runtime.consoleAPICalled fires when the console API is called.
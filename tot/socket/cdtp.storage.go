@@ -39,6 +39,32 @@ func (protocol *StorageProtocol) ClearDataForOrigin(
 	return resultChan
 }
 
+/*
+GetCookies returns all browser cookies.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Storage/#method-getCookies
+*/
+func (protocol *StorageProtocol) GetCookies(
+	params *storage.GetCookiesParams,
+) <-chan *storage.GetCookiesResult {
+	resultChan := make(chan *storage.GetCookiesResult)
+	command := NewCommand(protocol.Socket, "Storage.getCookies", params)
+	result := &storage.GetCookiesResult{}
+
+	go func() {
+		response := <-protocol.Socket.SendCommand(command)
+		if nil != response.Error && 0 != response.Error.Code {
+			result.Err = response.Error
+		} else {
+			result.Err = json.Unmarshal(response.Result, &result)
+		}
+		resultChan <- result
+		close(resultChan)
+	}()
+
+	return resultChan
+}
+
 /*
 GetUsageAndQuota returns usage and quota in bytes.
 
@@ -65,6 +91,30 @@ func (protocol *StorageProtocol) GetUsageAndQuota(
 	return resultChan
 }
 
+/*
+SetCookies sets given cookies.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Storage/#method-setCookies
+*/
+func (protocol *StorageProtocol) SetCookies(
+	params *storage.SetCookiesParams,
+) <-chan *storage.SetCookiesResult {
+	resultChan := make(chan *storage.SetCookiesResult)
+	command := NewCommand(protocol.Socket, "Storage.setCookies", params)
+	result := &storage.SetCookiesResult{}
+
+	go func() {
+		response := <-protocol.Socket.SendCommand(command)
+		if nil != response.Error && 0 != response.Error.Code {
+			result.Err = response.Error
+		}
+		resultChan <- result
+		close(resultChan)
+	}()
+
+	return resultChan
+}
+
 /*
 TrackCacheStorageForOrigin registers origin to be notified when an update occurs
 to its cache storage list.
@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/mkenney/go-chrome/tot/network"
 	"github.com/mkenney/go-chrome/tot/storage"
 )
 
@@ -46,6 +47,53 @@ func TestStorageClearDataForOrigin(t *testing.T) {
 	}
 }
 
+func TestStorageGetCookies(t *testing.T) {
+	socketURL, _ := url.Parse("https://test:9222/TestStorageGetCookies")
+	mockSocket := NewMock(socketURL)
+	mockSocket.Listen()
+	defer mockSocket.Stop()
+
+	params := &storage.GetCookiesParams{
+		BrowserContextID: "context-id",
+	}
+	resultChan := mockSocket.Storage().GetCookies(params)
+	mockResult := &storage.GetCookiesResult{
+		Cookies: []*network.Cookie{{
+			Name:   "name",
+			Value:  "value",
+			Domain: "example.com",
+			Path:   "/",
+		}},
+	}
+	mockResultBytes, _ := json.Marshal(mockResult)
+	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
+		ID:     mockSocket.CurCommandID(),
+		Error:  &Error{},
+		Result: mockResultBytes,
+	})
+	result := <-resultChan
+	if nil != result.Err {
+		t.Errorf("Expected nil, got error: '%s'", result.Err.Error())
+	}
+	if 1 != len(result.Cookies) || mockResult.Cookies[0].Name != result.Cookies[0].Name {
+		t.Errorf("Expected %v, got %v", mockResult.Cookies, result.Cookies)
+	}
+
+	resultChan = mockSocket.Storage().GetCookies(params)
+	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
+		ID: mockSocket.CurCommandID(),
+		Error: &Error{
+			Code:    1,
+			Data:    []byte(`"error data"`),
+			Message: "error message",
+		},
+	})
+	result = <-resultChan
+	if nil == result.Err {
+		t.Errorf("Expected error, got success")
+	}
+}
+
 func TestStorageGetUsageAndQuota(t *testing.T) {
 	socketURL, _ := url.Parse("https://test:9222/TestStorageGetUsageAndQuota")
 	mockSocket := NewMock(socketURL)
@@ -93,6 +141,48 @@ func TestStorageGetUsageAndQuota(t *testing.T) {
 	}
 }
 
+func TestStorageSetCookies(t *testing.T) {
+	socketURL, _ := url.Parse("https://test:9222/TestStorageSetCookies")
+	mockSocket := NewMock(socketURL)
+	mockSocket.Listen()
+	defer mockSocket.Stop()
+
+	params := &storage.SetCookiesParams{
+		Cookies: []*network.SetCookieParams{{
+			Name:  "name",
+			Value: "value",
+			URL:   "https://example.com/",
+		}},
+		BrowserContextID: "context-id",
+	}
+	resultChan := mockSocket.Storage().SetCookies(params)
+	mockResult := &storage.SetCookiesResult{}
+	mockResultBytes, _ := json.Marshal(mockResult)
+	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
+		ID:     mockSocket.CurCommandID(),
+		Error:  &Error{},
+		Result: mockResultBytes,
+	})
+	result := <-resultChan
+	if nil != result.Err {
+		t.Errorf("Expected nil, got error: '%s'", result.Err.Error())
+	}
+
+	resultChan = mockSocket.Storage().SetCookies(params)
+	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
+		ID: mockSocket.CurCommandID(),
+		Error: &Error{
+			Code:    1,
+			Data:    []byte(`"error data"`),
+			Message: "error message",
+		},
+	})
+	result = <-resultChan
+	if nil == result.Err {
+		t.Errorf("Expected error, got success")
+	}
+}
+
 func TestStorageTrackCacheStorageForOrigin(t *testing.T) {
 	socketURL, _ := url.Parse("https://test:9222/TestStorageTrackCacheStorageForOrigin")
 	mockSocket := NewMock(socketURL)
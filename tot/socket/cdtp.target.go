@@ -99,9 +99,11 @@ profile but you can have more than one.
 https://chromedevtools.github.io/devtools-protocol/tot/Target/#method-createBrowserContext
 EXPERIMENTAL.
 */
-func (protocol *TargetProtocol) CreateBrowserContext() <-chan *target.CreateBrowserContextResult {
+func (protocol *TargetProtocol) CreateBrowserContext(
+	params *target.CreateBrowserContextParams,
+) <-chan *target.CreateBrowserContextResult {
 	resultChan := make(chan *target.CreateBrowserContextResult)
-	command := NewCommand(protocol.Socket, "Target.createBrowserContext", nil)
+	command := NewCommand(protocol.Socket, "Target.createBrowserContext", params)
 	result := &target.CreateBrowserContextResult{}
 
 	go func() {
@@ -135,7 +135,11 @@ func TestTargetCreateBrowserContext(t *testing.T) {
 	mockSocket.Listen()
 	defer mockSocket.Stop()
 
-	resultChan := mockSocket.Target().CreateBrowserContext()
+	params := &target.CreateBrowserContextParams{
+		ProxyServer:     "http://proxy:3128",
+		ProxyBypassList: "localhost",
+	}
+	resultChan := mockSocket.Target().CreateBrowserContext(params)
 	mockResult := &target.CreateBrowserContextResult{
 		BrowserContextID: target.BrowserContextID("BrowserContextID"),
 	}
@@ -153,7 +157,7 @@ func TestTargetCreateBrowserContext(t *testing.T) {
 		t.Errorf("Expected %s, got %s", mockResult.BrowserContextID, result.BrowserContextID)
 	}
 
-	resultChan = mockSocket.Target().CreateBrowserContext()
+	resultChan = mockSocket.Target().CreateBrowserContext(params)
 	mockSocket.Conn().(*MockChromeWebSocket).AddMockData(&Response{
 		ID: mockSocket.CurCommandID(),
 		Error: &Error{
@@ -259,7 +263,7 @@ func TestTargetDisposeBrowserContext(t *testing.T) {
 	defer mockSocket.Stop()
 
 	params := &target.DisposeBrowserContextParams{
-		ID: target.ID("ID"),
+		BrowserContextID: target.BrowserContextID("BrowserContextID"),
 	}
 	resultChan := mockSocket.Target().DisposeBrowserContext(params)
 	mockResult := &target.DisposeBrowserContextResult{
@@ -66,6 +66,9 @@ type Protocoller interface {
 	// Input returns the InputProtocol instance.
 	Input() *InputProtocol
 
+	// Inspector returns the InspectorProtocol instance.
+	Inspector() *InspectorProtocol
+
 	// IO returns the IOProtocol instance.
 	IO() *IOProtocol
 
@@ -18,7 +18,9 @@ func NewMock(socketURL *url.URL) *Socket {
 	socket := &Socket{
 		commandIDMux: &sync.Mutex{},
 		commands:     NewCommandMap(),
+		failureMux:   &sync.Mutex{},
 		handlers:     NewEventHandlerMap(),
+		listeningMux: &sync.Mutex{},
 		mux:          &sync.Mutex{},
 		newSocket:    NewMockWebsocket,
 		socketID:     NextSocketID(),
@@ -46,6 +48,7 @@ func NewMock(socketURL *url.URL) *Socket {
 	socket.heapProfiler = &HeapProfilerProtocol{Socket: socket}
 	socket.indexedDB = &IndexedDBProtocol{Socket: socket}
 	socket.input = &InputProtocol{Socket: socket}
+	socket.inspector = &InspectorProtocol{Socket: socket}
 	socket.io = &IOProtocol{Socket: socket}
 	socket.layerTree = &LayerTreeProtocol{Socket: socket}
 	socket.log = &LogProtocol{Socket: socket}
@@ -38,6 +38,20 @@ func (stack *CommandMap) Delete(id int) {
 	stack.mux.Unlock()
 }
 
+/*
+Drain removes all commands from the stack and returns them.
+*/
+func (stack *CommandMap) Drain() []Commander {
+	stack.mux.Lock()
+	defer stack.mux.Unlock()
+	commands := make([]Commander, 0, len(stack.stack))
+	for id, command := range stack.stack {
+		commands = append(commands, command)
+		delete(stack.stack, id)
+	}
+	return commands
+}
+
 /*
 Get retrieves a command from the stack.
 
@@ -63,3 +77,18 @@ func (stack *CommandMap) Set(cmd Commander) {
 	stack.stack[cmd.ID()] = cmd
 	stack.mux.Unlock()
 }
+
+/*
+Take removes a command from the stack and returns it. Only one caller receives
+a command, so it is responded to once.
+*/
+func (stack *CommandMap) Take(id int) (Commander, error) {
+	stack.mux.Lock()
+	defer stack.mux.Unlock()
+	command, ok := stack.stack[id]
+	if !ok {
+		return nil, errs.New(0, fmt.Sprintf("Command %d not found", id))
+	}
+	delete(stack.stack, id)
+	return command, nil
+}
@@ -11,3 +11,29 @@ func TestSocketCommandMapperError(t *testing.T) {
 		t.Errorf("Expected nil, got error: '%s'", err.Error())
 	}
 }
+
+func TestSocketCommandMapperDrain(t *testing.T) {
+	commandMap := NewCommandMap()
+	commandMap.Set(&Command{id: 1})
+	commandMap.Set(&Command{id: 2})
+	if commands := commandMap.Drain(); 2 != len(commands) {
+		t.Errorf("Expected 2 commands, got %d", len(commands))
+	}
+	if _, err := commandMap.Get(1); nil == err {
+		t.Errorf("Expected error, got nil")
+	}
+	if commands := commandMap.Drain(); 0 != len(commands) {
+		t.Errorf("Expected no commands, got %d", len(commands))
+	}
+}
+
+func TestSocketCommandMapperTake(t *testing.T) {
+	commandMap := NewCommandMap()
+	commandMap.Set(&Command{id: 1})
+	if command, err := commandMap.Take(1); nil != err || 1 != command.ID() {
+		t.Errorf("Expected command 1, got error: '%v'", err)
+	}
+	if _, err := commandMap.Take(1); nil == err {
+		t.Errorf("Expected error, got nil")
+	}
+}
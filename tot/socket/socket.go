@@ -12,6 +12,12 @@ import (
 	"fmt"
 )
 
+/*
+ErrorCodeClosed is the Error code used to fail commands when the socket has been
+failed with Socket.Fail, for example because the browser exited.
+*/
+const ErrorCodeClosed = -32099
+
 /*
 Error represents a socket response error.
 */
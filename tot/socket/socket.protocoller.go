@@ -180,6 +180,15 @@ func (socket *Socket) Input() *InputProtocol {
 	return socket.input
 }
 
+/*
+Inspector returns the InspectorProtocol instance.
+
+Inspector is a Protocoller implementation.
+*/
+func (socket *Socket) Inspector() *InspectorProtocol {
+	return socket.inspector
+}
+
 /*
 IO returns the IOProtocol instance.
 
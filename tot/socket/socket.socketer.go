@@ -19,7 +19,9 @@ func New(url *url.URL) *Socket {
 	socket := &Socket{
 		commandIDMux: &sync.Mutex{},
 		commands:     NewCommandMap(),
+		failureMux:   &sync.Mutex{},
 		handlers:     NewEventHandlerMap(),
+		listeningMux: &sync.Mutex{},
 		mux:          &sync.Mutex{},
 		newSocket:    NewWebsocket,
 		socketID:     NextSocketID(),
@@ -47,6 +49,7 @@ func New(url *url.URL) *Socket {
 	socket.heapProfiler = &HeapProfilerProtocol{Socket: socket}
 	socket.indexedDB = &IndexedDBProtocol{Socket: socket}
 	socket.input = &InputProtocol{Socket: socket}
+	socket.inspector = &InspectorProtocol{Socket: socket}
 	socket.io = &IOProtocol{Socket: socket}
 	socket.layerTree = &LayerTreeProtocol{Socket: socket}
 	socket.log = &LogProtocol{Socket: socket}
@@ -96,13 +99,16 @@ Socket is a Socketer implementation.
 type Socket struct {
 	commandID    int
 	commandIDMux *sync.Mutex
-	commands     CommandMapper
+	commands     *CommandMap
 	conn         WebSocketer
 	connected    bool
+	failure      *Error
+	failureMux   *sync.Mutex
 	handlers     EventHandlerMapper
 	listenCh     chan bool
 	listenErr    errs.Err
 	listening    bool
+	listeningMux *sync.Mutex
 	mux          *sync.Mutex
 	newSocket    func(socketURL *url.URL) (WebSocketer, error)
 	socketID     int
@@ -129,6 +135,7 @@ type Socket struct {
 	heapProfiler         *HeapProfilerProtocol
 	indexedDB            *IndexedDBProtocol
 	input                *InputProtocol
+	inspector            *InspectorProtocol
 	io                   *IOProtocol
 	layerTree            *LayerTreeProtocol
 	log                  *LogProtocol
@@ -172,13 +179,66 @@ func (socket *Socket) CurCommandID() int {
 	return id
 }
 
+/*
+Fail stops the socket and responds to all pending commands with an Error with
+the ErrorCodeClosed code, for example when the browser has exited. Commands
+sent after Fail fail the same way.
+*/
+func (socket *Socket) Fail(err error) error {
+	if nil == err {
+		err = errs.New(0, "socket failed")
+	}
+	data, _ := json.Marshal(err.Error())
+	socket.failureMux.Lock()
+	socket.failure = &Error{
+		Code:    ErrorCodeClosed,
+		Data:    data,
+		Message: "socket closed",
+	}
+	socket.failureMux.Unlock()
+
+	for _, command := range socket.commands.Drain() {
+		go socket.fail(command)
+	}
+	log.WithFields(log.Fields{
+		"error":    err,
+		"socketID": socket.socketID,
+	}).Debug("socket failed")
+	return socket.Stop()
+}
+
+/*
+fail responds to a command with the socket failure, if any, and reports whether
+it did.
+*/
+func (socket *Socket) fail(command Commander) bool {
+	failure := socket.failed()
+	if nil == failure {
+		return false
+	}
+	command.Respond(&Response{
+		Error: failure,
+		ID:    command.ID(),
+	})
+	return true
+}
+
+/*
+failed returns the socket failure, or nil if the socket hasn't failed.
+*/
+func (socket *Socket) failed() *Error {
+	socket.failureMux.Lock()
+	defer socket.failureMux.Unlock()
+	return socket.failure
+}
+
 /*
 handleResponse receives the responses to requests sent to the websocket
 connection.
 */
 func (socket *Socket) handleResponse(response *Response) {
 	// Log a message on error
-	if command, err := socket.commands.Get(response.ID); nil != err {
+	if command, err := socket.commands.Take(response.ID); nil != err {
 		err = errs.Wrap(err, 0, fmt.Sprintf("command #%d not found", response.ID))
 		log.WithFields(log.Fields{
 			"error":    err,
@@ -193,7 +253,6 @@ func (socket *Socket) handleResponse(response *Response) {
 			"socketID":  socket.socketID,
 		}).Debug("executing handler")
 		command.Respond(response)
-		socket.commands.Delete(command.ID())
 		log.WithFields(log.Fields{
 			"commandID": command.ID(),
 			"method":    command.Method(),
@@ -283,7 +342,7 @@ Listen is a Socketer implementation.
 */
 func (socket *Socket) Listen() {
 	socket.listenCh = make(chan bool)
-	socket.listening = true
+	socket.setListening(true)
 	go socket.listen()
 }
 
@@ -345,7 +404,7 @@ func (socket *Socket) listen() error {
 			socket.handleUnknown(response)
 		}
 
-		if !socket.listening {
+		if !socket.isListening() {
 			log.WithFields(log.Fields{
 				"socketID": socket.socketID,
 				"url":      socket.url.String(),
@@ -363,10 +422,19 @@ func (socket *Socket) listen() error {
 	if nil != err {
 		err = errs.Wrap(err, 0, "socket read failed")
 	}
-	socket.listening = false
+	socket.setListening(false)
 	return err
 }
 
+/*
+isListening reports whether the read loop is running.
+*/
+func (socket *Socket) isListening() bool {
+	socket.listeningMux.Lock()
+	defer socket.listeningMux.Unlock()
+	return socket.listening
+}
+
 /*
 NextCommandID generates and returns the next command ID.
 
@@ -440,6 +508,9 @@ func (socket *Socket) SendCommand(command Commander) chan *Response {
 		"socketID":  socket.socketID,
 	}).Debug("sending command payload to socket")
 	go func() {
+		if socket.fail(command) {
+			return
+		}
 		payload := &Payload{
 			ID:     command.ID(),
 			Method: command.Method(),
@@ -457,11 +528,28 @@ func (socket *Socket) SendCommand(command Commander) chan *Response {
 		}
 
 		socket.commands.Set(command)
+
+		// The socket may have failed while the command was being sent. The
+		// command is only responded to here if Fail didn't drain it first.
+		if nil != socket.failed() {
+			if command, err := socket.commands.Take(command.ID()); nil == err {
+				socket.fail(command)
+			}
+		}
 	}()
 
 	return command.Response()
 }
 
+/*
+setListening records whether the read loop is running.
+*/
+func (socket *Socket) setListening(listening bool) {
+	socket.listeningMux.Lock()
+	defer socket.listeningMux.Unlock()
+	socket.listening = listening
+}
+
 /*
 Stop signals the socket read loop to stop listening for data and close the
 websocket connection.
@@ -469,12 +557,17 @@ websocket connection.
 Stop is a Socketer implementation.
 */
 func (socket *Socket) Stop() error {
-	if socket.listening {
-		socket.listening = false
+	socket.listeningMux.Lock()
+	listening := socket.listening
+	socket.listening = false
+	socket.listeningMux.Unlock()
+	if listening {
 		select {
 		case <-socket.listenCh:
 		case <-time.After(1 * time.Second):
-			socket.conn.Close()
+			if nil != socket.conn {
+				socket.conn.Close()
+			}
 		}
 		log.WithFields(log.Fields{
 			"socketID": socket.socketID,
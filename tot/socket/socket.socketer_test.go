@@ -1,6 +1,7 @@
 package socket
 
 import (
+	"errors"
 	"net/url"
 	"reflect"
 	"testing"
@@ -80,6 +81,64 @@ func TestListenCommand(t *testing.T) {
 	}
 }
 
+func TestSocketFail(t *testing.T) {
+	socketURL, _ := url.Parse("https://test:9222/TestSocketFail")
+	mockSocket := NewMock(socketURL)
+	mockSocket.Listen()
+
+	// Wait for the pending command to be stored.
+	command := NewCommand(mockSocket, "Some.method", nil)
+	resultChan := mockSocket.SendCommand(command)
+	for a := 0; a < 100; a++ {
+		if _, err := mockSocket.commands.Get(command.ID()); nil == err {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mockSocket.Fail(errors.New("browser exited"))
+	result := <-resultChan
+	if nil == result.Error || ErrorCodeClosed != result.Error.Code {
+		t.Errorf("Expected error code %d, received %v", ErrorCodeClosed, result.Error)
+	}
+	if `"browser exited"` != string(result.Error.Data) {
+		t.Errorf("Expected '\"browser exited\"', received '%s'", result.Error.Data)
+	}
+
+	result = <-mockSocket.SendCommand(NewCommand(mockSocket, "Some.method", nil))
+	if nil == result.Error || ErrorCodeClosed != result.Error.Code {
+		t.Errorf("Expected error code %d, received %v", ErrorCodeClosed, result.Error)
+	}
+}
+
+func TestSocketFailRespondsOnce(t *testing.T) {
+	socketURL, _ := url.Parse("https://test:9222/TestSocketFailRespondsOnce")
+	mockSocket := NewMock(socketURL)
+	mockSocket.Listen()
+
+	resultChans := []chan *Response{}
+	for a := 0; a < 50; a++ {
+		resultChans = append(resultChans, mockSocket.SendCommand(NewCommand(mockSocket, "Some.method", nil)))
+		if 25 == a {
+			go mockSocket.Fail(errors.New("browser exited"))
+		}
+	}
+	for _, resultChan := range resultChans {
+		select {
+		case <-resultChan:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a response, received none")
+		}
+	}
+	for _, resultChan := range resultChans {
+		select {
+		case result := <-resultChan:
+			t.Errorf("Expected a single response, received a second one: %v", result.Error)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
 func TestListenCommandError(t *testing.T) {
 	socketURL, _ := url.Parse("https://test:9222/TestListenCommandError")
 	mockSocket := NewMock(socketURL)
@@ -1,5 +1,10 @@
 package storage
 
+import (
+	"github.com/mkenney/go-chrome/tot/network"
+	"github.com/mkenney/go-chrome/tot/target"
+)
+
 /*
 ClearDataForOriginParams represents Storage.clearDataForOrigin parameters.
 
@@ -23,6 +28,29 @@ type ClearDataForOriginResult struct {
 	Err error `json:"-"`
 }
 
+/*
+GetCookiesParams represents Storage.getCookies parameters.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Storage/#method-getCookies
+*/
+type GetCookiesParams struct {
+	// Optional. Browser context to use when called on the browser endpoint.
+	BrowserContextID target.BrowserContextID `json:"browserContextId,omitempty"`
+}
+
+/*
+GetCookiesResult represents the result of calls to Storage.getCookies.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Storage/#method-getCookies
+*/
+type GetCookiesResult struct {
+	// Array of cookie objects.
+	Cookies []*network.Cookie `json:"cookies"`
+
+	// Error information related to executing this method
+	Err error `json:"-"`
+}
+
 /*
 GetUsageAndQuotaParams represents Storage.getUsageAndQuota parameters.
 
@@ -52,6 +80,29 @@ type GetUsageAndQuotaResult struct {
 	Err error `json:"-"`
 }
 
+/*
+SetCookiesParams represents Storage.setCookies parameters.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Storage/#method-setCookies
+*/
+type SetCookiesParams struct {
+	// Cookies to be set.
+	Cookies []*network.SetCookieParams `json:"cookies"`
+
+	// Optional. Browser context to use when called on the browser endpoint.
+	BrowserContextID target.BrowserContextID `json:"browserContextId,omitempty"`
+}
+
+/*
+SetCookiesResult represents the result of calls to Storage.setCookies.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Storage/#method-setCookies
+*/
+type SetCookiesResult struct {
+	// Error information related to executing this method
+	Err error `json:"-"`
+}
+
 /*
 TrackCacheStorageForOriginParams represents Storage.trackCacheStorageForOrigin parameters.
 
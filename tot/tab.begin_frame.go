@@ -0,0 +1,282 @@
+package chrome
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"time"
+
+	errs "github.com/bdlm/errors"
+	"github.com/mkenney/go-chrome/tot/headless/experimental"
+	"github.com/mkenney/go-chrome/tot/runtime"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+NewBeginFrameController returns a BeginFrameController for a tab created with
+Chrome.NewBeginFrameTab. BeginFrames are issued every interval once the
+controller is started.
+*/
+func NewBeginFrameController(tab *Tab, interval time.Duration) *BeginFrameController {
+	if interval <= 0 {
+		interval = time.Second / 60
+	}
+	return &BeginFrameController{
+		interval:    interval,
+		mux:         &sync.Mutex{},
+		needsFrames: true,
+		ready:       make(chan struct{}),
+		readyOnce:   &sync.Once{},
+		requests:    make(chan *frameRequest),
+		stop:        make(chan struct{}),
+		tab:         tab,
+	}
+}
+
+/*
+BeginFrameController drives the rendering of a BeginFrame controlled tab.
+
+Each BeginFrame carries a synthetic frame time that advances by exactly one
+interval per frame, independent of wall-clock time, so canvas and CSS animations
+are at the same point on every run when a screenshot is taken.
+*/
+type BeginFrameController struct {
+	// frames is the number of BeginFrames issued.
+	frames int
+
+	// interval is the time between BeginFrames.
+	interval time.Duration
+
+	// mux protects needsFrames.
+	mux *sync.Mutex
+
+	// needsFrames tracks HeadlessExperimental.needsBeginFramesChanged.
+	needsFrames bool
+
+	// ready is closed when HeadlessExperimental.mainFrameReadyForScreenshots
+	// fires.
+	ready     chan struct{}
+	readyOnce *sync.Once
+
+	// requests delivers pending screenshot requests to the frame loop.
+	requests chan *frameRequest
+
+	// startTime is the synthetic timestamp of the first BeginFrame, in
+	// milliseconds since epoch.
+	startTime runtime.Timestamp
+
+	// stop signals the frame loop to exit.
+	stop chan struct{}
+
+	// handlers holds the event handlers registered by Start.
+	handlers []socket.EventHandler
+
+	// tab is the controlled tab.
+	tab *Tab
+}
+
+/*
+frameRequest is a screenshot request waiting for the next BeginFrame.
+*/
+type frameRequest struct {
+	params *experimental.ScreenshotParams
+	result chan *experimental.BeginFrameResult
+}
+
+/*
+Capture waits until the main frame is ready for screenshots and returns the
+decoded image data captured by the next BeginFrame.
+*/
+func (controller *BeginFrameController) Capture(
+	ctx context.Context,
+	params *experimental.ScreenshotParams,
+) ([]byte, error) {
+	if nil == params {
+		params = &experimental.ScreenshotParams{Format: experimental.Format.Png}
+	}
+
+	select {
+	case <-controller.ready:
+	case <-ctx.Done():
+		return nil, errs.Wrap(ctx.Err(), 0, "main frame was not ready for screenshots")
+	}
+
+	request := &frameRequest{
+		params: params,
+		result: make(chan *experimental.BeginFrameResult, 1),
+	}
+	select {
+	case controller.requests <- request:
+	case <-ctx.Done():
+		return nil, errs.Wrap(ctx.Err(), 0, "screenshot request was not scheduled")
+	}
+
+	var result *experimental.BeginFrameResult
+	select {
+	case result = <-request.result:
+	case <-ctx.Done():
+		return nil, errs.Wrap(ctx.Err(), 0, "screenshot frame did not complete")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "HeadlessExperimental.beginFrame failed")
+	}
+	if "" == result.ScreenshotData {
+		return nil, errs.New(0, "BeginFrame did not produce a screenshot")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(result.ScreenshotData)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "could not decode screenshot data")
+	}
+	return data, nil
+}
+
+/*
+Ready returns a channel that is closed when the main frame is ready for
+screenshots.
+*/
+func (controller *BeginFrameController) Ready() <-chan struct{} {
+	return controller.ready
+}
+
+/*
+Start enables the HeadlessExperimental domain and starts issuing BeginFrames.
+The frame loop runs until Stop is called or the context is done.
+*/
+func (controller *BeginFrameController) Start(ctx context.Context) error {
+	controller.handlers = []socket.EventHandler{
+		socket.NewEventHandler(
+			"HeadlessExperimental.mainFrameReadyForScreenshots",
+			func(response *socket.Response) {
+				controller.readyOnce.Do(func() {
+					close(controller.ready)
+				})
+			},
+		),
+		socket.NewEventHandler(
+			"HeadlessExperimental.needsBeginFramesChanged",
+			func(response *socket.Response) {
+				event := &experimental.NeedsBeginFramesChangedEvent{}
+				if err := json.Unmarshal([]byte(response.Params), event); nil != err {
+					return
+				}
+				controller.mux.Lock()
+				controller.needsFrames = event.NeedsBeginFrames
+				controller.mux.Unlock()
+			},
+		),
+	}
+	for _, handler := range controller.handlers {
+		controller.tab.AddEventHandler(handler)
+	}
+
+	resultChan := controller.tab.HeadlessExperimental().Enable()
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			controller.removeHandlers()
+			return errs.Wrap(result.Err, 0, "HeadlessExperimental.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		controller.removeHandlers()
+		return errs.Wrap(ctx.Err(), 0, "HeadlessExperimental.enable failed")
+	}
+
+	controller.startTime = runtime.Timestamp(time.Now().UnixNano() / int64(time.Millisecond))
+	go controller.loop(ctx)
+	return nil
+}
+
+/*
+Stop stops issuing BeginFrames and removes the controller's event handlers.
+*/
+func (controller *BeginFrameController) Stop() {
+	select {
+	case <-controller.stop:
+	default:
+		close(controller.stop)
+	}
+	controller.removeHandlers()
+}
+
+/*
+loop issues a BeginFrame every interval. Frames are skipped while Chromium
+reports that it doesn't need them, unless a screenshot has been requested.
+*/
+func (controller *BeginFrameController) loop(ctx context.Context) {
+	ticker := time.NewTicker(controller.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-controller.stop:
+			return
+		case <-ticker.C:
+		}
+
+		var request *frameRequest
+		select {
+		case request = <-controller.requests:
+		default:
+		}
+
+		controller.mux.Lock()
+		needsFrames := controller.needsFrames
+		controller.mux.Unlock()
+		if !needsFrames && nil == request {
+			continue
+		}
+
+		// Frame times are derived from the frame count rather than summed so
+		// rounding errors don't accumulate.
+		interval := float64(controller.interval) / float64(time.Millisecond)
+		params := &experimental.BeginFrameParams{
+			FrameTime: controller.startTime + runtime.Timestamp(float64(controller.frames)*interval),
+			Interval:  interval,
+		}
+		if nil != request {
+			params.Screenshot = request.params
+		}
+		resultChan := controller.tab.HeadlessExperimental().BeginFrame(params)
+		var result *experimental.BeginFrameResult
+		select {
+		case result = <-resultChan:
+		case <-ctx.Done():
+			go func() { <-resultChan }()
+			controller.cancel(request, ctx.Err())
+			return
+		case <-controller.stop:
+			go func() { <-resultChan }()
+			controller.cancel(request, errs.New(0, "BeginFrame controller stopped"))
+			return
+		}
+		controller.frames++
+
+		if nil != request {
+			request.result <- result
+		}
+	}
+}
+
+/*
+cancel fails a screenshot request whose BeginFrame did not complete.
+*/
+func (controller *BeginFrameController) cancel(request *frameRequest, err error) {
+	if nil != request {
+		request.result <- &experimental.BeginFrameResult{Err: err}
+	}
+}
+
+/*
+removeHandlers removes the event handlers registered by Start.
+*/
+func (controller *BeginFrameController) removeHandlers() {
+	for _, handler := range controller.handlers {
+		controller.tab.RemoveEventHandler(handler)
+	}
+	controller.handlers = nil
+}
@@ -0,0 +1,140 @@
+package chrome
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/headless/experimental"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+func TestChromiumNewBeginFrameTab(t *testing.T) {
+	chrome := New(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, err := chrome.NewBeginFrameTab("about:blank", 800, 600)
+	if nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if nil != tab {
+		t.Errorf("Expected nil, received %v", tab)
+	}
+}
+
+func TestBeginFrameControllerCapture(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestBeginFrameControllerCapture")
+	controller := NewBeginFrameController(tab, 0)
+	if time.Second/60 != controller.interval {
+		t.Errorf("Expected %s, received %s", time.Second/60, controller.interval)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	data, err := controller.Capture(ctx, nil)
+	if nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if nil != data {
+		t.Errorf("Expected nil, received %v", data)
+	}
+}
+
+func TestBeginFrameControllerFrameTime(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestBeginFrameControllerFrameTime")
+	mockSocket := tab.Socket().(*MockSocket)
+	mockSocket.SetResponder(func(command socket.Commander) *socket.Response {
+		return &socket.Response{ID: command.ID(), Result: []byte("{}")}
+	})
+
+	controller := NewBeginFrameController(tab, time.Second/60)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := controller.Start(ctx); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	for 4 > len(mockSocket.Commands("HeadlessExperimental.beginFrame")) {
+		time.Sleep(time.Millisecond)
+	}
+	controller.Stop()
+
+	commands := mockSocket.Commands("HeadlessExperimental.beginFrame")
+	interval := float64(time.Second/60) / float64(time.Millisecond)
+	first := commands[0].Params().(*experimental.BeginFrameParams)
+	for a := 1; a < 4; a++ {
+		params := commands[a].Params().(*experimental.BeginFrameParams)
+		// Frame times are milliseconds since epoch, which leaves about 0.0002ms
+		// of float64 precision.
+		if delta := float64(params.FrameTime - first.FrameTime); 0.001 < math.Abs(delta-float64(a)*interval) {
+			t.Errorf("Expected frame %d to advance by %f, received %f", a, float64(a)*interval, delta)
+		}
+		if interval != params.Interval {
+			t.Errorf("Expected interval %f, received %f", interval, params.Interval)
+		}
+	}
+}
+
+func TestBeginFrameControllerStop(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestBeginFrameControllerStop")
+	mockSocket := tab.Socket().(*MockSocket)
+	mockSocket.SetResponder(func(command socket.Commander) *socket.Response {
+		if "HeadlessExperimental.beginFrame" == command.Method() {
+			// BeginFrames never complete.
+			select {}
+		}
+		return &socket.Response{ID: command.ID(), Result: []byte("{}")}
+	})
+
+	controller := NewBeginFrameController(tab, time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := controller.Start(ctx); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	close(controller.ready)
+	captured := make(chan error)
+	go func() {
+		_, err := controller.Capture(ctx, nil)
+		captured <- err
+	}()
+	for 0 == len(mockSocket.Commands("HeadlessExperimental.beginFrame")) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	controller.Stop()
+
+	select {
+	case err := <-captured:
+		if nil == err {
+			t.Errorf("Expected error, received nil")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the pending capture to fail when the controller stops")
+	}
+}
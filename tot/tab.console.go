@@ -0,0 +1,623 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	errs "github.com/bdlm/errors"
+	"github.com/mkenney/go-chrome/tot/console"
+	chromelog "github.com/mkenney/go-chrome/tot/log"
+	"github.com/mkenney/go-chrome/tot/runtime"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+Console record levels, matching the Log domain entry levels.
+*/
+const (
+	ConsoleLevelVerbose = "verbose"
+	ConsoleLevelInfo    = "info"
+	ConsoleLevelWarning = "warning"
+	ConsoleLevelError   = "error"
+)
+
+/*
+Console record types, identifying the event a record was created from.
+*/
+const (
+	// ConsoleTypeConsoleAPI records are created from Runtime.consoleAPICalled
+	// events.
+	ConsoleTypeConsoleAPI = "console-api"
+
+	// ConsoleTypeException records are created from Runtime.exceptionThrown
+	// events.
+	ConsoleTypeException = "exception"
+
+	// ConsoleTypeLog records are created from Log.entryAdded events.
+	ConsoleTypeLog = "log"
+
+	// ConsoleTypeMessage records are created from Console.messageAdded events.
+	ConsoleTypeMessage = "message"
+)
+
+/*
+ConsoleRecord is a console message, log entry or uncaught exception reported by
+a tab.
+*/
+type ConsoleRecord struct {
+	// Args holds the formatted arguments of console API calls.
+	Args []string `json:"args,omitempty"`
+
+	// Column is the 1-based column number of the source location, if known.
+	Column int `json:"column,omitempty"`
+
+	// ExceptionID identifies an uncaught exception.
+	ExceptionID int `json:"exceptionId,omitempty"`
+
+	// Level is one of the ConsoleLevel* values.
+	Level string `json:"level"`
+
+	// Line is the 1-based line number of the source location, if known.
+	Line int `json:"line,omitempty"`
+
+	// Revoked is set when an uncaught exception was revoked, for example when
+	// a rejected promise gets a handler after the fact.
+	Revoked bool `json:"revoked,omitempty"`
+
+	// Source is the Chromium source of the record, such as 'console-api',
+	// 'javascript', 'network' or 'violation'.
+	Source string `json:"source,omitempty"`
+
+	// Stack is the JavaScript stack trace, formatted like Error.prototype.stack.
+	Stack string `json:"stack,omitempty"`
+
+	// Text is the message text.
+	Text string `json:"text"`
+
+	// Timestamp is the time the record was reported.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Type is one of the ConsoleType* values.
+	Type string `json:"type"`
+
+	// URL is the URL of the source location, if known.
+	URL string `json:"url,omitempty"`
+}
+
+/*
+String implements Stringer.
+*/
+func (record *ConsoleRecord) String() string {
+	location := ""
+	if "" != record.URL {
+		location = fmt.Sprintf(" (%s:%d:%d)", record.URL, record.Line, record.Column)
+	}
+	return fmt.Sprintf("[%s] %s%s", record.Level, record.Text, location)
+}
+
+/*
+NewConsoleCollector returns a ConsoleCollector for a tab. The Runtime and Log
+domains are enabled. Console.messageAdded events are collected as well but are
+only sent if the deprecated Console domain is enabled, in which case console
+API calls are reported twice.
+*/
+func NewConsoleCollector(ctx context.Context, tab *Tab) (*ConsoleCollector, error) {
+	collector := newConsoleCollector(tab)
+
+	collector.handlers = []socket.EventHandler{
+		socket.NewEventHandler("Runtime.consoleAPICalled", func(response *socket.Response) {
+			event := &runtime.ConsoleAPICalledEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err {
+				collector.add(consoleAPIRecord(event))
+			}
+		}),
+		socket.NewEventHandler("Runtime.exceptionThrown", func(response *socket.Response) {
+			event := &runtime.ExceptionThrownEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err && nil != event.ExceptionDetails {
+				collector.add(exceptionRecord(event))
+			}
+		}),
+		socket.NewEventHandler("Runtime.exceptionRevoked", func(response *socket.Response) {
+			event := &runtime.ExceptionRevokedEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err {
+				collector.revoke(event.ExceptionID)
+			}
+		}),
+		socket.NewEventHandler("Log.entryAdded", func(response *socket.Response) {
+			event := &chromelog.EntryAddedEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err && nil != event.Entry {
+				collector.add(logRecord(event.Entry))
+			}
+		}),
+		socket.NewEventHandler("Console.messageAdded", func(response *socket.Response) {
+			event := &console.MessageAddedEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err && nil != event.Message {
+				collector.add(messageRecord(event.Message))
+			}
+		}),
+	}
+	for _, handler := range collector.handlers {
+		tab.AddEventHandler(handler)
+	}
+
+	runtimeChan := tab.Runtime().Enable()
+	select {
+	case result := <-runtimeChan:
+		if nil != result.Err {
+			collector.Close()
+			return nil, errs.Wrap(result.Err, 0, "Runtime.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-runtimeChan }()
+		collector.Close()
+		return nil, errs.Wrap(ctx.Err(), 0, "Runtime.enable failed")
+	}
+
+	logChan := tab.Log().Enable()
+	select {
+	case result := <-logChan:
+		if nil != result.Err {
+			collector.Close()
+			return nil, errs.Wrap(result.Err, 0, "Log.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-logChan }()
+		collector.Close()
+		return nil, errs.Wrap(ctx.Err(), 0, "Log.enable failed")
+	}
+
+	return collector, nil
+}
+
+/*
+newConsoleCollector returns an empty console collector that isn't listening for
+console events.
+*/
+func newConsoleCollector(tab *Tab) *ConsoleCollector {
+	return &ConsoleCollector{
+		mux:         &sync.Mutex{},
+		records:     []*ConsoleRecord{},
+		subscribers: make(map[chan *ConsoleRecord]struct{}),
+		tab:         tab,
+	}
+}
+
+/*
+ConsoleCollector collects the console messages, log entries and uncaught
+exceptions of a tab.
+
+Failing a test on an uncaught exception takes one line:
+
+	defer collector.AssertNoExceptions(t)
+*/
+type ConsoleCollector struct {
+	// closed is set when the collector has been closed.
+	closed bool
+
+	// handlers holds the event handlers registered by the collector.
+	handlers []socket.EventHandler
+
+	// mux protects closed, records and subscribers.
+	mux *sync.Mutex
+
+	// records holds the collected records in the order they were reported.
+	records []*ConsoleRecord
+
+	// subscribers holds the channels returned by Subscribe.
+	subscribers map[chan *ConsoleRecord]struct{}
+
+	// tab is the tab being observed.
+	tab *Tab
+}
+
+/*
+AssertNoExceptions reports every uncaught exception that hasn't been revoked to
+t, which is usually a *testing.T.
+*/
+func (collector *ConsoleCollector) AssertNoExceptions(t interface {
+	Errorf(format string, args ...interface{})
+}) {
+	for _, record := range collector.Exceptions() {
+		if "" == record.Stack {
+			t.Errorf("uncaught exception: %s", record)
+		} else {
+			t.Errorf("uncaught exception: %s\n%s", record, record.Stack)
+		}
+	}
+}
+
+/*
+Clear discards the collected records.
+*/
+func (collector *ConsoleCollector) Clear() {
+	collector.mux.Lock()
+	defer collector.mux.Unlock()
+	collector.records = []*ConsoleRecord{}
+}
+
+/*
+Close stops collecting records and closes all subscription channels. The
+collected records remain available.
+*/
+func (collector *ConsoleCollector) Close() {
+	for _, handler := range collector.handlers {
+		collector.tab.RemoveEventHandler(handler)
+	}
+
+	collector.mux.Lock()
+	defer collector.mux.Unlock()
+	if collector.closed {
+		return
+	}
+	collector.closed = true
+	for subscriber := range collector.subscribers {
+		close(subscriber)
+	}
+	collector.subscribers = nil
+}
+
+/*
+Err returns an error describing the first uncaught exception that hasn't been
+revoked, or nil.
+*/
+func (collector *ConsoleCollector) Err() error {
+	exceptions := collector.Exceptions()
+	if 0 == len(exceptions) {
+		return nil
+	}
+	message := fmt.Sprintf("uncaught exception: %s", exceptions[0])
+	if 1 < len(exceptions) {
+		message = fmt.Sprintf("%s (and %d more)", message, len(exceptions)-1)
+	}
+	return errs.New(0, message)
+}
+
+/*
+Exceptions returns the uncaught exceptions that haven't been revoked.
+*/
+func (collector *ConsoleCollector) Exceptions() []*ConsoleRecord {
+	return collector.Filter(func(record *ConsoleRecord) bool {
+		return ConsoleTypeException == record.Type && !record.Revoked
+	})
+}
+
+/*
+Filter returns the collected records for which fn returns true.
+*/
+func (collector *ConsoleCollector) Filter(fn func(record *ConsoleRecord) bool) []*ConsoleRecord {
+	collector.mux.Lock()
+	defer collector.mux.Unlock()
+	records := []*ConsoleRecord{}
+	for _, record := range collector.records {
+		if fn(record) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+/*
+Level returns the collected records with the specified level.
+*/
+func (collector *ConsoleCollector) Level(level string) []*ConsoleRecord {
+	return collector.Filter(func(record *ConsoleRecord) bool {
+		return level == record.Level
+	})
+}
+
+/*
+Records returns all collected records.
+*/
+func (collector *ConsoleCollector) Records() []*ConsoleRecord {
+	return collector.Filter(func(record *ConsoleRecord) bool {
+		return true
+	})
+}
+
+/*
+Stream writes the collected records to w as JSON lines, followed by new records
+as they are reported, until the context is done or the collector is closed.
+*/
+func (collector *ConsoleCollector) Stream(ctx context.Context, w io.Writer) error {
+	records, cancel := collector.subscribe(64, true)
+	defer cancel()
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case record, ok := <-records:
+			if !ok {
+				return nil
+			}
+			if err := encoder.Encode(record); nil != err {
+				return errs.Wrap(err, 0, "could not write console record")
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+/*
+Subscribe returns a channel that receives new records as they are reported and
+a function that cancels the subscription. Records are dropped if the channel
+buffer is full.
+*/
+func (collector *ConsoleCollector) Subscribe(buffer int) (<-chan *ConsoleRecord, func()) {
+	return collector.subscribe(buffer, false)
+}
+
+/*
+WriteJSON writes the collected records to w as JSON lines.
+*/
+func (collector *ConsoleCollector) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range collector.Records() {
+		if err := encoder.Encode(record); nil != err {
+			return errs.Wrap(err, 0, "could not write console record")
+		}
+	}
+	return nil
+}
+
+/*
+add adds a record and delivers it to the subscribers.
+*/
+func (collector *ConsoleCollector) add(record *ConsoleRecord) {
+	collector.mux.Lock()
+	defer collector.mux.Unlock()
+	collector.records = append(collector.records, record)
+	for subscriber := range collector.subscribers {
+		select {
+		case subscriber <- record:
+		default:
+		}
+	}
+}
+
+/*
+revoke marks an uncaught exception as revoked.
+*/
+func (collector *ConsoleCollector) revoke(exceptionID int) {
+	collector.mux.Lock()
+	defer collector.mux.Unlock()
+	for i, record := range collector.records {
+		if ConsoleTypeException == record.Type && exceptionID == record.ExceptionID {
+			// Records may be in use by subscribers, replace rather than modify.
+			revoked := *record
+			revoked.Revoked = true
+			collector.records[i] = &revoked
+		}
+	}
+}
+
+/*
+subscribe registers a subscription channel, optionally preloaded with the
+records collected so far.
+*/
+func (collector *ConsoleCollector) subscribe(buffer int, replay bool) (chan *ConsoleRecord, func()) {
+	collector.mux.Lock()
+	defer collector.mux.Unlock()
+
+	if replay {
+		buffer += len(collector.records)
+	}
+	subscriber := make(chan *ConsoleRecord, buffer)
+	if replay {
+		for _, record := range collector.records {
+			subscriber <- record
+		}
+	}
+	if collector.closed {
+		close(subscriber)
+		return subscriber, func() {}
+	}
+	collector.subscribers[subscriber] = struct{}{}
+
+	return subscriber, func() {
+		collector.mux.Lock()
+		defer collector.mux.Unlock()
+		if _, ok := collector.subscribers[subscriber]; ok {
+			delete(collector.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+/*
+consoleAPIRecord normalises a Runtime.consoleAPICalled event.
+*/
+func consoleAPIRecord(event *runtime.ConsoleAPICalledEvent) *ConsoleRecord {
+	record := &ConsoleRecord{
+		Args:      make([]string, 0, len(event.Args)),
+		Level:     ConsoleLevelInfo,
+		Source:    "console-api",
+		Stack:     formatStackTrace(event.StackTrace),
+		Timestamp: timestampTime(event.Timestamp),
+		Type:      ConsoleTypeConsoleAPI,
+	}
+	switch event.Type {
+	case runtime.CallType.Debug:
+		record.Level = ConsoleLevelVerbose
+	case runtime.CallType.Warning:
+		record.Level = ConsoleLevelWarning
+	case runtime.CallType.Error, runtime.CallType.Assert:
+		record.Level = ConsoleLevelError
+	}
+	for _, arg := range event.Args {
+		record.Args = append(record.Args, formatRemoteObject(arg))
+	}
+	record.Text = strings.Join(record.Args, " ")
+	if nil != event.StackTrace && 0 < len(event.StackTrace.CallFrames) {
+		frame := event.StackTrace.CallFrames[0]
+		record.URL = frame.URL
+		record.Line = frame.LineNumber + 1
+		record.Column = frame.ColumnNumber + 1
+	}
+	return record
+}
+
+/*
+exceptionRecord normalises a Runtime.exceptionThrown event.
+*/
+func exceptionRecord(event *runtime.ExceptionThrownEvent) *ConsoleRecord {
+	details := event.ExceptionDetails
+	exception := &ExceptionError{Details: details}
+	return &ConsoleRecord{
+		Column:      details.ColumnNumber + 1,
+		ExceptionID: details.ExceptionID,
+		Level:       ConsoleLevelError,
+		Line:        details.LineNumber + 1,
+		Source:      "javascript",
+		Stack:       exception.Stack(),
+		Text:        exceptionMessage(details),
+		Timestamp:   timestampTime(event.Timestamp),
+		Type:        ConsoleTypeException,
+		URL:         details.URL,
+	}
+}
+
+/*
+logRecord normalises a Log.entryAdded entry.
+*/
+func logRecord(entry *chromelog.Entry) *ConsoleRecord {
+	record := &ConsoleRecord{
+		Level:     entry.Level.String(),
+		Source:    entry.Source.String(),
+		Stack:     formatStackTrace(entry.StackTrace),
+		Text:      entry.Text,
+		Timestamp: timestampTime(entry.Timestamp),
+		Type:      ConsoleTypeLog,
+		URL:       entry.URL,
+	}
+	if "" != entry.URL {
+		record.Line = entry.LineNumber + 1
+	}
+	for _, arg := range entry.Args {
+		record.Args = append(record.Args, formatRemoteObject(arg))
+	}
+	return record
+}
+
+/*
+messageRecord normalises a Console.messageAdded message.
+*/
+func messageRecord(message *console.Message) *ConsoleRecord {
+	record := &ConsoleRecord{
+		Column:    message.Column,
+		Level:     message.Level.String(),
+		Line:      message.Line,
+		Source:    message.Source.String(),
+		Text:      message.Text,
+		Timestamp: time.Now(),
+		Type:      ConsoleTypeMessage,
+		URL:       message.URL,
+	}
+	switch message.Level {
+	case console.MessageLevel.Log:
+		record.Level = ConsoleLevelInfo
+	case console.MessageLevel.Debug:
+		record.Level = ConsoleLevelVerbose
+	}
+	return record
+}
+
+/*
+formatRemoteObject formats a remote object the way the DevTools console does,
+using the object preview when one is available.
+*/
+func formatRemoteObject(object *runtime.RemoteObject) string {
+	if nil == object {
+		return "undefined"
+	}
+	if 0 != object.UnserializableValue {
+		return object.UnserializableValue.String()
+	}
+	switch object.Type {
+	case runtime.ObjectType.Undefined:
+		return "undefined"
+	case runtime.ObjectType.String:
+		if value, ok := object.Value.(string); ok {
+			return value
+		}
+	case runtime.ObjectType.Number, runtime.ObjectType.Boolean:
+		return fmt.Sprintf("%v", object.Value)
+	}
+	if runtime.ObjectSubtype.Null == object.Subtype {
+		return "null"
+	}
+	if nil != object.Preview {
+		return formatObjectPreview(object.Preview)
+	}
+	if "" != object.Description {
+		return object.Description
+	}
+	if nil != object.Value {
+		data, _ := json.Marshal(object.Value)
+		return string(data)
+	}
+	return object.Type.String()
+}
+
+/*
+formatObjectPreview formats an object preview, such as {a: 1, b: "x"} or
+Array(2) [1, 2].
+*/
+func formatObjectPreview(preview *runtime.ObjectPreview) string {
+	items := []string{}
+	isArray := runtime.ObjectSubtype.Array == preview.Subtype
+	for _, property := range preview.Properties {
+		value := property.Value
+		switch {
+		case nil != property.ValuePreview:
+			value = formatObjectPreview(property.ValuePreview)
+		case runtime.ObjectType.String == property.Type:
+			value = fmt.Sprintf("%q", property.Value)
+		}
+		if isArray {
+			items = append(items, value)
+		} else {
+			items = append(items, fmt.Sprintf("%s: %s", property.Name, value))
+		}
+	}
+	for _, entry := range preview.Entries {
+		if nil == entry.Value {
+			continue
+		}
+		if nil != entry.Key {
+			items = append(items, fmt.Sprintf("%s => %s", formatObjectPreview(entry.Key), formatObjectPreview(entry.Value)))
+		} else {
+			items = append(items, formatObjectPreview(entry.Value))
+		}
+	}
+	if preview.Overflow {
+		items = append(items, "…")
+	}
+
+	if 0 == len(items) && "" != preview.Description && runtime.ObjectType.Object != preview.Type {
+		return preview.Description
+	}
+	if isArray {
+		return fmt.Sprintf("%s [%s]", preview.Description, strings.Join(items, ", "))
+	}
+	if "" != preview.Description && "Object" != preview.Description {
+		return fmt.Sprintf("%s {%s}", preview.Description, strings.Join(items, ", "))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(items, ", "))
+}
+
+/*
+timestampTime converts a protocol timestamp to a time.Time, using the current
+time if the timestamp is missing.
+*/
+func timestampTime(timestamp runtime.Timestamp) time.Time {
+	if 0 == timestamp {
+		return time.Now()
+	}
+	return time.Unix(0, int64(float64(timestamp)*float64(time.Millisecond)))
+}
@@ -0,0 +1,165 @@
+package chrome
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/runtime"
+)
+
+type mockTestReporter struct {
+	errors []string
+}
+
+func (reporter *mockTestReporter) Errorf(format string, args ...interface{}) {
+	reporter.errors = append(reporter.errors, fmt.Sprintf(format, args...))
+}
+
+func TestNewConsoleCollector(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestNewConsoleCollector")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := NewConsoleCollector(ctx, tab); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestConsoleAPIRecord(t *testing.T) {
+	event := &runtime.ConsoleAPICalledEvent{}
+	json.Unmarshal([]byte(`{
+		"type": "warning",
+		"args": [
+			{"type": "string", "value": "count"},
+			{"type": "number", "value": 3},
+			{"type": "object", "subtype": "null", "value": null},
+			{"type": "object", "className": "Object", "description": "Object", "objectId": "1",
+				"preview": {"type": "object", "description": "Object", "overflow": false, "properties": [
+					{"name": "a", "type": "number", "value": "1"},
+					{"name": "b", "type": "string", "value": "x"}
+				]}},
+			{"type": "object", "subtype": "array", "className": "Array", "description": "Array(2)", "objectId": "2",
+				"preview": {"type": "object", "subtype": "array", "description": "Array(2)", "overflow": false, "properties": [
+					{"name": "0", "type": "number", "value": "1"},
+					{"name": "1", "type": "number", "value": "2"}
+				]}}
+		],
+		"executionContextId": 1,
+		"timestamp": 1528383312345.678,
+		"stackTrace": {"callFrames": [
+			{"functionName": "f", "scriptId": "1", "url": "https://example.com/app.js", "lineNumber": 4, "columnNumber": 2}
+		]}
+	}`), event)
+
+	record := consoleAPIRecord(event)
+	if ConsoleLevelWarning != record.Level {
+		t.Errorf("Expected level '%s', received '%s'", ConsoleLevelWarning, record.Level)
+	}
+	expected := `count 3 null {a: 1, b: "x"} Array(2) [1, 2]`
+	if expected != record.Text {
+		t.Errorf("Expected '%s', received '%s'", expected, record.Text)
+	}
+	if "https://example.com/app.js" != record.URL || 5 != record.Line || 3 != record.Column {
+		t.Errorf("Expected https://example.com/app.js:5:3, received %s:%d:%d", record.URL, record.Line, record.Column)
+	}
+	if 2018 != record.Timestamp.UTC().Year() {
+		t.Errorf("Expected a 2018 timestamp, received %s", record.Timestamp)
+	}
+}
+
+func TestConsoleCollectorExceptions(t *testing.T) {
+	collector := newConsoleCollector(nil)
+	records, cancel := collector.Subscribe(10)
+	defer cancel()
+
+	collector.add(exceptionRecord(&runtime.ExceptionThrownEvent{
+		ExceptionDetails: &runtime.ExceptionDetails{
+			ExceptionID: 1,
+			Text:        "Uncaught",
+			URL:         "https://example.com/app.js",
+			Exception:   &runtime.RemoteObject{Description: "Error: boom\n    at app.js:1:1"},
+		},
+	}))
+	collector.add(exceptionRecord(&runtime.ExceptionThrownEvent{
+		ExceptionDetails: &runtime.ExceptionDetails{
+			ExceptionID: 2,
+			Text:        "Uncaught (in promise)",
+		},
+	}))
+
+	if "Uncaught Error: boom" != (<-records).Text {
+		t.Errorf("Expected the exception to be streamed")
+	}
+	if 2 != len(collector.Exceptions()) {
+		t.Errorf("Expected 2 exceptions, received %d", len(collector.Exceptions()))
+	}
+
+	collector.revoke(2)
+	if 1 != len(collector.Exceptions()) {
+		t.Errorf("Expected 1 exception, received %d", len(collector.Exceptions()))
+	}
+	if err := collector.Err(); nil == err || !strings.Contains(err.Error(), "Error: boom") {
+		t.Errorf("Expected exception error, received %v", err)
+	}
+
+	reporter := &mockTestReporter{}
+	collector.AssertNoExceptions(reporter)
+	if 1 != len(reporter.errors) || !strings.Contains(reporter.errors[0], "at app.js:1:1") {
+		t.Errorf("Expected 1 reported exception with a stack, received %v", reporter.errors)
+	}
+
+	collector.Clear()
+	if nil != collector.Err() {
+		t.Errorf("Expected nil, received %v", collector.Err())
+	}
+}
+
+func TestConsoleCollectorJSON(t *testing.T) {
+	collector := newConsoleCollector(nil)
+	collector.add(&ConsoleRecord{Level: ConsoleLevelInfo, Text: "one", Type: ConsoleTypeConsoleAPI})
+	collector.add(&ConsoleRecord{Level: ConsoleLevelError, Text: "two", Type: ConsoleTypeLog})
+
+	buf := &bytes.Buffer{}
+	if err := collector.WriteJSON(buf); nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if 2 != len(lines) {
+		t.Errorf("Expected 2 lines, received %d", len(lines))
+	}
+	record := &ConsoleRecord{}
+	if err := json.Unmarshal([]byte(lines[1]), record); nil != err || "two" != record.Text {
+		t.Errorf("Expected record 'two', received %v: %v", record, err)
+	}
+	if 1 != len(collector.Level(ConsoleLevelError)) {
+		t.Errorf("Expected 1 error record, received %d", len(collector.Level(ConsoleLevelError)))
+	}
+
+	buf.Reset()
+	done := make(chan error)
+	go func() {
+		done <- collector.Stream(context.Background(), buf)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	collector.add(&ConsoleRecord{Level: ConsoleLevelInfo, Text: "three", Type: ConsoleTypeConsoleAPI})
+	time.Sleep(10 * time.Millisecond)
+	collector.Close()
+	if err := <-done; nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	if 3 != strings.Count(buf.String(), "\n") {
+		t.Errorf("Expected 3 streamed records, received '%s'", buf.String())
+	}
+}
@@ -0,0 +1,149 @@
+package chrome
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	errs "github.com/bdlm/errors"
+	"github.com/mkenney/go-chrome/tot/network"
+)
+
+/*
+netscapeHTTPOnlyPrefix marks HttpOnly cookies in cookies.txt files, following
+curl.
+*/
+const netscapeHTTPOnlyPrefix = "#HttpOnly_"
+
+/*
+ReadJSONCookies reads cookies written by WriteJSONCookies. The format is the
+JSON encoding of the cookies returned by Network.getAllCookies.
+*/
+func ReadJSONCookies(r io.Reader) ([]*network.Cookie, error) {
+	cookies := []*network.Cookie{}
+	if err := json.NewDecoder(r).Decode(&cookies); nil != err {
+		return nil, errs.Wrap(err, 0, "could not decode cookies")
+	}
+	return cookies, nil
+}
+
+/*
+ReadNetscapeCookies reads cookies in the Netscape cookies.txt format used by
+curl, wget and browser extensions. Lines have 7 tab separated fields:
+
+	domain  include-subdomains  path  secure  expires  name  value
+
+Domains prefixed with #HttpOnly_ are HttpOnly cookies and an expiry of 0 is a
+session cookie. Cookies with include-subdomains set are domain cookies, their
+domain is prefixed with a dot if it has none.
+*/
+func ReadNetscapeCookies(r io.Reader) ([]*network.Cookie, error) {
+	cookies := []*network.Cookie{}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, netscapeHTTPOnlyPrefix)
+		if httpOnly {
+			line = strings.TrimPrefix(line, netscapeHTTPOnlyPrefix)
+		}
+		if "" == strings.TrimSpace(line) || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, errs.New(0, fmt.Sprintf("line %d: expected 7 fields, found %d", lineNumber, len(fields)))
+		}
+		expires, err := strconv.ParseFloat(fields[4], 64)
+		if nil != err {
+			return nil, errs.Wrap(err, 0, fmt.Sprintf("line %d: invalid expiry '%s'", lineNumber, fields[4]))
+		}
+
+		domain := fields[0]
+		if strings.EqualFold("TRUE", fields[1]) && !strings.HasPrefix(domain, ".") {
+			domain = "." + domain
+		}
+		cookie := &network.Cookie{
+			Domain:   domain,
+			Path:     fields[2],
+			Secure:   strings.EqualFold("TRUE", fields[3]),
+			Expires:  expires,
+			Name:     fields[5],
+			Value:    fields[6],
+			HTTPOnly: httpOnly,
+		}
+		cookie.Size = len(cookie.Name) + len(cookie.Value)
+		if 0 == expires {
+			cookie.Expires = -1
+			cookie.Session = true
+		}
+		cookies = append(cookies, cookie)
+	}
+	if err := scanner.Err(); nil != err {
+		return nil, errs.Wrap(err, 0, "could not read cookies")
+	}
+	return cookies, nil
+}
+
+/*
+WriteJSONCookies writes cookies as a JSON array, preserving all cookie
+attributes.
+*/
+func WriteJSONCookies(w io.Writer, cookies []*network.Cookie) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(cookies); nil != err {
+		return errs.Wrap(err, 0, "could not encode cookies")
+	}
+	return nil
+}
+
+/*
+WriteNetscapeCookies writes cookies in the Netscape cookies.txt format, see
+ReadNetscapeCookies. The format has no SameSite attribute, use
+WriteJSONCookies to preserve it.
+*/
+func WriteNetscapeCookies(w io.Writer, cookies []*network.Cookie) error {
+	buf := bufio.NewWriter(w)
+	fmt.Fprintln(buf, "# Netscape HTTP Cookie File")
+	for _, cookie := range cookies {
+		domain := cookie.Domain
+		if cookie.HTTPOnly {
+			domain = netscapeHTTPOnlyPrefix + domain
+		}
+		expires := int64(0)
+		if !cookie.Session && 0 < cookie.Expires {
+			expires = int64(cookie.Expires)
+		}
+		fmt.Fprintf(
+			buf,
+			"%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain,
+			netscapeBool(strings.HasPrefix(cookie.Domain, ".")),
+			cookie.Path,
+			netscapeBool(cookie.Secure),
+			expires,
+			cookie.Name,
+			cookie.Value,
+		)
+	}
+	if err := buf.Flush(); nil != err {
+		return errs.Wrap(err, 0, "could not write cookies")
+	}
+	return nil
+}
+
+/*
+netscapeBool formats a boolean cookies.txt field.
+*/
+func netscapeBool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
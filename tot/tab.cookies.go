@@ -0,0 +1,307 @@
+package chrome
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+	"github.com/mkenney/go-chrome/tot/network"
+)
+
+/*
+Cookies returns all browser cookies for all URLs. Tabs in the same browser
+context share their cookies.
+*/
+func (tab *Tab) Cookies(ctx context.Context) ([]*network.Cookie, error) {
+	var result *network.GetAllCookiesResult
+	resultChan := tab.Network().GetAllCookies()
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "Network.getAllCookies failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Network.getAllCookies failed")
+	}
+	return result.Cookies, nil
+}
+
+/*
+SetCookies sets browser cookies, such as cookies returned by Cookies or read
+with ReadNetscapeCookies or ReadJSONCookies.
+*/
+func (tab *Tab) SetCookies(ctx context.Context, cookies []*network.Cookie) error {
+	params := &network.SetCookiesParams{
+		Cookies: make([]*network.SetCookieParams, 0, len(cookies)),
+	}
+	for _, cookie := range cookies {
+		params.Cookies = append(params.Cookies, CookieParams(cookie))
+	}
+	return tab.setCookies(ctx, params)
+}
+
+/*
+deleteCookies sends a Network.deleteCookies command and waits for the result.
+*/
+func (tab *Tab) deleteCookies(ctx context.Context, params *network.DeleteCookiesParams) error {
+	resultChan := tab.Network().DeleteCookies(params)
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Network.deleteCookies failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "Network.deleteCookies failed")
+	}
+	return nil
+}
+
+/*
+getCookies returns the browser cookies that would be sent to the specified
+URLs.
+*/
+func (tab *Tab) getCookies(ctx context.Context, urls ...string) ([]*network.Cookie, error) {
+	var result *network.GetCookiesResult
+	resultChan := tab.Network().GetCookies(&network.GetCookiesParams{URLs: urls})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "Network.getCookies failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Network.getCookies failed")
+	}
+	return result.Cookies, nil
+}
+
+/*
+setCookies sends a Network.setCookies command and waits for the result.
+*/
+func (tab *Tab) setCookies(ctx context.Context, params *network.SetCookiesParams) error {
+	resultChan := tab.Network().SetCookies(params)
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Network.setCookies failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "Network.setCookies failed")
+	}
+	return nil
+}
+
+/*
+cookieStore is a browser cookie store, implemented by Tab and BrowserContext.
+*/
+type cookieStore interface {
+	deleteCookies(ctx context.Context, params *network.DeleteCookiesParams) error
+	getCookies(ctx context.Context, urls ...string) ([]*network.Cookie, error)
+	setCookies(ctx context.Context, params *network.SetCookiesParams) error
+}
+
+/*
+NewCookieJar returns an http.CookieJar backed by the cookie store of a tab,
+which is shared by all tabs in the same browser context. Each jar operation
+waits at most timeout for Chromium to respond, a timeout of 0 waits
+indefinitely.
+
+This allows a session to be handed between Go HTTP clients and the browser:
+
+	client := &http.Client{Jar: chrome.NewCookieJar(tab, 5*time.Second)}
+*/
+func NewCookieJar(tab *Tab, timeout time.Duration) *CookieJar {
+	return &CookieJar{
+		store:   tab,
+		timeout: timeout,
+	}
+}
+
+/*
+NewBrowserContextCookieJar returns an http.CookieJar backed by the cookie store
+of a browser context, which doesn't need an open tab. See NewCookieJar.
+*/
+func NewBrowserContextCookieJar(browserContext *BrowserContext, timeout time.Duration) *CookieJar {
+	return &CookieJar{
+		store:   browserContext,
+		timeout: timeout,
+	}
+}
+
+/*
+CookieJar implements http.CookieJar using browser cookies. http.CookieJar
+methods can't return errors, failures are logged.
+*/
+type CookieJar struct {
+	// store is the tab or browser context whose cookies are used.
+	store cookieStore
+
+	// timeout limits the duration of each jar operation.
+	timeout time.Duration
+}
+
+/*
+Cookies implements http.CookieJar.
+*/
+func (jar *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	ctx, cancel := jar.context()
+	defer cancel()
+
+	cookies, err := jar.store.getCookies(ctx, u.String())
+	if nil != err {
+		log.WithFields(log.Fields{
+			"url":   u.String(),
+			"error": err,
+		}).Warn("could not read browser cookies")
+		return nil
+	}
+
+	httpCookies := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		httpCookies = append(httpCookies, HTTPCookie(cookie))
+	}
+	return httpCookies
+}
+
+/*
+SetCookies implements http.CookieJar. Cookies with a negative MaxAge are
+deleted.
+*/
+func (jar *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	ctx, cancel := jar.context()
+	defer cancel()
+
+	params := &network.SetCookiesParams{Cookies: []*network.SetCookieParams{}}
+	for _, cookie := range cookies {
+		if cookie.MaxAge < 0 {
+			err := jar.store.deleteCookies(ctx, &network.DeleteCookiesParams{
+				Name:   cookie.Name,
+				URL:    u.String(),
+				Domain: cookie.Domain,
+				Path:   cookie.Path,
+			})
+			if nil != err {
+				log.WithFields(log.Fields{
+					"url":    u.String(),
+					"cookie": cookie.Name,
+					"error":  err,
+				}).Warn("could not delete browser cookie")
+			}
+			continue
+		}
+		params.Cookies = append(params.Cookies, httpCookieParams(u, cookie))
+	}
+	if 0 == len(params.Cookies) {
+		return
+	}
+
+	if err := jar.store.setCookies(ctx, params); nil != err {
+		log.WithFields(log.Fields{
+			"url":   u.String(),
+			"error": err,
+		}).Warn("could not set browser cookies")
+	}
+}
+
+/*
+context returns the context for a jar operation.
+*/
+func (jar *CookieJar) context() (context.Context, context.CancelFunc) {
+	if 0 == jar.timeout {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), jar.timeout)
+}
+
+/*
+CookieParams converts a browser cookie to Network.setCookie parameters.
+*/
+func CookieParams(cookie *network.Cookie) *network.SetCookieParams {
+	params := &network.SetCookieParams{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   cookie.Domain,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HTTPOnly: cookie.HTTPOnly,
+		SameSite: cookie.SameSite,
+	}
+	if !cookie.Session && 0 < cookie.Expires {
+		params.Expires = network.TimeSinceEpoch(math.Ceil(cookie.Expires))
+	}
+	return params
+}
+
+/*
+HTTPCookie converts a browser cookie to an http.Cookie.
+*/
+func HTTPCookie(cookie *network.Cookie) *http.Cookie {
+	httpCookie := &http.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   cookie.Domain,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HTTPOnly,
+	}
+	if !cookie.Session && 0 < cookie.Expires {
+		seconds, fraction := math.Modf(cookie.Expires)
+		httpCookie.Expires = time.Unix(int64(seconds), int64(fraction*float64(time.Second)))
+	}
+	setHTTPCookieSameSite(httpCookie, cookie.SameSite)
+	return httpCookie
+}
+
+/*
+httpCookieParams converts a cookie received by an HTTP client from u to
+Network.setCookie parameters. Host-only cookies are scoped to u by Chromium.
+*/
+func httpCookieParams(u *url.URL, cookie *http.Cookie) *network.SetCookieParams {
+	params := &network.SetCookieParams{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		URL:      u.String(),
+		Domain:   cookie.Domain,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HTTPOnly: cookie.HttpOnly,
+		SameSite: httpCookieSameSite(cookie),
+	}
+	switch {
+	case 0 < cookie.MaxAge:
+		params.Expires = network.TimeSinceEpoch(time.Now().Unix() + int64(cookie.MaxAge))
+	case !cookie.Expires.IsZero():
+		params.Expires = network.TimeSinceEpoch(cookie.Expires.Unix())
+	}
+	return params
+}
+
+/*
+unparsedSameSite returns the SameSite attribute kept in the unparsed attributes
+of an http.Cookie, for values the running Go version doesn't parse.
+*/
+func unparsedSameSite(cookie *http.Cookie) network.CookieSameSiteEnum {
+	for _, attr := range cookie.Unparsed {
+		parts := strings.SplitN(attr, "=", 2)
+		if 2 != len(parts) || !strings.EqualFold("samesite", strings.TrimSpace(parts[0])) {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(parts[1])) {
+		case "strict":
+			return network.CookieSameSite.Strict
+		case "lax":
+			return network.CookieSameSite.Lax
+		case "none":
+			return network.CookieSameSite.None
+		}
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+//go:build go1.11
+// +build go1.11
+
+package chrome
+
+import (
+	"net/http"
+
+	"github.com/mkenney/go-chrome/tot/network"
+)
+
+/*
+sameSiteNoneMode is http.SameSiteNoneMode, which was added in Go 1.13. Earlier
+versions don't write the attribute.
+*/
+const sameSiteNoneMode = http.SameSite(4)
+
+/*
+httpCookieSameSite returns the SameSite attribute of an http.Cookie.
+*/
+func httpCookieSameSite(cookie *http.Cookie) network.CookieSameSiteEnum {
+	switch cookie.SameSite {
+	case http.SameSiteStrictMode:
+		return network.CookieSameSite.Strict
+	case http.SameSiteLaxMode:
+		return network.CookieSameSite.Lax
+	case sameSiteNoneMode:
+		return network.CookieSameSite.None
+	}
+	return unparsedSameSite(cookie)
+}
+
+/*
+setHTTPCookieSameSite sets the SameSite attribute of an http.Cookie.
+*/
+func setHTTPCookieSameSite(cookie *http.Cookie, sameSite network.CookieSameSiteEnum) {
+	switch sameSite {
+	case network.CookieSameSite.Strict:
+		cookie.SameSite = http.SameSiteStrictMode
+	case network.CookieSameSite.Lax:
+		cookie.SameSite = http.SameSiteLaxMode
+	case network.CookieSameSite.None:
+		cookie.SameSite = sameSiteNoneMode
+	}
+}
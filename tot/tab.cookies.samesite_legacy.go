@@ -0,0 +1,26 @@
+//go:build !go1.11
+// +build !go1.11
+
+package chrome
+
+import (
+	"net/http"
+
+	"github.com/mkenney/go-chrome/tot/network"
+)
+
+/*
+httpCookieSameSite returns the SameSite attribute of an http.Cookie. Before
+Go 1.11 http.Cookie has no SameSite field, only cookies that kept the attribute
+in their unparsed attributes are supported.
+*/
+func httpCookieSameSite(cookie *http.Cookie) network.CookieSameSiteEnum {
+	return unparsedSameSite(cookie)
+}
+
+/*
+setHTTPCookieSameSite is a no-op before Go 1.11, http.Cookie has no SameSite
+field.
+*/
+func setHTTPCookieSameSite(cookie *http.Cookie, sameSite network.CookieSameSiteEnum) {
+}
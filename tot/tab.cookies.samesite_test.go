@@ -0,0 +1,29 @@
+//go:build go1.11
+// +build go1.11
+
+package chrome
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mkenney/go-chrome/tot/network"
+)
+
+func TestHTTPCookieSameSite(t *testing.T) {
+	for _, sameSite := range []network.CookieSameSiteEnum{
+		network.CookieSameSite.Strict,
+		network.CookieSameSite.Lax,
+		network.CookieSameSite.None,
+	} {
+		httpCookie := HTTPCookie(&network.Cookie{Name: "a", SameSite: sameSite})
+		if received := httpCookieSameSite(httpCookie); sameSite != received {
+			t.Errorf("Expected '%s', received '%s'", sameSite, received)
+		}
+	}
+
+	cookie := &http.Cookie{Name: "a", Unparsed: []string{"SameSite=None"}}
+	if received := httpCookieSameSite(cookie); network.CookieSameSite.None != received {
+		t.Errorf("Expected 'None', received '%s'", received)
+	}
+}
@@ -0,0 +1,172 @@
+package chrome
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/network"
+)
+
+func testCookies() []*network.Cookie {
+	return []*network.Cookie{
+		{
+			Name:     "session",
+			Value:    "abc",
+			Domain:   "example.com",
+			Path:     "/",
+			Expires:  -1,
+			HTTPOnly: true,
+			Secure:   true,
+			Session:  true,
+			SameSite: network.CookieSameSite.Strict,
+		},
+		{
+			Name:     "prefs",
+			Value:    "dark",
+			Domain:   ".example.com",
+			Path:     "/app",
+			Expires:  1893456000.5,
+			SameSite: network.CookieSameSite.None,
+		},
+		{
+			Name:    "plain",
+			Value:   "1",
+			Domain:  "example.com",
+			Path:    "/",
+			Expires: 1893456000,
+		},
+	}
+}
+
+func TestTabCookies(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabCookies")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := tab.Cookies(ctx); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if err := tab.SetCookies(ctx, testCookies()); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	jar := NewCookieJar(tab, 10*time.Millisecond)
+	u, _ := url.Parse("https://example.com/")
+	if cookies := jar.Cookies(u); nil != cookies {
+		t.Errorf("Expected nil, received %v", cookies)
+	}
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: "b"}, {Name: "c", MaxAge: -1}})
+}
+
+func TestCookieConversion(t *testing.T) {
+	cookies := testCookies()
+
+	params := CookieParams(cookies[0])
+	if 0 != params.Expires || !params.HTTPOnly || !params.Secure || network.CookieSameSite.Strict != params.SameSite {
+		t.Errorf("Expected a secure, http-only, strict session cookie, received %v", params)
+	}
+	params = CookieParams(cookies[1])
+	if 1893456001 != params.Expires {
+		t.Errorf("Expected expiry 1893456001, received %d", params.Expires)
+	}
+
+	httpCookie := HTTPCookie(cookies[1])
+	if 1893456000 != httpCookie.Expires.Unix() || 500*time.Millisecond != time.Duration(httpCookie.Expires.Nanosecond()) {
+		t.Errorf("Expected expiry 1893456000.5, received %s", httpCookie.Expires)
+	}
+	if !HTTPCookie(cookies[0]).Expires.IsZero() {
+		t.Errorf("Expected a session cookie")
+	}
+
+	u, _ := url.Parse("https://example.com/login")
+	params = httpCookieParams(u, &http.Cookie{Name: "id", Value: "1", MaxAge: 60, HttpOnly: true})
+	if "https://example.com/login" != params.URL || !params.HTTPOnly {
+		t.Errorf("Expected a host-only http-only cookie, received %v", params)
+	}
+	if params.Expires < network.TimeSinceEpoch(time.Now().Unix()+59) {
+		t.Errorf("Expected expiry in 60 seconds, received %d", params.Expires)
+	}
+}
+
+func TestNetscapeCookies(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteNetscapeCookies(buf, testCookies()); nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	expected := "# Netscape HTTP Cookie File\n" +
+		"#HttpOnly_example.com\tFALSE\t/\tTRUE\t0\tsession\tabc\n" +
+		".example.com\tTRUE\t/app\tFALSE\t1893456000\tprefs\tdark\n" +
+		"example.com\tFALSE\t/\tFALSE\t1893456000\tplain\t1\n"
+	if expected != buf.String() {
+		t.Errorf("Expected '%s', received '%s'", expected, buf.String())
+	}
+
+	cookies, err := ReadNetscapeCookies(buf)
+	if nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	if 3 != len(cookies) {
+		t.Fatalf("Expected 3 cookies, received %d", len(cookies))
+	}
+	if !cookies[0].HTTPOnly || !cookies[0].Secure || !cookies[0].Session {
+		t.Errorf("Expected a secure, http-only session cookie, received %v", cookies[0])
+	}
+	if 1893456000 != cookies[1].Expires || ".example.com" != cookies[1].Domain {
+		t.Errorf("Expected expiry 1893456000 and domain .example.com, received %v", cookies[1])
+	}
+	if "example.com" != cookies[2].Domain {
+		t.Errorf("Expected a host-only cookie, received %v", cookies[2])
+	}
+
+	// Domain cookies may be written without a leading dot.
+	cookies, err = ReadNetscapeCookies(strings.NewReader("example.com\tTRUE\t/\tFALSE\t0\ta\tb\n"))
+	if nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	if 1 != len(cookies) || ".example.com" != cookies[0].Domain {
+		t.Errorf("Expected a domain cookie for .example.com, received %v", cookies)
+	}
+
+	if _, err := ReadNetscapeCookies(strings.NewReader("example.com\tFALSE\t/\n")); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if _, err := ReadNetscapeCookies(strings.NewReader("example.com\tFALSE\t/\tFALSE\tsoon\ta\tb\n")); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestJSONCookies(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteJSONCookies(buf, testCookies()); nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	cookies, err := ReadJSONCookies(buf)
+	if nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	if 3 != len(cookies) {
+		t.Fatalf("Expected 3 cookies, received %d", len(cookies))
+	}
+	if 1893456000.5 != cookies[1].Expires || network.CookieSameSite.None != cookies[1].SameSite {
+		t.Errorf("Expected expiry 1893456000.5 and SameSite None, received %v", cookies[1])
+	}
+	if !cookies[0].HTTPOnly || !cookies[0].Secure {
+		t.Errorf("Expected a secure http-only cookie, received %v", cookies[0])
+	}
+
+	if _, err := ReadJSONCookies(strings.NewReader("{")); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
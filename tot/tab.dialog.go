@@ -0,0 +1,373 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+	"github.com/mkenney/go-chrome/tot/page"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+DefaultDialogTimeout is the time a DialogHandler is given to decide before the
+dialog gets its default action.
+*/
+var DefaultDialogTimeout = 5 * time.Second
+
+/*
+dialogCloseTimeout is the time Tab.Close waits for an open dialog to be
+accepted.
+*/
+const dialogCloseTimeout = time.Second
+
+/*
+DialogHandler decides how a JavaScript dialog is handled. promptText is only
+used when a prompt dialog is accepted.
+*/
+type DialogHandler func(dialog *page.JavascriptDialogOpeningEvent) (accept bool, promptText string)
+
+/*
+AcceptDialog returns a DialogHandler that accepts dialogs. Prompts are accepted
+with their default text.
+*/
+func AcceptDialog() DialogHandler {
+	return func(dialog *page.JavascriptDialogOpeningEvent) (bool, string) {
+		return true, dialog.DefaultPrompt
+	}
+}
+
+/*
+DismissDialog returns a DialogHandler that dismisses dialogs.
+*/
+func DismissDialog() DialogHandler {
+	return func(dialog *page.JavascriptDialogOpeningEvent) (bool, string) {
+		return false, ""
+	}
+}
+
+/*
+PromptDialog returns a DialogHandler that accepts dialogs, entering text into
+prompts.
+*/
+func PromptDialog(text string) DialogHandler {
+	return func(dialog *page.JavascriptDialogOpeningEvent) (bool, string) {
+		return true, text
+	}
+}
+
+/*
+DialogPolicy defines how the JavaScript dialogs of a tab are handled, by dialog
+type. Any DialogHandler can be used as a callback, AcceptDialog, DismissDialog
+and PromptDialog provide the common modes:
+
+	tab.SetDialogPolicy(ctx, &chrome.DialogPolicy{
+		Confirm: chrome.AcceptDialog(),
+		Prompt:  chrome.PromptDialog("go-chrome"),
+		Default: chrome.DismissDialog(),
+	})
+
+Dialog types without a handler use Default. Without a Default, beforeunload
+dialogs are accepted and other dialogs are dismissed.
+*/
+type DialogPolicy struct {
+	// Alert handles alert() dialogs.
+	Alert DialogHandler
+
+	// Beforeunload handles beforeunload dialogs.
+	Beforeunload DialogHandler
+
+	// Confirm handles confirm() dialogs.
+	Confirm DialogHandler
+
+	// Default handles dialog types without a handler.
+	Default DialogHandler
+
+	// Prompt handles prompt() dialogs.
+	Prompt DialogHandler
+
+	// Timeout limits the time handlers are given to decide, after which the
+	// dialog gets the default action. 0 uses DefaultDialogTimeout.
+	Timeout time.Duration
+}
+
+/*
+handler returns the DialogHandler for a dialog type.
+*/
+func (policy *DialogPolicy) handler(dialogType page.DialogTypeEnum) DialogHandler {
+	var handler DialogHandler
+	switch dialogType {
+	case page.DialogType.Alert:
+		handler = policy.Alert
+	case page.DialogType.Beforeunload:
+		handler = policy.Beforeunload
+	case page.DialogType.Confirm:
+		handler = policy.Confirm
+	case page.DialogType.Prompt:
+		handler = policy.Prompt
+	}
+	if nil == handler {
+		handler = policy.Default
+	}
+	if nil == handler {
+		handler = defaultDialogHandler(dialogType)
+	}
+	return handler
+}
+
+/*
+timeout returns the time handlers are given to decide.
+*/
+func (policy *DialogPolicy) timeout() time.Duration {
+	if 0 < policy.Timeout {
+		return policy.Timeout
+	}
+	return DefaultDialogTimeout
+}
+
+/*
+SetDialogPolicy handles the JavaScript dialogs of the tab according to policy,
+replacing any previous policy. A nil policy stops handling dialogs, which then
+block the page until Page.handleJavaScriptDialog is called.
+
+Every dialog is logged. While the tab is closing, open dialogs are accepted so
+beforeunload handlers can't keep the page open.
+*/
+func (tab *Tab) SetDialogPolicy(ctx context.Context, policy *DialogPolicy) error {
+	tab.mux.Lock()
+	dialogs := tab.dialogs
+	if nil == policy {
+		tab.dialogs = nil
+		tab.mux.Unlock()
+		if nil != dialogs {
+			dialogs.removeHandlers()
+		}
+		return nil
+	}
+	if nil != dialogs {
+		dialogs.setPolicy(policy)
+		tab.mux.Unlock()
+		return nil
+	}
+	dialogs = newDialogManager(tab, policy)
+	tab.dialogs = dialogs
+	tab.mux.Unlock()
+
+	for _, handler := range dialogs.handlers {
+		tab.AddEventHandler(handler)
+	}
+
+	resultChan := tab.Page().Enable()
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			tab.SetDialogPolicy(ctx, nil)
+			return errs.Wrap(result.Err, 0, "Page.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		tab.SetDialogPolicy(ctx, nil)
+		return errs.Wrap(ctx.Err(), 0, "Page.enable failed")
+	}
+	return nil
+}
+
+/*
+newDialogManager returns a dialog manager for a tab.
+*/
+func newDialogManager(tab *Tab, policy *DialogPolicy) *dialogManager {
+	dialogs := &dialogManager{
+		mux:    &sync.Mutex{},
+		policy: policy,
+		tab:    tab,
+	}
+	dialogs.handlers = []socket.EventHandler{
+		socket.NewEventHandler("Page.javascriptDialogOpening", func(response *socket.Response) {
+			event := &page.JavascriptDialogOpeningEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err {
+				dialogs.opening(event)
+			}
+		}),
+		socket.NewEventHandler("Page.javascriptDialogClosed", func(response *socket.Response) {
+			dialogs.closed()
+		}),
+	}
+	return dialogs
+}
+
+/*
+dialogManager applies a DialogPolicy to the dialogs of a tab.
+*/
+type dialogManager struct {
+	// closing is set when the tab is closing.
+	closing bool
+
+	// handlers holds the event handlers registered by the manager.
+	handlers []socket.EventHandler
+
+	// mux protects closing, open and policy.
+	mux *sync.Mutex
+
+	// open is the dialog currently open, if any.
+	open *page.JavascriptDialogOpeningEvent
+
+	// policy is the dialog policy.
+	policy *DialogPolicy
+
+	// tab is the tab being managed.
+	tab *Tab
+}
+
+/*
+close accepts any open dialog, waiting at most dialogCloseTimeout, and stops
+handling dialogs. Called when the tab is closing.
+*/
+func (dialogs *dialogManager) close() {
+	dialogs.mux.Lock()
+	dialogs.closing = true
+	open := dialogs.open
+	dialogs.mux.Unlock()
+
+	if nil != open {
+		log.WithFields(log.Fields{
+			"type":    open.Type,
+			"message": open.Message,
+			"url":     open.URL,
+		}).Info("accepting javascript dialog, tab is closing")
+		resultChan := dialogs.tab.Page().HandleJavaScriptDialog(&page.HandleJavaScriptDialogParams{
+			Accept: true,
+		})
+		select {
+		case <-resultChan:
+		case <-time.After(dialogCloseTimeout):
+			go func() { <-resultChan }()
+		}
+	}
+	dialogs.removeHandlers()
+}
+
+/*
+closed handles Page.javascriptDialogClosed events.
+*/
+func (dialogs *dialogManager) closed() {
+	dialogs.mux.Lock()
+	defer dialogs.mux.Unlock()
+	dialogs.open = nil
+}
+
+/*
+decide returns the action for a dialog, calling the policy handler in its own
+goroutine so a slow or blocked handler can't hold the dialog open past the
+policy timeout.
+*/
+func (dialogs *dialogManager) decide(dialog *page.JavascriptDialogOpeningEvent) (accept bool, promptText string) {
+	dialogs.mux.Lock()
+	closing := dialogs.closing
+	policy := dialogs.policy
+	dialogs.mux.Unlock()
+	if closing {
+		return true, dialog.DefaultPrompt
+	}
+
+	type decision struct {
+		accept     bool
+		promptText string
+	}
+	decisionChan := make(chan decision, 1)
+	handler := policy.handler(dialog.Type)
+	go func() {
+		defer func() {
+			if err := recover(); nil != err {
+				log.WithFields(log.Fields{
+					"type":  dialog.Type,
+					"error": err,
+				}).Warn("javascript dialog handler panicked")
+				close(decisionChan)
+			}
+		}()
+		accept, promptText := handler(dialog)
+		decisionChan <- decision{accept, promptText}
+	}()
+
+	select {
+	case result, ok := <-decisionChan:
+		if ok {
+			return result.accept, result.promptText
+		}
+	case <-time.After(policy.timeout()):
+		log.WithFields(log.Fields{
+			"type":    dialog.Type,
+			"timeout": policy.timeout(),
+		}).Warn("javascript dialog handler timed out")
+	}
+	return defaultDialogHandler(dialog.Type)(dialog)
+}
+
+/*
+handle decides how a dialog is handled and sends the decision to Chromium.
+*/
+func (dialogs *dialogManager) handle(dialog *page.JavascriptDialogOpeningEvent) {
+	accept, promptText := dialogs.decide(dialog)
+	log.WithFields(log.Fields{
+		"type":    dialog.Type,
+		"message": dialog.Message,
+		"url":     dialog.URL,
+		"accept":  accept,
+	}).Info("handling javascript dialog")
+
+	params := &page.HandleJavaScriptDialogParams{Accept: accept}
+	if accept && page.DialogType.Prompt == dialog.Type {
+		params.PromptText = promptText
+	}
+	if result := <-dialogs.tab.Page().HandleJavaScriptDialog(params); nil != result.Err {
+		// The dialog may already have been closed, by navigation for example.
+		log.WithFields(log.Fields{
+			"type":  dialog.Type,
+			"error": result.Err,
+		}).Warn("could not handle javascript dialog")
+	}
+}
+
+/*
+opening handles Page.javascriptDialogOpening events.
+*/
+func (dialogs *dialogManager) opening(dialog *page.JavascriptDialogOpeningEvent) {
+	dialogs.mux.Lock()
+	dialogs.open = dialog
+	dialogs.mux.Unlock()
+	// Event handlers run in the socket loop, which must keep running for the
+	// handler to send commands.
+	go dialogs.handle(dialog)
+}
+
+/*
+removeHandlers removes the event handlers registered by the manager.
+*/
+func (dialogs *dialogManager) removeHandlers() {
+	for _, handler := range dialogs.handlers {
+		dialogs.tab.RemoveEventHandler(handler)
+	}
+}
+
+/*
+setPolicy replaces the dialog policy.
+*/
+func (dialogs *dialogManager) setPolicy(policy *DialogPolicy) {
+	dialogs.mux.Lock()
+	defer dialogs.mux.Unlock()
+	dialogs.policy = policy
+}
+
+/*
+defaultDialogHandler returns the handler for dialogs without a policy: the page
+may unload and other dialogs are dismissed.
+*/
+func defaultDialogHandler(dialogType page.DialogTypeEnum) DialogHandler {
+	if page.DialogType.Beforeunload == dialogType {
+		return AcceptDialog()
+	}
+	return DismissDialog()
+}
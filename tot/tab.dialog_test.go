@@ -0,0 +1,139 @@
+package chrome
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/page"
+)
+
+func TestDialogPolicyHandler(t *testing.T) {
+	dialog := &page.JavascriptDialogOpeningEvent{DefaultPrompt: "default"}
+	policy := &DialogPolicy{}
+	for dialogType, expected := range map[page.DialogTypeEnum]bool{
+		page.DialogType.Alert:        false,
+		page.DialogType.Beforeunload: true,
+		page.DialogType.Confirm:      false,
+		page.DialogType.Prompt:       false,
+	} {
+		if accept, _ := policy.handler(dialogType)(dialog); expected != accept {
+			t.Errorf("Expected %v for %s, received %v", expected, dialogType, accept)
+		}
+	}
+
+	policy = &DialogPolicy{
+		Prompt:  PromptDialog("text"),
+		Default: AcceptDialog(),
+	}
+	if accept, text := policy.handler(page.DialogType.Prompt)(dialog); !accept || "text" != text {
+		t.Errorf("Expected accepted prompt with 'text', received %v '%s'", accept, text)
+	}
+	if accept, text := policy.handler(page.DialogType.Confirm)(dialog); !accept || "default" != text {
+		t.Errorf("Expected accepted confirm with 'default', received %v '%s'", accept, text)
+	}
+	policy.Beforeunload = DismissDialog()
+	if accept, _ := policy.handler(page.DialogType.Beforeunload)(dialog); accept {
+		t.Errorf("Expected dismissed beforeunload, received accept")
+	}
+}
+
+func TestDialogManagerDecide(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestDialogManagerDecide")
+
+	var received *page.JavascriptDialogOpeningEvent
+	dialogs := newDialogManager(tab, &DialogPolicy{
+		Confirm: func(dialog *page.JavascriptDialogOpeningEvent) (bool, string) {
+			received = dialog
+			return true, ""
+		},
+		Prompt: func(dialog *page.JavascriptDialogOpeningEvent) (bool, string) {
+			select {}
+		},
+		Alert: func(dialog *page.JavascriptDialogOpeningEvent) (bool, string) {
+			panic("handler failed")
+		},
+		Timeout: 10 * time.Millisecond,
+	})
+
+	confirm := &page.JavascriptDialogOpeningEvent{Type: page.DialogType.Confirm, Message: "sure?"}
+	if accept, _ := dialogs.decide(confirm); !accept {
+		t.Errorf("Expected accept, received dismiss")
+	}
+	if confirm != received {
+		t.Errorf("Expected the dialog to be passed to the handler")
+	}
+
+	prompt := &page.JavascriptDialogOpeningEvent{Type: page.DialogType.Prompt}
+	if accept, _ := dialogs.decide(prompt); accept {
+		t.Errorf("Expected a blocked handler to time out and dismiss, received accept")
+	}
+
+	alert := &page.JavascriptDialogOpeningEvent{Type: page.DialogType.Alert}
+	if accept, _ := dialogs.decide(alert); accept {
+		t.Errorf("Expected a panicking handler to dismiss, received accept")
+	}
+
+	dialogs.closing = true
+	if accept, _ := dialogs.decide(prompt); !accept {
+		t.Errorf("Expected dialogs to be accepted while closing, received dismiss")
+	}
+}
+
+func TestTabSetDialogPolicy(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabSetDialogPolicy")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tab.SetDialogPolicy(ctx, &DialogPolicy{}); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if nil != tab.dialogs {
+		t.Errorf("Expected no dialog policy after failure")
+	}
+	if err := tab.SetDialogPolicy(ctx, nil); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+}
+
+func TestTabCloseDialog(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabCloseDialog")
+
+	tab.dialogs = newDialogManager(tab, &DialogPolicy{Beforeunload: DismissDialog()})
+	tab.dialogs.open = &page.JavascriptDialogOpeningEvent{Type: page.DialogType.Beforeunload}
+
+	done := make(chan struct{})
+	go func() {
+		tab.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected Close to return with an open beforeunload dialog")
+	}
+	if nil != tab.dialogs {
+		t.Errorf("Expected the dialog policy to be removed")
+	}
+}
@@ -0,0 +1,568 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+	"github.com/mkenney/go-chrome/tot/network"
+	"github.com/mkenney/go-chrome/tot/page"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+downloadPollInterval is the interval at which the staging directory is checked
+for download progress.
+*/
+const downloadPollInterval = 100 * time.Millisecond
+
+/*
+downloadResponseLimit is the number of recent network responses kept to
+describe downloads that begin after their response was received.
+*/
+const downloadResponseLimit = 32
+
+/*
+Download describes a file downloaded by a tab.
+*/
+type Download struct {
+	// Dir is the unique directory holding the downloaded file, set when the
+	// download completes.
+	Dir string
+
+	// Err is set if the download was canceled or couldn't be moved to Dir.
+	Err error
+
+	// FrameID is the ID of the frame that started the download.
+	FrameID page.FrameID
+
+	// GUID is the unique ID Chromium assigned to the download.
+	GUID string
+
+	// MimeType is the MIME type of the download, if the response was seen.
+	MimeType string
+
+	// Path is the final path of the downloaded file, set when the download
+	// completes.
+	Path string
+
+	// ReceivedBytes is the number of bytes received so far.
+	ReceivedBytes int64
+
+	// Size is the size of the downloaded file once complete, or the expected
+	// size while in progress, 0 if unknown.
+	Size int64
+
+	// State is the download state.
+	State page.DownloadStateEnum
+
+	// SuggestedFilename is the file name suggested by the server or page. The
+	// file is saved under this name, see Path.
+	SuggestedFilename string
+
+	// URL is the URL of the downloaded resource.
+	URL string
+
+	// finishing is set while a completed download is being moved to Dir.
+	finishing bool
+
+	// progress is set once a Page.downloadProgress event was received.
+	// Otherwise completion is detected from the staging directory.
+	progress bool
+
+	// size is the staged file size at the last poll.
+	size int64
+}
+
+/*
+Finished returns true if the download completed or was canceled.
+*/
+func (download *Download) Finished() bool {
+	return page.DownloadState.Completed == download.State ||
+		page.DownloadState.Canceled == download.State
+}
+
+/*
+NewDownloadManager allows downloads in a tab and tracks them. Each download is
+saved to its own directory created in dir, or in the default temporary
+directory if dir is empty. Downloaded files are not removed by the manager.
+
+	downloads, err := chrome.NewDownloadManager(ctx, tab, "")
+	...
+	// click the export button
+	download, err := downloads.WaitFor(ctx, func(d *chrome.Download) bool {
+		return strings.HasSuffix(d.SuggestedFilename, ".csv")
+	})
+
+Downloads are detected with the Page.downloadWillBegin and
+Page.downloadProgress events, Network.responseReceived events provide the MIME
+type and expected size. Chromium versions without Page.downloadProgress events
+are supported by watching the staging directory.
+*/
+func NewDownloadManager(ctx context.Context, tab *Tab, dir string) (*DownloadManager, error) {
+	stage, err := ioutil.TempDir(dir, "go-chrome-downloads-")
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "could not create download directory")
+	}
+
+	manager := newDownloadManager(tab, dir, stage)
+	manager.handlers = []socket.EventHandler{
+		socket.NewEventHandler("Page.downloadWillBegin", func(response *socket.Response) {
+			event := &page.DownloadWillBeginEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err {
+				manager.begin(event)
+			}
+		}),
+		socket.NewEventHandler("Page.downloadProgress", func(response *socket.Response) {
+			event := &page.DownloadProgressEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err {
+				manager.progress(event)
+			}
+		}),
+		socket.NewEventHandler("Network.responseReceived", func(response *socket.Response) {
+			event := &network.ResponseReceivedEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err && nil != event.Response {
+				manager.response(event.Response)
+			}
+		}),
+	}
+	for _, handler := range manager.handlers {
+		tab.AddEventHandler(handler)
+	}
+
+	if err := manager.enable(ctx); nil != err {
+		manager.Close()
+		return nil, err
+	}
+
+	go manager.watch()
+	return manager, nil
+}
+
+/*
+newDownloadManager returns a download manager that stages downloads in stage.
+*/
+func newDownloadManager(tab *Tab, dir, stage string) *DownloadManager {
+	return &DownloadManager{
+		changed:   make(chan struct{}),
+		dir:       dir,
+		downloads: make(map[string]*Download),
+		guids:     []string{},
+		mux:       &sync.Mutex{},
+		responses: make(map[string]*network.Response),
+		stage:     stage,
+		stop:      make(chan struct{}),
+		tab:       tab,
+		urls:      []string{},
+	}
+}
+
+/*
+DownloadManager tracks the downloads of a tab.
+*/
+type DownloadManager struct {
+	// changed is closed and replaced whenever a download changes.
+	changed chan struct{}
+
+	// closed is set when the manager has been closed.
+	closed bool
+
+	// dir is the directory download directories are created in.
+	dir string
+
+	// downloads holds the downloads by GUID.
+	downloads map[string]*Download
+
+	// guids holds the download GUIDs in the order the downloads began.
+	guids []string
+
+	// handlers holds the event handlers registered by the manager.
+	handlers []socket.EventHandler
+
+	// mux protects changed, closed, downloads, guids, responses and urls.
+	mux *sync.Mutex
+
+	// responses holds recent network responses by URL.
+	responses map[string]*network.Response
+
+	// stage is the directory Chromium saves downloads to.
+	stage string
+
+	// stop is closed when the manager is closed.
+	stop chan struct{}
+
+	// tab is the tab being observed.
+	tab *Tab
+
+	// urls holds the URLs of responses in the order they were received.
+	urls []string
+}
+
+/*
+Close stops tracking downloads, disables downloads in the tab and removes the
+staging directory. Completed downloads remain in their directories.
+*/
+func (manager *DownloadManager) Close() {
+	for _, handler := range manager.handlers {
+		manager.tab.RemoveEventHandler(handler)
+	}
+
+	manager.mux.Lock()
+	if manager.closed {
+		manager.mux.Unlock()
+		return
+	}
+	manager.closed = true
+	close(manager.stop)
+	manager.notify()
+	manager.mux.Unlock()
+
+	resultChan := manager.tab.Page().SetDownloadBehavior(&page.SetDownloadBehaviorParams{
+		Behavior: page.Behavior.Default,
+	})
+	go func() { <-resultChan }()
+
+	if err := os.RemoveAll(manager.stage); nil != err {
+		log.WithFields(log.Fields{
+			"dir":   manager.stage,
+			"error": err,
+		}).Warn("could not remove download directory")
+	}
+}
+
+/*
+Download returns a copy of the download with the specified GUID.
+*/
+func (manager *DownloadManager) Download(guid string) (*Download, bool) {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+	download, ok := manager.downloads[guid]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *download
+	return &snapshot, true
+}
+
+/*
+Downloads returns copies of all downloads in the order they began.
+*/
+func (manager *DownloadManager) Downloads() []*Download {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+	downloads := make([]*Download, 0, len(manager.guids))
+	for _, guid := range manager.guids {
+		snapshot := *manager.downloads[guid]
+		downloads = append(downloads, &snapshot)
+	}
+	return downloads
+}
+
+/*
+Wait waits for the download with the specified GUID to finish. The download
+error is returned if it was canceled.
+*/
+func (manager *DownloadManager) Wait(ctx context.Context, guid string) (*Download, error) {
+	return manager.WaitFor(ctx, func(download *Download) bool {
+		return guid == download.GUID
+	})
+}
+
+/*
+WaitFor waits for a download matching match to finish, including downloads
+that finished before WaitFor was called. match is called with copies of the
+downloads while they are in progress and must not block. The download error is
+returned if it was canceled.
+*/
+func (manager *DownloadManager) WaitFor(ctx context.Context, match func(download *Download) bool) (*Download, error) {
+	for {
+		manager.mux.Lock()
+		for _, guid := range manager.guids {
+			snapshot := *manager.downloads[guid]
+			if snapshot.Finished() && match(&snapshot) {
+				manager.mux.Unlock()
+				return &snapshot, snapshot.Err
+			}
+		}
+		closed := manager.closed
+		changed := manager.changed
+		manager.mux.Unlock()
+
+		if closed {
+			return nil, errs.New(0, "download manager closed")
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, errs.Wrap(ctx.Err(), 0, "download did not finish")
+		}
+	}
+}
+
+/*
+begin starts tracking a download.
+*/
+func (manager *DownloadManager) begin(event *page.DownloadWillBeginEvent) {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+	if _, ok := manager.downloads[event.GUID]; ok {
+		return
+	}
+
+	download := &Download{
+		FrameID:           event.FrameID,
+		GUID:              event.GUID,
+		State:             page.DownloadState.InProgress,
+		SuggestedFilename: event.SuggestedFilename,
+		URL:               event.URL,
+	}
+	if response, ok := manager.responses[event.URL]; ok {
+		download.MimeType = response.MimeType
+		download.Size = contentLength(response.Headers)
+	}
+	manager.downloads[event.GUID] = download
+	manager.guids = append(manager.guids, event.GUID)
+	manager.notify()
+}
+
+/*
+enable enables the Page and Network domains and allows downloads to the
+staging directory.
+*/
+func (manager *DownloadManager) enable(ctx context.Context) error {
+	pageChan := manager.tab.Page().Enable()
+	select {
+	case result := <-pageChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Page.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-pageChan }()
+		return errs.Wrap(ctx.Err(), 0, "Page.enable failed")
+	}
+
+	networkChan := manager.tab.Network().Enable(&network.EnableParams{})
+	select {
+	case result := <-networkChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Network.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-networkChan }()
+		return errs.Wrap(ctx.Err(), 0, "Network.enable failed")
+	}
+
+	// Downloads are saved under their GUID so concurrent downloads of files
+	// with the same name can't collide.
+	behaviorChan := manager.tab.Page().SetDownloadBehavior(&page.SetDownloadBehaviorParams{
+		Behavior:     page.Behavior.AllowAndName,
+		DownloadPath: manager.stage,
+	})
+	select {
+	case result := <-behaviorChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Page.setDownloadBehavior failed")
+		}
+	case <-ctx.Done():
+		go func() { <-behaviorChan }()
+		return errs.Wrap(ctx.Err(), 0, "Page.setDownloadBehavior failed")
+	}
+	return nil
+}
+
+/*
+finish moves a completed download from the staging directory to its own
+directory.
+*/
+func (manager *DownloadManager) finish(guid string) {
+	manager.mux.Lock()
+	download, ok := manager.downloads[guid]
+	if !ok || download.finishing || download.Finished() {
+		manager.mux.Unlock()
+		return
+	}
+	download.finishing = true
+	name := downloadFilename(download.SuggestedFilename, guid)
+	manager.mux.Unlock()
+
+	var path string
+	var size int64
+	dir, err := ioutil.TempDir(manager.dir, "download-")
+	if nil == err {
+		path = filepath.Join(dir, name)
+		err = os.Rename(filepath.Join(manager.stage, guid), path)
+	}
+	if nil == err {
+		var info os.FileInfo
+		if info, err = os.Stat(path); nil == err {
+			size = info.Size()
+		}
+	}
+
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+	download.finishing = false
+	download.State = page.DownloadState.Completed
+	if nil != err {
+		download.Err = errs.Wrap(err, 0, fmt.Sprintf("could not save download '%s'", name))
+	} else {
+		download.Dir = dir
+		download.Path = path
+		download.ReceivedBytes = size
+		download.Size = size
+	}
+	manager.notify()
+}
+
+/*
+notify wakes goroutines waiting for a download to change. The caller must hold
+the lock.
+*/
+func (manager *DownloadManager) notify() {
+	close(manager.changed)
+	manager.changed = make(chan struct{})
+}
+
+/*
+poll checks the staging directory for downloads that don't report progress. A
+download is complete once its file exists under its GUID and its size hasn't
+changed since the last poll.
+*/
+func (manager *DownloadManager) poll() {
+	manager.mux.Lock()
+	pending := []*Download{}
+	for _, guid := range manager.guids {
+		download := manager.downloads[guid]
+		if !download.progress && !download.finishing && !download.Finished() {
+			pending = append(pending, download)
+		}
+	}
+	manager.mux.Unlock()
+
+	for _, download := range pending {
+		path := filepath.Join(manager.stage, download.GUID)
+		partial, partialErr := os.Stat(path + ".crdownload")
+		info, err := os.Stat(path)
+		if nil != err {
+			if nil == partialErr {
+				manager.mux.Lock()
+				download.ReceivedBytes = partial.Size()
+				manager.mux.Unlock()
+			}
+			continue
+		}
+
+		manager.mux.Lock()
+		stable := nil != partialErr && info.Size() == download.size
+		download.size = info.Size()
+		download.ReceivedBytes = info.Size()
+		manager.mux.Unlock()
+		if stable {
+			manager.finish(download.GUID)
+		}
+	}
+}
+
+/*
+progress updates a download from a Page.downloadProgress event.
+*/
+func (manager *DownloadManager) progress(event *page.DownloadProgressEvent) {
+	manager.mux.Lock()
+	download, ok := manager.downloads[event.GUID]
+	if !ok || download.finishing || download.Finished() {
+		manager.mux.Unlock()
+		return
+	}
+	download.progress = true
+	download.ReceivedBytes = int64(event.ReceivedBytes)
+	if 0 < event.TotalBytes {
+		download.Size = int64(event.TotalBytes)
+	}
+	if page.DownloadState.Canceled == event.State {
+		download.State = page.DownloadState.Canceled
+		download.Err = errs.New(0, fmt.Sprintf("download of '%s' was canceled", download.URL))
+	}
+	manager.notify()
+	manager.mux.Unlock()
+
+	if page.DownloadState.Completed == event.State {
+		// Event handlers run in the socket loop, leave file operations to a
+		// goroutine.
+		go manager.finish(event.GUID)
+	}
+}
+
+/*
+response records a network response that may belong to a download.
+*/
+func (manager *DownloadManager) response(response *network.Response) {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+	if _, ok := manager.responses[response.URL]; !ok {
+		manager.urls = append(manager.urls, response.URL)
+	}
+	manager.responses[response.URL] = response
+	if len(manager.urls) > downloadResponseLimit {
+		delete(manager.responses, manager.urls[0])
+		manager.urls = manager.urls[1:]
+	}
+
+	for _, download := range manager.downloads {
+		if response.URL == download.URL && !download.Finished() && 0 == download.Size {
+			download.MimeType = response.MimeType
+			download.Size = contentLength(response.Headers)
+		}
+	}
+}
+
+/*
+watch polls the staging directory until the manager is closed.
+*/
+func (manager *DownloadManager) watch() {
+	ticker := time.NewTicker(downloadPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			manager.poll()
+		case <-manager.stop:
+			return
+		}
+	}
+}
+
+/*
+contentLength returns the Content-Length response header value, or 0.
+*/
+func contentLength(headers network.Headers) int64 {
+	for name, value := range headers {
+		if strings.EqualFold("Content-Length", name) {
+			length, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if nil == err && 0 < length {
+				return length
+			}
+		}
+	}
+	return 0
+}
+
+/*
+downloadFilename returns a safe file name for a download, falling back to its
+GUID.
+*/
+func downloadFilename(suggested, guid string) string {
+	name := filepath.Base(strings.Replace(suggested, "\\", "/", -1))
+	if "" == name || "." == name || ".." == name || "/" == name || string(filepath.Separator) == name {
+		return guid
+	}
+	return name
+}
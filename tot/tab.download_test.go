@@ -0,0 +1,196 @@
+package chrome
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/network"
+	"github.com/mkenney/go-chrome/tot/page"
+)
+
+func newTestDownloadManager(t *testing.T, name string) (*DownloadManager, string) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://" + name)
+
+	dir, err := ioutil.TempDir("", "go-chrome-test-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	stage, err := ioutil.TempDir(dir, "stage-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	return newDownloadManager(tab, dir, stage), dir
+}
+
+func TestNewDownloadManager(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestNewDownloadManager")
+
+	dir, err := ioutil.TempDir("", "go-chrome-test-")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := NewDownloadManager(ctx, tab, dir); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	entries, _ := ioutil.ReadDir(dir)
+	if 0 != len(entries) {
+		t.Errorf("Expected staging directory to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestDownloadManagerProgress(t *testing.T) {
+	manager, dir := newTestDownloadManager(t, "TestDownloadManagerProgress")
+	defer os.RemoveAll(dir)
+
+	manager.response(&network.Response{
+		URL:      "https://example.com/report",
+		MimeType: "text/csv",
+		Headers:  network.Headers{"content-length": "5"},
+	})
+	manager.begin(&page.DownloadWillBeginEvent{
+		GUID:              "guid-1",
+		URL:               "https://example.com/report",
+		SuggestedFilename: "report.csv",
+	})
+	download, ok := manager.Download("guid-1")
+	if !ok {
+		t.Fatalf("Expected download guid-1")
+	}
+	if "text/csv" != download.MimeType || 5 != download.Size {
+		t.Errorf("Expected text/csv of 5 bytes, received %s of %d bytes", download.MimeType, download.Size)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(manager.stage, "guid-1"), []byte("a,b,c"), 0600); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	manager.progress(&page.DownloadProgressEvent{
+		GUID:          "guid-1",
+		TotalBytes:    5,
+		ReceivedBytes: 5,
+		State:         page.DownloadState.Completed,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	download, err := manager.Wait(ctx, "guid-1")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if "report.csv" != filepath.Base(download.Path) || download.Dir != filepath.Dir(download.Path) {
+		t.Errorf("Expected report.csv in its own directory, received '%s'", download.Path)
+	}
+	data, _ := ioutil.ReadFile(download.Path)
+	if "a,b,c" != string(data) {
+		t.Errorf("Expected 'a,b,c', received '%s'", data)
+	}
+	if 5 != download.Size {
+		t.Errorf("Expected 5, received %d", download.Size)
+	}
+}
+
+func TestDownloadManagerCanceled(t *testing.T) {
+	manager, dir := newTestDownloadManager(t, "TestDownloadManagerCanceled")
+	defer os.RemoveAll(dir)
+
+	manager.begin(&page.DownloadWillBeginEvent{GUID: "guid-1", URL: "https://example.com/a"})
+	manager.progress(&page.DownloadProgressEvent{GUID: "guid-1", State: page.DownloadState.Canceled})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	download, err := manager.Wait(ctx, "guid-1")
+	if nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if page.DownloadState.Canceled != download.State {
+		t.Errorf("Expected %s, received %s", page.DownloadState.Canceled, download.State)
+	}
+}
+
+func TestDownloadManagerPoll(t *testing.T) {
+	manager, dir := newTestDownloadManager(t, "TestDownloadManagerPoll")
+	defer os.RemoveAll(dir)
+
+	manager.begin(&page.DownloadWillBeginEvent{GUID: "guid-1", SuggestedFilename: "data.bin"})
+	path := filepath.Join(manager.stage, "guid-1")
+	ioutil.WriteFile(path+".crdownload", []byte("abc"), 0600)
+	manager.poll()
+	if download, _ := manager.Download("guid-1"); 3 != download.ReceivedBytes || download.Finished() {
+		t.Errorf("Expected 3 bytes in progress, received %d bytes in state %s", download.ReceivedBytes, download.State)
+	}
+
+	os.Rename(path+".crdownload", path)
+	manager.poll()
+	if download, _ := manager.Download("guid-1"); download.Finished() {
+		t.Errorf("Expected download in progress until its size is stable")
+	}
+	manager.poll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	download, err := manager.WaitFor(ctx, func(download *Download) bool {
+		return "data.bin" == download.SuggestedFilename
+	})
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if "data.bin" != filepath.Base(download.Path) {
+		t.Errorf("Expected data.bin, received '%s'", download.Path)
+	}
+}
+
+func TestDownloadManagerWaitTimeout(t *testing.T) {
+	manager, dir := newTestDownloadManager(t, "TestDownloadManagerWaitTimeout")
+	defer os.RemoveAll(dir)
+
+	manager.begin(&page.DownloadWillBeginEvent{GUID: "guid-1"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := manager.Wait(ctx, "guid-1"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	manager.Close()
+	if _, err := manager.Wait(context.Background(), "guid-1"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if _, err := os.Stat(manager.stage); !os.IsNotExist(err) {
+		t.Errorf("Expected staging directory to be removed")
+	}
+}
+
+func TestDownloadFilename(t *testing.T) {
+	for suggested, expected := range map[string]string{
+		"report.csv":        "report.csv",
+		"../../etc/passwd":  "passwd",
+		`C:\temp\notes.txt`: "notes.txt",
+		"":                  "guid",
+		"..":                "guid",
+		"/":                 "guid",
+	} {
+		if name := downloadFilename(suggested, "guid"); expected != name {
+			t.Errorf("Expected '%s' for '%s', received '%s'", expected, suggested, name)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package chrome
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	errs "github.com/bdlm/errors"
+	"github.com/mkenney/go-chrome/tot/runtime"
+)
+
+/*
+evalObjectGroup is the prefix of the object groups for the temporary remote
+objects created by CallFunction. Each call uses its own group so concurrent
+calls don't release each other's objects.
+*/
+const evalObjectGroup = "go-chrome-eval"
+
+/*
+ExceptionError is returned when evaluated JavaScript throws an exception or
+returns a rejected promise.
+*/
+type ExceptionError struct {
+	// Details contains the exception details reported by Chromium.
+	Details *runtime.ExceptionDetails
+}
+
+/*
+Error implements error. The message is followed by the JavaScript stack trace,
+if one is available.
+*/
+func (err *ExceptionError) Error() string {
+	message := exceptionMessage(err.Details)
+	stack := err.Stack()
+	if "" == stack {
+		return message
+	}
+	return message + "\n" + stack
+}
+
+/*
+Stack returns the JavaScript stack trace of the exception formatted like V8's
+Error.prototype.stack, or an empty string if there is none.
+*/
+func (err *ExceptionError) Stack() string {
+	if stack := formatStackTrace(err.Details.StackTrace); "" != stack {
+		return stack
+	}
+
+	// Fall back to the stack embedded in the description of Error objects.
+	if nil != err.Details.Exception {
+		parts := strings.SplitN(err.Details.Exception.Description, "\n", 2)
+		if 2 == len(parts) {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
+/*
+CallFunction calls a JavaScript function declaration, such as
+"function (a, b) { return a + b }", in the global scope of the page with the
+specified arguments. Promises are awaited and the result is returned by value,
+use UnmarshalRemoteObject to convert it to a Go value. Arguments are converted
+with NewCallArgument.
+
+A JavaScript exception is returned as an *ExceptionError.
+*/
+func (tab *Tab) CallFunction(
+	ctx context.Context,
+	functionDeclaration string,
+	args ...interface{},
+) (*runtime.RemoteObject, error) {
+	return tab.callFunction(ctx, 0, functionDeclaration, args)
+}
+
+/*
+Eval evaluates a JavaScript expression in the global scope of the page and
+unmarshals the result into out. Promises are awaited. NaN, Infinity, -Infinity
+and -0 are converted to the equivalent float64 values.
+
+A JavaScript exception is returned as an *ExceptionError.
+*/
+func (tab *Tab) Eval(ctx context.Context, expression string, out interface{}) error {
+	return tab.eval(ctx, 0, expression, out)
+}
+
+/*
+callFunction calls a JavaScript function declaration in the global scope of an
+execution context, 0 being the default context of the main frame.
+*/
+func (tab *Tab) callFunction(
+	ctx context.Context,
+	contextID runtime.ExecutionContextID,
+	functionDeclaration string,
+	args []interface{},
+) (*runtime.RemoteObject, error) {
+	group := fmt.Sprintf("%s-%d", evalObjectGroup, atomic.AddInt64(&objectGroupCounter, 1))
+	global, err := tab.evaluate(ctx, &runtime.EvaluateParams{
+		Expression:  "this",
+		ObjectGroup: group,
+		ContextID:   contextID,
+	})
+	defer tab.releaseObjectGroup(group)
+	if nil != err {
+		return nil, err
+	}
+
+	return tab.callFunctionOn(ctx, &runtime.CallFunctionOnParams{
+		FunctionDeclaration: functionDeclaration,
+		ObjectID:            global.ObjectID,
+		Arguments:           callArguments(args),
+		ReturnByValue:       true,
+		AwaitPromise:        true,
+	})
+}
+
+/*
+callFunctionOn sends a Runtime.callFunctionOn command and waits for the result.
+*/
+func (tab *Tab) callFunctionOn(
+	ctx context.Context,
+	params *runtime.CallFunctionOnParams,
+) (*runtime.RemoteObject, error) {
+	var result *runtime.CallFunctionOnResult
+	resultChan := tab.Runtime().CallFunctionOn(params)
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "Runtime.callFunctionOn failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Runtime.callFunctionOn failed")
+	}
+	if nil != result.ExceptionDetails {
+		return nil, &ExceptionError{Details: result.ExceptionDetails}
+	}
+	return result.Result, nil
+}
+
+/*
+eval evaluates a JavaScript expression in an execution context, 0 being the
+default context of the main frame, and unmarshals the result into out.
+*/
+func (tab *Tab) eval(
+	ctx context.Context,
+	contextID runtime.ExecutionContextID,
+	expression string,
+	out interface{},
+) error {
+	object, err := tab.evaluate(ctx, &runtime.EvaluateParams{
+		Expression:    expression,
+		ContextID:     contextID,
+		ReturnByValue: true,
+		AwaitPromise:  true,
+	})
+	if nil != err {
+		return err
+	}
+	return UnmarshalRemoteObject(object, out)
+}
+
+/*
+evaluate sends a Runtime.evaluate command and waits for the result.
+*/
+func (tab *Tab) evaluate(
+	ctx context.Context,
+	params *runtime.EvaluateParams,
+) (*runtime.RemoteObject, error) {
+	var result *runtime.EvaluateResult
+	resultChan := tab.Runtime().Evaluate(params)
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "Runtime.evaluate failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Runtime.evaluate failed")
+	}
+	if nil != result.ExceptionDetails {
+		return nil, &ExceptionError{Details: result.ExceptionDetails}
+	}
+	return result.Result, nil
+}
+
+/*
+releaseObject releases a remote object without waiting for the result.
+*/
+func (tab *Tab) releaseObject(objectID runtime.RemoteObjectID) {
+	resultChan := tab.Runtime().ReleaseObject(&runtime.ReleaseObjectParams{
+		ObjectID: objectID,
+	})
+	go func() { <-resultChan }()
+}
+
+/*
+releaseObjectGroup releases all remote objects in an object group without
+waiting for the result.
+*/
+func (tab *Tab) releaseObjectGroup(group string) {
+	resultChan := tab.Runtime().ReleaseObjectGroup(&runtime.ReleaseObjectGroupParams{
+		ObjectGroup: group,
+	})
+	go func() { <-resultChan }()
+}
+
+/*
+NewCallArgument converts a Go value to a Runtime.callFunctionOn argument.
+
+  - nil is passed as undefined
+  - *runtime.RemoteObject and runtime.RemoteObjectID values are passed by
+    reference
+  - NaN, Infinity, -Infinity and -0 are passed as unserializable values
+  - anything else is passed by value and must be JSON serializable
+*/
+func NewCallArgument(value interface{}) *runtime.CallArgument {
+	switch v := value.(type) {
+	case nil:
+		return &runtime.CallArgument{}
+	case *runtime.CallArgument:
+		return v
+	case runtime.RemoteObjectID:
+		return &runtime.CallArgument{ObjectID: v}
+	case *runtime.RemoteObject:
+		if "" != v.ObjectID {
+			return &runtime.CallArgument{ObjectID: v.ObjectID}
+		}
+		return &runtime.CallArgument{
+			Value:               v.Value,
+			UnserializableValue: v.UnserializableValue,
+		}
+	case float32:
+		return numberArgument(float64(v))
+	case float64:
+		return numberArgument(v)
+	}
+	return &runtime.CallArgument{Value: value}
+}
+
+/*
+UnmarshalRemoteObject stores the value of a remote object returned by value in
+the value pointed to by out, following the rules of json.Unmarshal.
+Unserializable numbers can only be stored in floating point or empty interface
+values. An undefined value leaves out unchanged.
+*/
+func UnmarshalRemoteObject(object *runtime.RemoteObject, out interface{}) error {
+	if nil == out {
+		return nil
+	}
+	target := reflect.ValueOf(out)
+	if reflect.Ptr != target.Kind() || target.IsNil() {
+		return errs.New(0, fmt.Sprintf("cannot unmarshal into non-pointer %T", out))
+	}
+	if nil == object || runtime.ObjectType.Undefined == object.Type {
+		return nil
+	}
+
+	if 0 != object.UnserializableValue {
+		number := unserializableFloat(object.UnserializableValue)
+		elem := target.Elem()
+		switch {
+		case reflect.Float32 == elem.Kind() || reflect.Float64 == elem.Kind():
+			elem.SetFloat(number)
+		case reflect.Interface == elem.Kind() && 0 == elem.NumMethod():
+			elem.Set(reflect.ValueOf(number))
+		default:
+			return errs.New(0, fmt.Sprintf("cannot unmarshal %s into %s", object.UnserializableValue, elem.Type()))
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(object.Value)
+	if nil != err {
+		return errs.Wrap(err, 0, "could not marshal remote object value")
+	}
+	if err = json.Unmarshal(data, out); nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("cannot unmarshal %s into %T", object.Type, out))
+	}
+	return nil
+}
+
+/*
+callArguments converts a list of Go values to Runtime.callFunctionOn arguments.
+*/
+func callArguments(args []interface{}) []*runtime.CallArgument {
+	arguments := make([]*runtime.CallArgument, 0, len(args))
+	for _, arg := range args {
+		arguments = append(arguments, NewCallArgument(arg))
+	}
+	return arguments
+}
+
+/*
+numberArgument converts a float to a call argument, using unserializable values
+where JSON can't represent the number.
+*/
+func numberArgument(number float64) *runtime.CallArgument {
+	switch {
+	case math.IsNaN(number):
+		return &runtime.CallArgument{UnserializableValue: runtime.UnserializableValue.NaN}
+	case math.IsInf(number, 1):
+		return &runtime.CallArgument{UnserializableValue: runtime.UnserializableValue.Infinity}
+	case math.IsInf(number, -1):
+		return &runtime.CallArgument{UnserializableValue: runtime.UnserializableValue.NegInfinity}
+	case 0 == number && math.Signbit(number):
+		return &runtime.CallArgument{UnserializableValue: runtime.UnserializableValue.NegZero}
+	}
+	return &runtime.CallArgument{Value: number}
+}
+
+/*
+unserializableFloat returns the float64 equivalent of an unserializable value.
+*/
+func unserializableFloat(value runtime.UnserializableValueEnum) float64 {
+	switch value {
+	case runtime.UnserializableValue.Infinity:
+		return math.Inf(1)
+	case runtime.UnserializableValue.NegInfinity:
+		return math.Inf(-1)
+	case runtime.UnserializableValue.NegZero:
+		return math.Copysign(0, -1)
+	}
+	return math.NaN()
+}
+
+/*
+exceptionMessage returns the exception text followed by the message of the
+thrown value, without its stack.
+*/
+func exceptionMessage(details *runtime.ExceptionDetails) string {
+	message := details.Text
+	if nil != details.Exception {
+		description := details.Exception.Description
+		if "" == description && nil != details.Exception.Value {
+			description = fmt.Sprintf("%v", details.Exception.Value)
+		}
+		if "" != description {
+			// Error descriptions include the stack, keep only the message.
+			message = fmt.Sprintf("%s %s", message, strings.SplitN(description, "\n", 2)[0])
+		}
+	}
+	return message
+}
+
+/*
+formatStackTrace formats a stack trace like V8's Error.prototype.stack,
+including any asynchronous parent traces.
+*/
+func formatStackTrace(stackTrace *runtime.StackTrace) string {
+	var buf bytes.Buffer
+	for trace := stackTrace; nil != trace; trace = trace.Parent {
+		if trace != stackTrace && "" != trace.Description {
+			fmt.Fprintf(&buf, "    -- %s --\n", trace.Description)
+		}
+		for _, frame := range trace.CallFrames {
+			name := frame.FunctionName
+			if "" == name {
+				name = "<anonymous>"
+			}
+			fmt.Fprintf(&buf, "    at %s (%s:%d:%d)\n", name, frame.URL, frame.LineNumber+1, frame.ColumnNumber+1)
+		}
+	}
+	return strings.TrimRight(buf.String(), "\n")
+}
@@ -0,0 +1,196 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/runtime"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+func TestTabEval(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabEval")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var out int
+	if err := tab.Eval(ctx, "1 + 1", &out); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if _, err := tab.CallFunction(ctx, "function (a, b) { return a + b }", 1, 2); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestUnmarshalRemoteObject(t *testing.T) {
+	object := &runtime.RemoteObject{}
+	json.Unmarshal([]byte(`{"type":"object","value":{"a":1,"b":["x","y"]}}`), object)
+	out := struct {
+		A int      `json:"a"`
+		B []string `json:"b"`
+	}{}
+	if err := UnmarshalRemoteObject(object, &out); nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	if 1 != out.A || 2 != len(out.B) || "y" != out.B[1] {
+		t.Errorf("Expected {1 [x y]}, received %v", out)
+	}
+
+	if err := UnmarshalRemoteObject(object, out); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	number := 1.0
+	object = &runtime.RemoteObject{Type: runtime.ObjectType.Number, UnserializableValue: runtime.UnserializableValue.NaN}
+	UnmarshalRemoteObject(object, &number)
+	if !math.IsNaN(number) {
+		t.Errorf("Expected NaN, received %f", number)
+	}
+	object.UnserializableValue = runtime.UnserializableValue.NegInfinity
+	UnmarshalRemoteObject(object, &number)
+	if !math.IsInf(number, -1) {
+		t.Errorf("Expected -Inf, received %f", number)
+	}
+	object.UnserializableValue = runtime.UnserializableValue.NegZero
+	UnmarshalRemoteObject(object, &number)
+	if 0 != number || !math.Signbit(number) {
+		t.Errorf("Expected -0, received %f", number)
+	}
+
+	var value interface{}
+	object.UnserializableValue = runtime.UnserializableValue.Infinity
+	UnmarshalRemoteObject(object, &value)
+	if !math.IsInf(value.(float64), 1) {
+		t.Errorf("Expected +Inf, received %v", value)
+	}
+
+	var str string
+	if err := UnmarshalRemoteObject(object, &str); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	str = "unchanged"
+	UnmarshalRemoteObject(&runtime.RemoteObject{Type: runtime.ObjectType.Undefined}, &str)
+	if "unchanged" != str {
+		t.Errorf("Expected 'unchanged', received '%s'", str)
+	}
+}
+
+func TestNewCallArgument(t *testing.T) {
+	if arg := NewCallArgument(math.NaN()); runtime.UnserializableValue.NaN != arg.UnserializableValue {
+		t.Errorf("Expected NaN, received %s", arg.UnserializableValue)
+	}
+	if arg := NewCallArgument(math.Inf(-1)); runtime.UnserializableValue.NegInfinity != arg.UnserializableValue {
+		t.Errorf("Expected -Infinity, received %s", arg.UnserializableValue)
+	}
+	if arg := NewCallArgument(math.Copysign(0, -1)); runtime.UnserializableValue.NegZero != arg.UnserializableValue {
+		t.Errorf("Expected -0, received %s", arg.UnserializableValue)
+	}
+	if arg := NewCallArgument(runtime.RemoteObjectID("1.2.3")); "1.2.3" != arg.ObjectID {
+		t.Errorf("Expected '1.2.3', received '%s'", arg.ObjectID)
+	}
+	if arg := NewCallArgument("value"); "value" != arg.Value {
+		t.Errorf("Expected 'value', received %v", arg.Value)
+	}
+	data, _ := json.Marshal(NewCallArgument(nil))
+	if "{}" != string(data) {
+		t.Errorf("Expected '{}', received '%s'", data)
+	}
+}
+
+func TestExceptionError(t *testing.T) {
+	err := &ExceptionError{Details: &runtime.ExceptionDetails{
+		Text: "Uncaught",
+		Exception: &runtime.RemoteObject{
+			Type:        runtime.ObjectType.Object,
+			Description: "Error: boom\n    at fail (https://example.com/app.js:10:5)",
+		},
+		StackTrace: &runtime.StackTrace{
+			CallFrames: []*runtime.CallFrame{
+				{FunctionName: "fail", URL: "https://example.com/app.js", LineNumber: 9, ColumnNumber: 4},
+				{URL: "https://example.com/app.js", LineNumber: 20, ColumnNumber: 0},
+			},
+		},
+	}}
+	expected := "Uncaught Error: boom\n" +
+		"    at fail (https://example.com/app.js:10:5)\n" +
+		"    at <anonymous> (https://example.com/app.js:21:1)"
+	if expected != err.Error() {
+		t.Errorf("Expected '%s', received '%s'", expected, err.Error())
+	}
+
+	err.Details.StackTrace = nil
+	if !strings.HasSuffix(err.Error(), "at fail (https://example.com/app.js:10:5)") {
+		t.Errorf("Expected embedded stack trace, received '%s'", err.Error())
+	}
+}
+
+func TestTabCallFunctionObjectGroups(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabCallFunctionObjectGroups")
+	mockSocket := tab.Socket().(*MockSocket)
+	mockSocket.SetResponder(func(command socket.Commander) *socket.Response {
+		switch command.Method() {
+		case "Runtime.evaluate":
+			return &socket.Response{ID: command.ID(), Result: []byte(fmt.Sprintf(`{"result":{"type":"object","objectId":"global-%d"}}`, command.ID()))}
+		case "Runtime.callFunctionOn":
+			return &socket.Response{ID: command.ID(), Result: []byte(`{"result":{"type":"number","value":3}}`)}
+		}
+		return &socket.Response{ID: command.ID(), Result: []byte("{}")}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	for a := 0; a < 10; a++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tab.CallFunction(ctx, "function (a, b) { return a + b }", 1, 2); nil != err {
+				t.Errorf("Expected nil, received '%s'", err)
+			}
+		}()
+	}
+	wg.Wait()
+	// Object groups are released without waiting for the result.
+	for deadline := time.Now().Add(time.Second); 10 > len(mockSocket.Commands("Runtime.releaseObjectGroup")) && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	groups := map[string]bool{}
+	for _, command := range mockSocket.Commands("Runtime.evaluate") {
+		groups[command.Params().(*runtime.EvaluateParams).ObjectGroup] = true
+	}
+	if 10 != len(groups) {
+		t.Errorf("Expected 10 object groups, received %d", len(groups))
+	}
+	for _, command := range mockSocket.Commands("Runtime.releaseObjectGroup") {
+		group := command.Params().(*runtime.ReleaseObjectGroupParams).ObjectGroup
+		if !groups[group] {
+			t.Errorf("Expected group '%s' to be released once", group)
+		}
+		delete(groups, group)
+	}
+	if 0 != len(groups) {
+		t.Errorf("Expected all groups to be released, received %v", groups)
+	}
+}
@@ -0,0 +1,230 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+	"github.com/mkenney/go-chrome/tot/page"
+	"github.com/mkenney/go-chrome/tot/runtime"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+exposedBindingPrefix prefixes the names of the Runtime bindings used by exposed
+functions.
+*/
+const exposedBindingPrefix = "__goChromeBinding_"
+
+/*
+exposedFunctionStub is the page script that defines an exposed function. It is
+formatted with the JSON encoded function and binding names. Calls are sent to
+the binding as a JSON payload and the returned promise is settled by
+exposedFunctionResolve.
+*/
+const exposedFunctionStub = `(function (name, binding) {
+	if (window[name] && window[name].callbacks) {
+		return;
+	}
+	var callbacks = new Map();
+	var seq = 0;
+	window[name] = function () {
+		var args = Array.prototype.slice.call(arguments);
+		return new Promise(function (resolve, reject) {
+			seq++;
+			callbacks.set(seq, {resolve: resolve, reject: reject});
+			window[binding](JSON.stringify({seq: seq, args: args}));
+		});
+	};
+	window[name].callbacks = callbacks;
+})(%s, %s)`
+
+/*
+exposedFunctionResolve settles the promise returned by a call to an exposed
+function.
+*/
+const exposedFunctionResolve = `function (name, seq, message, result) {
+	var callback = window[name].callbacks.get(seq);
+	if (!callback) {
+		return;
+	}
+	window[name].callbacks.delete(seq);
+	if (undefined !== message) {
+		callback.reject(new Error(message));
+	} else {
+		callback.resolve(result);
+	}
+}`
+
+/*
+ExposedFunc is a Go function that can be called from page JavaScript. args
+contains the JSON encoded call arguments. The returned value is JSON encoded
+and resolves the JavaScript promise, a returned error rejects it.
+*/
+type ExposedFunc func(args []json.RawMessage) (interface{}, error)
+
+/*
+exposedCall is the payload sent to the binding by an exposed function.
+*/
+type exposedCall struct {
+	Seq  int               `json:"seq"`
+	Args []json.RawMessage `json:"args"`
+}
+
+/*
+ExposeFunction adds a function named name to the global object of every frame
+in the tab. Calling it from JavaScript calls fn and returns a promise for its
+result:
+
+	tab.ExposeFunction(ctx, "fixture", func(args []json.RawMessage) (interface{}, error) {
+		var name string
+		if err := json.Unmarshal(args[0], &name); nil != err {
+			return nil, err
+		}
+		return fixtures[name], nil
+	})
+
+	// page JavaScript
+	const user = await window.fixture("user");
+
+The function is defined in the current document of the main frame and in every
+document loaded afterwards, in any frame. Child frames that already exist get
+the function when they next navigate. Calls are delivered by a Runtime
+binding and fn is called in its own goroutine. A name can only be exposed once
+per tab.
+*/
+func (tab *Tab) ExposeFunction(ctx context.Context, name string, fn ExposedFunc) error {
+	if "" == name {
+		return errs.New(0, "function name is required")
+	}
+	tab.mux.Lock()
+	if tab.exposed[name] {
+		tab.mux.Unlock()
+		return errs.New(0, fmt.Sprintf("function '%s' is already exposed", name))
+	}
+	if nil == tab.exposed {
+		tab.exposed = map[string]bool{}
+	}
+	tab.exposed[name] = true
+	tab.mux.Unlock()
+	binding := exposedBindingPrefix + name
+
+	handler := socket.NewEventHandler(
+		"Runtime.bindingCalled",
+		func(response *socket.Response) {
+			event := &runtime.BindingCalledEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil != err {
+				return
+			}
+			if binding != event.Name {
+				return
+			}
+			go tab.callExposedFunction(name, fn, event)
+		},
+	)
+	tab.AddEventHandler(handler)
+
+	err := tab.exposeFunction(ctx, name, binding)
+	if nil != err {
+		tab.RemoveEventHandler(handler)
+		tab.mux.Lock()
+		delete(tab.exposed, name)
+		tab.mux.Unlock()
+	}
+	return err
+}
+
+/*
+callExposedFunction calls an exposed function with the arguments of a binding
+call and settles the JavaScript promise with the result.
+*/
+func (tab *Tab) callExposedFunction(name string, fn ExposedFunc, event *runtime.BindingCalledEvent) {
+	call := &exposedCall{}
+	if err := json.Unmarshal([]byte(event.Payload), call); nil != err {
+		log.WithFields(log.Fields{
+			"function": name,
+			"payload":  event.Payload,
+			"error":    err,
+		}).Warn("invalid exposed function payload")
+		return
+	}
+
+	var message interface{}
+	result := json.RawMessage("null")
+	value, err := fn(call.Args)
+	if nil == err {
+		result, err = json.Marshal(value)
+	}
+	if nil != err {
+		message = err.Error()
+		result = json.RawMessage("null")
+	}
+
+	resultChan := tab.Runtime().CallFunctionOn(&runtime.CallFunctionOnParams{
+		FunctionDeclaration: exposedFunctionResolve,
+		ExecutionContextID:  event.ExecutionContextID,
+		Arguments: []*runtime.CallArgument{
+			{Value: name},
+			{Value: call.Seq},
+			NewCallArgument(message),
+			{Value: result},
+		},
+	})
+	if response := <-resultChan; nil != response.Err {
+		// The context may have been destroyed while fn was running.
+		log.WithFields(log.Fields{
+			"function": name,
+			"error":    response.Err,
+		}).Debug("could not deliver exposed function result")
+	}
+}
+
+/*
+exposeFunction adds the binding for an exposed function and installs the
+function in the current and future documents.
+*/
+func (tab *Tab) exposeFunction(ctx context.Context, name, binding string) error {
+	runtimeChan := tab.Runtime().Enable()
+	select {
+	case result := <-runtimeChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Runtime.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-runtimeChan }()
+		return errs.Wrap(ctx.Err(), 0, "Runtime.enable failed")
+	}
+
+	bindingChan := tab.Runtime().AddBinding(&runtime.AddBindingParams{Name: binding})
+	select {
+	case result := <-bindingChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Runtime.addBinding failed")
+		}
+	case <-ctx.Done():
+		go func() { <-bindingChan }()
+		return errs.Wrap(ctx.Err(), 0, "Runtime.addBinding failed")
+	}
+
+	nameJSON, _ := json.Marshal(name)
+	bindingJSON, _ := json.Marshal(binding)
+	stub := fmt.Sprintf(exposedFunctionStub, nameJSON, bindingJSON)
+
+	scriptChan := tab.Page().AddScriptToEvaluateOnNewDocument(&page.AddScriptToEvaluateOnNewDocumentParams{
+		Source: stub,
+	})
+	select {
+	case result := <-scriptChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Page.addScriptToEvaluateOnNewDocument failed")
+		}
+	case <-ctx.Done():
+		go func() { <-scriptChan }()
+		return errs.Wrap(ctx.Err(), 0, "Page.addScriptToEvaluateOnNewDocument failed")
+	}
+
+	_, err := tab.evaluate(ctx, &runtime.EvaluateParams{Expression: stub})
+	return err
+}
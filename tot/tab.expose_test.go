@@ -0,0 +1,90 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/runtime"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+func TestTabExposeFunction(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabExposeFunction")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	fn := func(args []json.RawMessage) (interface{}, error) {
+		return len(args), nil
+	}
+	if err := tab.ExposeFunction(ctx, "", fn); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if err := tab.ExposeFunction(ctx, "count", fn); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestTabExposeFunctionCall(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabExposeFunctionCall")
+	mockSocket := tab.Socket().(*MockSocket)
+	mockSocket.SetResponder(func(command socket.Commander) *socket.Response {
+		return &socket.Response{ID: command.ID(), Result: []byte(`{"result":{"type":"undefined"}}`)}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	calls := make(chan []json.RawMessage, 10)
+	fn := func(args []json.RawMessage) (interface{}, error) {
+		calls <- args
+		return len(args), nil
+	}
+	if err := tab.ExposeFunction(ctx, "count", fn); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if err := tab.ExposeFunction(ctx, "count", fn); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	mockSocket.Emit("Runtime.bindingCalled", &runtime.BindingCalledEvent{
+		Name:               exposedBindingPrefix + "count",
+		Payload:            `{"seq":1,"args":["a","b"]}`,
+		ExecutionContextID: 1,
+	})
+	select {
+	case args := <-calls:
+		if 2 != len(args) {
+			t.Errorf("Expected 2 arguments, received %d", len(args))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the exposed function to be called")
+	}
+	for deadline := time.Now().Add(time.Second); 0 == len(mockSocket.Commands("Runtime.callFunctionOn")) && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if 0 != len(calls) {
+		t.Errorf("Expected a single call, received %d more", len(calls))
+	}
+	if resolved := mockSocket.Commands("Runtime.callFunctionOn"); 1 != len(resolved) {
+		t.Errorf("Expected a single resolution, received %d", len(resolved))
+	}
+	if bindings := mockSocket.Commands("Runtime.addBinding"); 1 != len(bindings) {
+		t.Errorf("Expected a single binding, received %d", len(bindings))
+	}
+}
@@ -0,0 +1,599 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	errs "github.com/bdlm/errors"
+	"github.com/mkenney/go-chrome/tot/page"
+	"github.com/mkenney/go-chrome/tot/runtime"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+NewFrameRegistry returns a FrameRegistry for a tab. The Page and Runtime
+domains are enabled and the registry is seeded from Page.getFrameTree, after
+which it is kept in sync with the frame and execution context events until
+Close is called.
+*/
+func NewFrameRegistry(ctx context.Context, tab *Tab) (*FrameRegistry, error) {
+	registry := newFrameRegistry(tab)
+
+	registry.handlers = []socket.EventHandler{
+		socket.NewEventHandler("Page.frameAttached", func(response *socket.Response) {
+			event := &page.FrameAttachedEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err {
+				registry.attached(event.FrameID, event.ParentFrameID)
+			}
+		}),
+		socket.NewEventHandler("Page.frameNavigated", func(response *socket.Response) {
+			event := &page.FrameNavigatedEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err && nil != event.Frame {
+				registry.navigated(event.Frame)
+			}
+		}),
+		socket.NewEventHandler("Page.frameDetached", func(response *socket.Response) {
+			event := &page.FrameDetachedEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err {
+				registry.detached(event.FrameID)
+			}
+		}),
+		socket.NewEventHandler("Runtime.executionContextCreated", func(response *socket.Response) {
+			event := &runtime.ExecutionContextCreatedEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err && nil != event.Context {
+				registry.contextCreated(event.Context)
+			}
+		}),
+		socket.NewEventHandler("Runtime.executionContextDestroyed", func(response *socket.Response) {
+			event := &runtime.ExecutionContextDestroyedEvent{}
+			if err := json.Unmarshal([]byte(response.Params), event); nil == err {
+				registry.contextDestroyed(event.ExecutionContextID)
+			}
+		}),
+		socket.NewEventHandler("Runtime.executionContextsCleared", func(response *socket.Response) {
+			registry.contextsCleared()
+		}),
+	}
+	for _, handler := range registry.handlers {
+		tab.AddEventHandler(handler)
+	}
+
+	// Runtime.enable reports the existing execution contexts, so it is
+	// enabled after the frame tree is known.
+	pageChan := tab.Page().Enable()
+	select {
+	case result := <-pageChan:
+		if nil != result.Err {
+			registry.Close()
+			return nil, errs.Wrap(result.Err, 0, "Page.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-pageChan }()
+		registry.Close()
+		return nil, errs.Wrap(ctx.Err(), 0, "Page.enable failed")
+	}
+
+	treeChan := tab.Page().GetFrameTree()
+	select {
+	case result := <-treeChan:
+		if nil != result.Err {
+			registry.Close()
+			return nil, errs.Wrap(result.Err, 0, "Page.getFrameTree failed")
+		}
+		registry.seed(result.FrameTree)
+	case <-ctx.Done():
+		go func() { <-treeChan }()
+		registry.Close()
+		return nil, errs.Wrap(ctx.Err(), 0, "Page.getFrameTree failed")
+	}
+
+	runtimeChan := tab.Runtime().Enable()
+	select {
+	case result := <-runtimeChan:
+		if nil != result.Err {
+			registry.Close()
+			return nil, errs.Wrap(result.Err, 0, "Runtime.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-runtimeChan }()
+		registry.Close()
+		return nil, errs.Wrap(ctx.Err(), 0, "Runtime.enable failed")
+	}
+
+	return registry, nil
+}
+
+/*
+newFrameRegistry returns an empty frame registry that isn't listening for frame
+events.
+*/
+func newFrameRegistry(tab *Tab) *FrameRegistry {
+	return &FrameRegistry{
+		frames: make(map[page.FrameID]*Frame),
+		mux:    &sync.Mutex{},
+		tab:    tab,
+	}
+}
+
+/*
+FrameRegistry tracks the frames of a tab and the execution contexts that belong
+to them.
+*/
+type FrameRegistry struct {
+	// frames holds the attached frames by ID.
+	frames map[page.FrameID]*Frame
+
+	// handlers holds the event handlers registered by the registry.
+	handlers []socket.EventHandler
+
+	// main is the main frame of the tab.
+	main *Frame
+
+	// mux protects the registry and the state of its frames.
+	mux *sync.Mutex
+
+	// tab is the tab the frames belong to.
+	tab *Tab
+}
+
+/*
+Close stops tracking frame and execution context events.
+*/
+func (registry *FrameRegistry) Close() {
+	for _, handler := range registry.handlers {
+		registry.tab.RemoveEventHandler(handler)
+	}
+	registry.handlers = nil
+}
+
+/*
+Frame returns the attached frame with the specified ID, or nil.
+*/
+func (registry *FrameRegistry) Frame(frameID page.FrameID) *Frame {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	return registry.frames[frameID]
+}
+
+/*
+FrameByName returns the first attached frame with the specified name, or nil.
+*/
+func (registry *FrameRegistry) FrameByName(name string) *Frame {
+	for _, frame := range registry.Frames() {
+		if name == frame.Name() {
+			return frame
+		}
+	}
+	return nil
+}
+
+/*
+Frames returns all attached frames, parents before their children.
+*/
+func (registry *FrameRegistry) Frames() []*Frame {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	frames := []*Frame{}
+	if nil != registry.main {
+		frames = registry.main.appendTree(frames)
+	}
+	return frames
+}
+
+/*
+MainFrame returns the main frame of the tab.
+*/
+func (registry *FrameRegistry) MainFrame() *Frame {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	return registry.main
+}
+
+/*
+attached adds a new child frame.
+*/
+func (registry *FrameRegistry) attached(frameID, parentID page.FrameID) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	frame := registry.frame(frameID)
+	if parent, ok := registry.frames[parentID]; ok && frame.parent != parent {
+		frame.parent = parent
+		parent.children = append(parent.children, frame)
+	}
+}
+
+/*
+contextCreated records an execution context in the frame it belongs to.
+Contexts that don't belong to a frame, such as worker contexts, are ignored.
+*/
+func (registry *FrameRegistry) contextCreated(description *runtime.ExecutionContextDescription) {
+	frameID, _ := description.AuxData["frameId"].(string)
+	if "" == frameID {
+		return
+	}
+	world := description.Name
+	if isDefault, _ := description.AuxData["isDefault"].(bool); isDefault {
+		world = ""
+	}
+
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	frame := registry.frame(page.FrameID(frameID))
+	frame.contexts[world] = description.ID
+	frame.changed()
+}
+
+/*
+contextDestroyed removes an execution context from the frame it belongs to.
+*/
+func (registry *FrameRegistry) contextDestroyed(contextID runtime.ExecutionContextID) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	for _, frame := range registry.frames {
+		for world, id := range frame.contexts {
+			if id == contextID {
+				delete(frame.contexts, world)
+				frame.changed()
+				return
+			}
+		}
+	}
+}
+
+/*
+contextsCleared removes all execution contexts.
+*/
+func (registry *FrameRegistry) contextsCleared() {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	for _, frame := range registry.frames {
+		if 0 != len(frame.contexts) {
+			frame.contexts = make(map[string]runtime.ExecutionContextID)
+			frame.changed()
+		}
+	}
+}
+
+/*
+detached removes a frame and its descendants.
+*/
+func (registry *FrameRegistry) detached(frameID page.FrameID) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	frame, ok := registry.frames[frameID]
+	if !ok {
+		return
+	}
+	if nil != frame.parent {
+		frame.parent.removeChild(frame)
+	}
+	registry.remove(frame)
+}
+
+/*
+frame returns the frame with the specified ID, creating it if it isn't known
+yet. The caller must hold the registry lock.
+*/
+func (registry *FrameRegistry) frame(frameID page.FrameID) *Frame {
+	frame, ok := registry.frames[frameID]
+	if !ok {
+		frame = &Frame{
+			contexts: make(map[string]runtime.ExecutionContextID),
+			change:   make(chan struct{}),
+			id:       frameID,
+			registry: registry,
+		}
+		registry.frames[frameID] = frame
+	}
+	return frame
+}
+
+/*
+navigated updates a frame after it committed a navigation. Child frames belong
+to the previous document and are removed.
+*/
+func (registry *FrameRegistry) navigated(data *page.Frame) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	frame := registry.update(data)
+	for _, child := range frame.children {
+		registry.remove(child)
+	}
+	frame.children = nil
+}
+
+/*
+remove removes a frame and its descendants from the registry. The caller must
+hold the registry lock.
+*/
+func (registry *FrameRegistry) remove(frame *Frame) {
+	for _, child := range frame.children {
+		registry.remove(child)
+	}
+	frame.children = nil
+	frame.detached = true
+	frame.changed()
+	delete(registry.frames, frame.id)
+}
+
+/*
+seed adds a frame tree to the registry.
+*/
+func (registry *FrameRegistry) seed(tree *page.FrameTree) {
+	if nil == tree || nil == tree.Frame {
+		return
+	}
+	registry.mux.Lock()
+	registry.update(tree.Frame)
+	registry.mux.Unlock()
+	for _, child := range tree.ChildFrames {
+		registry.seed(child)
+	}
+}
+
+/*
+update applies frame data to the registry and returns the frame. The caller
+must hold the registry lock.
+*/
+func (registry *FrameRegistry) update(data *page.Frame) *Frame {
+	frame := registry.frame(page.FrameID(data.ID))
+	frame.name = data.Name
+	frame.url = data.URL
+
+	if "" == data.ParentID {
+		// Cross-process navigations can replace the main frame.
+		if nil != registry.main && registry.main != frame {
+			registry.remove(registry.main)
+		}
+		registry.main = frame
+		return frame
+	}
+	if parent, ok := registry.frames[page.FrameID(data.ParentID)]; ok && frame.parent != parent {
+		if nil != frame.parent {
+			frame.parent.removeChild(frame)
+		}
+		frame.parent = parent
+		parent.children = append(parent.children, frame)
+	}
+	return frame
+}
+
+/*
+Frame is a frame in a tab. JavaScript is evaluated in the frame's main world
+unless an isolated world is requested.
+*/
+type Frame struct {
+	// change is closed and replaced whenever the frame's contexts or state
+	// change.
+	change chan struct{}
+
+	// children holds the attached child frames.
+	children []*Frame
+
+	// contexts maps world names to execution contexts. The main world has an
+	// empty name.
+	contexts map[string]runtime.ExecutionContextID
+
+	// detached is set when the frame has been detached.
+	detached bool
+
+	// id is the frame ID.
+	id page.FrameID
+
+	// name is the frame name.
+	name string
+
+	// parent is the parent frame, nil for the main frame.
+	parent *Frame
+
+	// registry is the registry tracking the frame.
+	registry *FrameRegistry
+
+	// url is the frame document URL.
+	url string
+}
+
+/*
+CallFunction calls a JavaScript function declaration in the global scope of
+the frame's main world. See Tab.CallFunction.
+*/
+func (frame *Frame) CallFunction(
+	ctx context.Context,
+	functionDeclaration string,
+	args ...interface{},
+) (*runtime.RemoteObject, error) {
+	contextID, err := frame.ExecutionContext(ctx)
+	if nil != err {
+		return nil, err
+	}
+	return frame.registry.tab.callFunction(ctx, contextID, functionDeclaration, args)
+}
+
+/*
+ChildFrames returns the attached child frames.
+*/
+func (frame *Frame) ChildFrames() []*Frame {
+	frame.registry.mux.Lock()
+	defer frame.registry.mux.Unlock()
+	return append([]*Frame{}, frame.children...)
+}
+
+/*
+Detached returns true if the frame has been detached.
+*/
+func (frame *Frame) Detached() bool {
+	frame.registry.mux.Lock()
+	defer frame.registry.mux.Unlock()
+	return frame.detached
+}
+
+/*
+Eval evaluates a JavaScript expression in the frame's main world and
+unmarshals the result into out. See Tab.Eval.
+*/
+func (frame *Frame) Eval(ctx context.Context, expression string, out interface{}) error {
+	contextID, err := frame.ExecutionContext(ctx)
+	if nil != err {
+		return err
+	}
+	return frame.registry.tab.eval(ctx, contextID, expression, out)
+}
+
+/*
+ExecutionContext returns the main world execution context of the frame,
+waiting for it to be created if the frame is navigating.
+*/
+func (frame *Frame) ExecutionContext(ctx context.Context) (runtime.ExecutionContextID, error) {
+	return frame.world(ctx, "")
+}
+
+/*
+ID returns the frame ID.
+*/
+func (frame *Frame) ID() page.FrameID {
+	return frame.id
+}
+
+/*
+IsolatedWorld returns the execution context of the named isolated world in the
+frame, creating it with Page.createIsolatedWorld if it doesn't exist. Isolated
+worlds share the DOM with the page but not its JavaScript globals, and are
+destroyed when the frame navigates.
+*/
+func (frame *Frame) IsolatedWorld(ctx context.Context, name string) (runtime.ExecutionContextID, error) {
+	if "" == name {
+		return 0, errs.New(0, "isolated world name is required")
+	}
+	frame.registry.mux.Lock()
+	contextID, ok := frame.contexts[name]
+	detached := frame.detached
+	frame.registry.mux.Unlock()
+	if detached {
+		return 0, errs.New(0, fmt.Sprintf("frame %s is detached", frame.id))
+	}
+	if ok {
+		return contextID, nil
+	}
+
+	var result *page.CreateIsolatedWorldResult
+	resultChan := frame.registry.tab.Page().CreateIsolatedWorld(&page.CreateIsolatedWorldParams{
+		FrameID:             frame.id,
+		WorldName:           name,
+		GrantUniveralAccess: true,
+	})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return 0, errs.Wrap(ctx.Err(), 0, "Page.createIsolatedWorld failed")
+	}
+	if nil != result.Err {
+		return 0, errs.Wrap(result.Err, 0, "Page.createIsolatedWorld failed")
+	}
+
+	frame.registry.mux.Lock()
+	frame.contexts[name] = result.ExecutionContextID
+	frame.changed()
+	frame.registry.mux.Unlock()
+	return result.ExecutionContextID, nil
+}
+
+/*
+Name returns the frame name.
+*/
+func (frame *Frame) Name() string {
+	frame.registry.mux.Lock()
+	defer frame.registry.mux.Unlock()
+	return frame.name
+}
+
+/*
+NewObjectGroup returns an object group scoped to the frame's main world
+execution context. See Tab.NewObjectGroup.
+*/
+func (frame *Frame) NewObjectGroup(ctx context.Context) (*ObjectGroup, error) {
+	contextID, err := frame.ExecutionContext(ctx)
+	if nil != err {
+		return nil, err
+	}
+	return frame.registry.tab.NewObjectGroup(ctx, contextID)
+}
+
+/*
+Parent returns the parent frame, or nil for the main frame.
+*/
+func (frame *Frame) Parent() *Frame {
+	frame.registry.mux.Lock()
+	defer frame.registry.mux.Unlock()
+	return frame.parent
+}
+
+/*
+URL returns the URL of the frame document.
+*/
+func (frame *Frame) URL() string {
+	frame.registry.mux.Lock()
+	defer frame.registry.mux.Unlock()
+	return frame.url
+}
+
+/*
+appendTree appends the frame and its descendants to frames. The caller must
+hold the registry lock.
+*/
+func (frame *Frame) appendTree(frames []*Frame) []*Frame {
+	frames = append(frames, frame)
+	for _, child := range frame.children {
+		frames = child.appendTree(frames)
+	}
+	return frames
+}
+
+/*
+changed wakes up goroutines waiting for the frame's state to change. The
+caller must hold the registry lock.
+*/
+func (frame *Frame) changed() {
+	close(frame.change)
+	frame.change = make(chan struct{})
+}
+
+/*
+removeChild removes a child frame. The caller must hold the registry lock.
+*/
+func (frame *Frame) removeChild(child *Frame) {
+	for i, c := range frame.children {
+		if c == child {
+			frame.children = append(frame.children[:i], frame.children[i+1:]...)
+			return
+		}
+	}
+}
+
+/*
+world returns the execution context of the named world, waiting for it to be
+created.
+*/
+func (frame *Frame) world(ctx context.Context, name string) (runtime.ExecutionContextID, error) {
+	for {
+		frame.registry.mux.Lock()
+		contextID, ok := frame.contexts[name]
+		detached := frame.detached
+		change := frame.change
+		frame.registry.mux.Unlock()
+
+		if ok {
+			return contextID, nil
+		}
+		if detached {
+			return 0, errs.New(0, fmt.Sprintf("frame %s is detached", frame.id))
+		}
+		select {
+		case <-change:
+		case <-ctx.Done():
+			return 0, errs.Wrap(ctx.Err(), 0, fmt.Sprintf("no execution context for frame %s", frame.id))
+		}
+	}
+}
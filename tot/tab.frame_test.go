@@ -0,0 +1,137 @@
+package chrome
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/page"
+	"github.com/mkenney/go-chrome/tot/runtime"
+)
+
+func newTestFrameRegistry(tab *Tab) *FrameRegistry {
+	registry := newFrameRegistry(tab)
+	registry.seed(&page.FrameTree{
+		Frame: &page.Frame{ID: "main", URL: "https://example.com/"},
+		ChildFrames: []*page.FrameTree{
+			{Frame: &page.Frame{ID: "child", ParentID: "main", Name: "ad", URL: "https://example.com/ad"}},
+		},
+	})
+	return registry
+}
+
+func TestNewFrameRegistry(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestNewFrameRegistry")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := NewFrameRegistry(ctx, tab); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestFrameRegistryTree(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestFrameRegistryTree")
+	registry := newTestFrameRegistry(tab)
+
+	if "main" != registry.MainFrame().ID() {
+		t.Errorf("Expected main frame 'main', received '%s'", registry.MainFrame().ID())
+	}
+	child := registry.FrameByName("ad")
+	if nil == child || registry.MainFrame() != child.Parent() {
+		t.Errorf("Expected child frame of the main frame, received %v", child)
+	}
+
+	registry.attached("grandchild", "child")
+	if 3 != len(registry.Frames()) {
+		t.Errorf("Expected 3 frames, received %d", len(registry.Frames()))
+	}
+
+	registry.detached("child")
+	if 1 != len(registry.Frames()) {
+		t.Errorf("Expected 1 frame, received %d", len(registry.Frames()))
+	}
+	if !child.Detached() || nil != registry.Frame("grandchild") {
+		t.Errorf("Expected the child frames to be detached")
+	}
+
+	registry.attached("child2", "main")
+	registry.navigated(&page.Frame{ID: "main", URL: "https://example.com/next"})
+	if "https://example.com/next" != registry.MainFrame().URL() {
+		t.Errorf("Expected the main frame URL to be updated, received '%s'", registry.MainFrame().URL())
+	}
+	if 0 != len(registry.MainFrame().ChildFrames()) {
+		t.Errorf("Expected navigation to remove child frames")
+	}
+}
+
+func TestFrameExecutionContext(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestFrameExecutionContext")
+	registry := newTestFrameRegistry(tab)
+	child := registry.Frame("child")
+
+	result := make(chan runtime.ExecutionContextID)
+	go func() {
+		contextID, _ := child.ExecutionContext(context.Background())
+		result <- contextID
+	}()
+	registry.contextCreated(&runtime.ExecutionContextDescription{
+		ID:      7,
+		Name:    "isolated",
+		AuxData: map[string]interface{}{"frameId": "child", "isDefault": false, "type": "isolated"},
+	})
+	registry.contextCreated(&runtime.ExecutionContextDescription{
+		ID:      5,
+		AuxData: map[string]interface{}{"frameId": "child", "isDefault": true, "type": "default"},
+	})
+	select {
+	case contextID := <-result:
+		if 5 != contextID {
+			t.Errorf("Expected context 5, received %d", contextID)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the main world context to be reported")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if contextID, err := child.IsolatedWorld(ctx, "isolated"); 7 != contextID || nil != err {
+		t.Errorf("Expected context 7, received %d: %v", contextID, err)
+	}
+
+	registry.contextDestroyed(5)
+	if _, err := child.ExecutionContext(ctx); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	registry.contextsCleared()
+	if _, err := child.IsolatedWorld(ctx, "isolated"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	registry.detached("child")
+	if _, err := child.ExecutionContext(context.Background()); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
@@ -0,0 +1,483 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	errs "github.com/bdlm/errors"
+	"github.com/mkenney/go-chrome/tot/dom"
+	"github.com/mkenney/go-chrome/tot/runtime"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+objectGroupCounter is used to generate unique object group names.
+*/
+var objectGroupCounter int64
+
+/*
+NewObjectGroup returns an object group scoped to the specified execution
+context. A contextID of 0 scopes the group to the current navigation of the
+page: the default context of the main frame is used and the group is disposed
+when the page navigates.
+
+All handles created by the group are released when the group is disposed,
+either explicitly with Dispose or automatically when its execution context is
+destroyed. The Runtime domain is enabled so that context events are delivered.
+*/
+func (tab *Tab) NewObjectGroup(
+	ctx context.Context,
+	contextID runtime.ExecutionContextID,
+) (*ObjectGroup, error) {
+	group := newObjectGroup(tab, contextID, fmt.Sprintf("go-chrome-%d", atomic.AddInt64(&objectGroupCounter, 1)))
+
+	group.handlers = []socket.EventHandler{
+		socket.NewEventHandler(
+			"Runtime.executionContextsCleared",
+			func(response *socket.Response) {
+				// Chromium has already released the objects.
+				group.dispose()
+			},
+		),
+	}
+	if 0 != contextID {
+		group.handlers = append(group.handlers, socket.NewEventHandler(
+			"Runtime.executionContextDestroyed",
+			func(response *socket.Response) {
+				event := &runtime.ExecutionContextDestroyedEvent{}
+				if err := json.Unmarshal([]byte(response.Params), event); nil != err {
+					return
+				}
+				if event.ExecutionContextID == group.contextID {
+					group.dispose()
+				}
+			},
+		))
+	}
+	for _, handler := range group.handlers {
+		tab.AddEventHandler(handler)
+	}
+
+	resultChan := tab.Runtime().Enable()
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			group.removeHandlers()
+			return nil, errs.Wrap(result.Err, 0, "Runtime.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		group.removeHandlers()
+		return nil, errs.Wrap(ctx.Err(), 0, "Runtime.enable failed")
+	}
+
+	return group, nil
+}
+
+/*
+newObjectGroup returns an object group with the specified name that isn't
+listening for context events.
+*/
+func newObjectGroup(tab *Tab, contextID runtime.ExecutionContextID, name string) *ObjectGroup {
+	return &ObjectGroup{
+		contextID: contextID,
+		done:      make(chan struct{}),
+		handles:   make(map[runtime.RemoteObjectID]*JSHandle),
+		mux:       &sync.Mutex{},
+		name:      name,
+		tab:       tab,
+	}
+}
+
+/*
+ObjectGroup owns the remote objects created through it. Chromium keeps remote
+objects alive until they are released, so handles should always be created
+through a group that is disposed when they are no longer needed.
+*/
+type ObjectGroup struct {
+	// contextID is the execution context the group is scoped to, or 0 for the
+	// main frame's current navigation.
+	contextID runtime.ExecutionContextID
+
+	// disposed is set when the group has been released.
+	disposed bool
+
+	// done is closed when the group is disposed.
+	done chan struct{}
+
+	// handles holds the live handles in the group.
+	handles map[runtime.RemoteObjectID]*JSHandle
+
+	// handlers holds the context event handlers registered by the group.
+	handlers []socket.EventHandler
+
+	// mux protects disposed and handles.
+	mux *sync.Mutex
+
+	// name is the object group name sent to Chromium.
+	name string
+
+	// tab is the tab the group belongs to.
+	tab *Tab
+}
+
+/*
+CallFunction calls a JavaScript function declaration in the global scope of the
+group's execution context and returns a handle to the result. Arguments are
+converted with NewCallArgument.
+*/
+func (group *ObjectGroup) CallFunction(
+	ctx context.Context,
+	functionDeclaration string,
+	args ...interface{},
+) (*JSHandle, error) {
+	global, err := group.Evaluate(ctx, "this")
+	if nil != err {
+		return nil, err
+	}
+	return global.CallFunction(ctx, functionDeclaration, args...)
+}
+
+/*
+ContextID returns the execution context the group is scoped to, or 0 if it is
+scoped to the current navigation.
+*/
+func (group *ObjectGroup) ContextID() runtime.ExecutionContextID {
+	return group.contextID
+}
+
+/*
+Dispose releases all remote objects in the group. Disposing a group more than
+once has no effect.
+*/
+func (group *ObjectGroup) Dispose() {
+	if group.dispose() {
+		group.tab.releaseObjectGroup(group.name)
+	}
+}
+
+/*
+Disposed returns true if the group has been disposed.
+*/
+func (group *ObjectGroup) Disposed() bool {
+	group.mux.Lock()
+	defer group.mux.Unlock()
+	return group.disposed
+}
+
+/*
+Done returns a channel that is closed when the group is disposed.
+*/
+func (group *ObjectGroup) Done() <-chan struct{} {
+	return group.done
+}
+
+/*
+Evaluate evaluates a JavaScript expression in the group's execution context and
+returns a handle to the result. Promises are awaited.
+*/
+func (group *ObjectGroup) Evaluate(ctx context.Context, expression string) (*JSHandle, error) {
+	if group.Disposed() {
+		return nil, errs.New(0, "object group is disposed")
+	}
+	object, err := group.tab.evaluate(ctx, &runtime.EvaluateParams{
+		Expression:   expression,
+		ObjectGroup:  group.name,
+		ContextID:    group.contextID,
+		AwaitPromise: true,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return group.handle(object), nil
+}
+
+/*
+Name returns the object group name used by Chromium.
+*/
+func (group *ObjectGroup) Name() string {
+	return group.name
+}
+
+/*
+ResolveNode returns a handle to the JavaScript object for a DOM node.
+*/
+func (group *ObjectGroup) ResolveNode(ctx context.Context, nodeID dom.NodeID) (*JSHandle, error) {
+	if group.Disposed() {
+		return nil, errs.New(0, "object group is disposed")
+	}
+	var result *dom.ResolveNodeResult
+	resultChan := group.tab.DOM().ResolveNode(&dom.ResolveNodeParams{
+		NodeID:      nodeID,
+		ObjectGroup: group.name,
+	})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "DOM.resolveNode failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "DOM.resolveNode failed")
+	}
+	return group.handle(result.Object), nil
+}
+
+/*
+dispose marks the group and its handles as disposed and removes the event
+handlers. It returns false if the group was already disposed.
+*/
+func (group *ObjectGroup) dispose() bool {
+	group.mux.Lock()
+	if group.disposed {
+		group.mux.Unlock()
+		return false
+	}
+	group.disposed = true
+	handles := group.handles
+	group.handles = make(map[runtime.RemoteObjectID]*JSHandle)
+	close(group.done)
+	group.mux.Unlock()
+
+	for _, handle := range handles {
+		handle.markDisposed()
+	}
+	// Event handlers may be running in the socket's event loop, don't block
+	// it while removing them.
+	go group.removeHandlers()
+	return true
+}
+
+/*
+handle wraps a remote object in a JSHandle owned by the group.
+*/
+func (group *ObjectGroup) handle(object *runtime.RemoteObject) *JSHandle {
+	if nil == object {
+		object = &runtime.RemoteObject{Type: runtime.ObjectType.Undefined}
+	}
+	handle := &JSHandle{
+		group:  group,
+		mux:    &sync.Mutex{},
+		object: object,
+	}
+	if "" == object.ObjectID {
+		return handle
+	}
+
+	group.mux.Lock()
+	defer group.mux.Unlock()
+	if group.disposed {
+		handle.disposed = true
+		return handle
+	}
+	group.handles[object.ObjectID] = handle
+	return handle
+}
+
+/*
+removeHandlers removes the context event handlers registered by the group.
+*/
+func (group *ObjectGroup) removeHandlers() {
+	for _, handler := range group.handlers {
+		group.tab.RemoveEventHandler(handler)
+	}
+}
+
+/*
+JSHandle is a reference to a JavaScript value in the page. Primitive values are
+held locally, objects are held by Chromium until the handle or its group is
+disposed.
+*/
+type JSHandle struct {
+	// disposed is set when the remote object has been released.
+	disposed bool
+
+	// group is the object group that owns the handle.
+	group *ObjectGroup
+
+	// mux protects disposed.
+	mux *sync.Mutex
+
+	// object is the remote object.
+	object *runtime.RemoteObject
+}
+
+/*
+CallFunction calls a JavaScript function declaration with the handle's value
+as `this` and returns a handle to the result in the same object group.
+Promises are awaited.
+*/
+func (handle *JSHandle) CallFunction(
+	ctx context.Context,
+	functionDeclaration string,
+	args ...interface{},
+) (*JSHandle, error) {
+	params, err := handle.callParams(functionDeclaration, args)
+	if nil != err {
+		return nil, err
+	}
+	params.ObjectGroup = handle.group.name
+	object, err := handle.group.tab.callFunctionOn(ctx, params)
+	if nil != err {
+		return nil, err
+	}
+	return handle.group.handle(object), nil
+}
+
+/*
+Dispose releases the remote object. Disposing a handle more than once, or after
+its group has been disposed, has no effect.
+*/
+func (handle *JSHandle) Dispose() {
+	if !handle.markDisposed() || "" == handle.object.ObjectID {
+		return
+	}
+	handle.group.mux.Lock()
+	delete(handle.group.handles, handle.object.ObjectID)
+	handle.group.mux.Unlock()
+
+	handle.group.tab.releaseObject(handle.object.ObjectID)
+}
+
+/*
+Disposed returns true if the remote object has been released.
+*/
+func (handle *JSHandle) Disposed() bool {
+	handle.mux.Lock()
+	defer handle.mux.Unlock()
+	return handle.disposed
+}
+
+/*
+GetProperties returns handles to the own enumerable properties of the object,
+keyed by property name.
+*/
+func (handle *JSHandle) GetProperties(ctx context.Context) (map[string]*JSHandle, error) {
+	if handle.Disposed() {
+		return nil, errs.New(0, "handle is disposed")
+	}
+	properties := make(map[string]*JSHandle)
+	if "" == handle.object.ObjectID {
+		return properties, nil
+	}
+
+	var result *runtime.GetPropertiesResult
+	resultChan := handle.group.tab.Runtime().GetProperties(&runtime.GetPropertiesParams{
+		ObjectID:      handle.object.ObjectID,
+		OwnProperties: true,
+	})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "Runtime.getProperties failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Runtime.getProperties failed")
+	}
+	if nil != result.ExceptionDetails {
+		return nil, &ExceptionError{Details: result.ExceptionDetails}
+	}
+
+	// Properties inherit the object group of the object they belong to.
+	for _, property := range result.Result {
+		if !property.Enumerable || nil == property.Value {
+			continue
+		}
+		properties[property.Name] = handle.group.handle(property.Value)
+	}
+	return properties, nil
+}
+
+/*
+GetProperty returns a handle to the named property of the object.
+*/
+func (handle *JSHandle) GetProperty(ctx context.Context, name string) (*JSHandle, error) {
+	return handle.CallFunction(ctx, "function (name) { return this[name] }", name)
+}
+
+/*
+Group returns the object group that owns the handle.
+*/
+func (handle *JSHandle) Group() *ObjectGroup {
+	return handle.group
+}
+
+/*
+Object returns the remote object referenced by the handle.
+*/
+func (handle *JSHandle) Object() *runtime.RemoteObject {
+	return handle.object
+}
+
+/*
+Value serializes the handle's value and unmarshals it into out with
+UnmarshalRemoteObject.
+*/
+func (handle *JSHandle) Value(ctx context.Context, out interface{}) error {
+	if "" == handle.object.ObjectID {
+		return UnmarshalRemoteObject(handle.object, out)
+	}
+	params, err := handle.callParams("function () { return this }", nil)
+	if nil != err {
+		return err
+	}
+	params.ReturnByValue = true
+	object, err := handle.group.tab.callFunctionOn(ctx, params)
+	if nil != err {
+		return err
+	}
+	return UnmarshalRemoteObject(object, out)
+}
+
+/*
+callParams returns the Runtime.callFunctionOn parameters for calling a function
+on the handle's value. Primitive values are passed as the first argument of a
+wrapper function that is called on the group's global object.
+*/
+func (handle *JSHandle) callParams(
+	functionDeclaration string,
+	args []interface{},
+) (*runtime.CallFunctionOnParams, error) {
+	if handle.Disposed() {
+		return nil, errs.New(0, "handle is disposed")
+	}
+	if "" != handle.object.ObjectID {
+		return &runtime.CallFunctionOnParams{
+			FunctionDeclaration: functionDeclaration,
+			ObjectID:            handle.object.ObjectID,
+			Arguments:           callArguments(args),
+			AwaitPromise:        true,
+		}, nil
+	}
+
+	if 0 == handle.group.contextID {
+		return nil, errs.New(0, "cannot call a function on a primitive value without an execution context")
+	}
+	return &runtime.CallFunctionOnParams{
+		FunctionDeclaration: fmt.Sprintf(
+			"function (value, ...args) { return (%s).apply(value, args) }",
+			functionDeclaration,
+		),
+		ExecutionContextID: handle.group.contextID,
+		Arguments:          append([]*runtime.CallArgument{NewCallArgument(handle.object)}, callArguments(args)...),
+		AwaitPromise:       true,
+	}, nil
+}
+
+/*
+markDisposed marks the handle as disposed. It returns false if the handle was
+already disposed.
+*/
+func (handle *JSHandle) markDisposed() bool {
+	handle.mux.Lock()
+	defer handle.mux.Unlock()
+	if handle.disposed {
+		return false
+	}
+	handle.disposed = true
+	return true
+}
@@ -0,0 +1,141 @@
+package chrome
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/runtime"
+)
+
+func TestTabNewObjectGroup(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabNewObjectGroup")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := tab.NewObjectGroup(ctx, 0); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestObjectGroupDispose(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestObjectGroupDispose")
+	group := newObjectGroup(tab, 1, "test")
+
+	object := group.handle(&runtime.RemoteObject{Type: runtime.ObjectType.Object, ObjectID: "1.1.1"})
+	primitive := group.handle(&runtime.RemoteObject{Type: runtime.ObjectType.Number, Value: 1.0})
+	if 1 != len(group.handles) {
+		t.Errorf("Expected 1 tracked handle, received %d", len(group.handles))
+	}
+
+	group.Dispose()
+	if !group.Disposed() {
+		t.Errorf("Expected the group to be disposed")
+	}
+	if !object.Disposed() {
+		t.Errorf("Expected the handle to be disposed")
+	}
+	select {
+	case <-group.Done():
+	default:
+		t.Errorf("Expected the done channel to be closed")
+	}
+	group.Dispose()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := group.Evaluate(ctx, "document"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if _, err := object.GetProperties(ctx); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if _, err := object.GetProperty(ctx, "length"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	var value int
+	if err := primitive.Value(ctx, &value); nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	if 1 != value {
+		t.Errorf("Expected 1, received %d", value)
+	}
+
+	late := group.handle(&runtime.RemoteObject{Type: runtime.ObjectType.Object, ObjectID: "1.1.2"})
+	if !late.Disposed() {
+		t.Errorf("Expected a handle created after disposal to be disposed")
+	}
+}
+
+func TestJSHandleDispose(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestJSHandleDispose")
+	group := newObjectGroup(tab, 1, "test")
+
+	handle := group.handle(&runtime.RemoteObject{Type: runtime.ObjectType.Object, ObjectID: "1.1.1"})
+	handle.Dispose()
+	if !handle.Disposed() {
+		t.Errorf("Expected the handle to be disposed")
+	}
+	if 0 != len(group.handles) {
+		t.Errorf("Expected 0 tracked handles, received %d", len(group.handles))
+	}
+	if group.Disposed() {
+		t.Errorf("Expected the group to remain active")
+	}
+
+	undefined := group.handle(nil)
+	if runtime.ObjectType.Undefined != undefined.Object().Type {
+		t.Errorf("Expected undefined, received %s", undefined.Object().Type)
+	}
+	undefined.Dispose()
+}
+
+func TestJSHandleCallParams(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestJSHandleCallParams")
+
+	primitive := newObjectGroup(tab, 0, "test").handle(&runtime.RemoteObject{Type: runtime.ObjectType.String, Value: "abc"})
+	if _, err := primitive.callParams("function () { return this.length }", nil); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	primitive = newObjectGroup(tab, 3, "test").handle(&runtime.RemoteObject{Type: runtime.ObjectType.String, Value: "abc"})
+	params, err := primitive.callParams("function (n) { return this.length + n }", []interface{}{1})
+	if nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	if 3 != params.ExecutionContextID {
+		t.Errorf("Expected context 3, received %d", params.ExecutionContextID)
+	}
+	if 2 != len(params.Arguments) || "abc" != params.Arguments[0].Value {
+		t.Errorf("Expected the value to be passed as the first argument, received %v", params.Arguments)
+	}
+}
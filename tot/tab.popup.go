@@ -0,0 +1,260 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	errs "github.com/bdlm/errors"
+	"github.com/bdlm/log"
+	"github.com/mkenney/go-chrome/tot/socket"
+	"github.com/mkenney/go-chrome/tot/target"
+)
+
+/*
+targetDiscoverer is implemented by browsers that can discover targets, such as
+Chrome.
+*/
+type targetDiscoverer interface {
+	DiscoverTargets(ctx context.Context) error
+}
+
+/*
+DiscoverTargets enables target discovery so pages opened by tabs are tracked as
+tabs. It is enabled by Launch and Connect, calling it again has no effect.
+
+Target discovery is enabled with Target.setDiscoverTargets on the browser
+connection. Pages opened while discovery is enabled, including pages opened by
+other clients, are added to the list of open tabs, and closed pages are removed
+from it, see WatchTabs. Popups, such as windows opened by window.open() or links
+with a target, are linked to their opener, see Tab.WaitForPopup.
+*/
+func (chrome *Chrome) DiscoverTargets(ctx context.Context) error {
+	chrome.mux.Lock()
+	if chrome.discovering {
+		chrome.mux.Unlock()
+		return nil
+	}
+	chrome.discovering = true
+	chrome.mux.Unlock()
+
+	browser, err := chrome.browserSocket()
+	if nil != err {
+		chrome.setDiscovering(false)
+		return errs.Wrap(err, 0, "browser connection failed")
+	}
+
+	handlers := []socket.EventHandler{
+		socket.NewEventHandler(
+			"Target.targetCreated",
+			func(response *socket.Response) {
+				event := &target.CreatedEvent{}
+				if err := json.Unmarshal([]byte(response.Params), event); nil != err || nil == event.Info {
+					return
+				}
+				// Connecting to the new target blocks, leave the socket loop.
+				go chrome.targetCreated(event.Info)
+			},
+		),
+		socket.NewEventHandler(
+			"Target.targetDestroyed",
+			func(response *socket.Response) {
+				event := &target.DestroyedEvent{}
+				if err := json.Unmarshal([]byte(response.Params), event); nil != err {
+					return
+				}
+				chrome.targetDestroyed(event.ID)
+			},
+		),
+		socket.NewEventHandler(
+			"Target.targetInfoChanged",
+			func(response *socket.Response) {
+				event := &target.InfoChangedEvent{}
+				if err := json.Unmarshal([]byte(response.Params), event); nil != err || nil == event.Info {
+					return
+				}
+				chrome.targetInfoChanged(event.Info)
+			},
+		),
+	}
+	for _, handler := range handlers {
+		browser.AddEventHandler(handler)
+	}
+	removeHandlers := func() {
+		for _, handler := range handlers {
+			browser.RemoveEventHandler(handler)
+		}
+	}
+
+	resultChan := browser.Target().SetDiscoverTargets(&target.SetDiscoverTargetsParams{
+		Discover: true,
+	})
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			removeHandlers()
+			chrome.setDiscovering(false)
+			return errs.Wrap(result.Err, 0, "Target.setDiscoverTargets failed")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		removeHandlers()
+		chrome.setDiscovering(false)
+		return errs.Wrap(ctx.Err(), 0, "Target.setDiscoverTargets failed")
+	}
+	return nil
+}
+
+/*
+setDiscovering records whether target discovery is enabled.
+*/
+func (chrome *Chrome) setDiscovering(discovering bool) {
+	chrome.mux.Lock()
+	defer chrome.mux.Unlock()
+	chrome.discovering = discovering
+}
+
+/*
+targetCreated wraps a new page as a Tab. Pages opened by another page are linked
+to their opener.
+*/
+func (chrome *Chrome) targetCreated(info *target.Info) {
+	if "page" != info.Type {
+		return
+	}
+	if _, ok := chrome.tabs.get(string(info.ID)); ok {
+		return
+	}
+
+	targetURL, err := url.Parse(info.URL)
+	if nil != err {
+		targetURL = &url.URL{}
+	}
+	data := chrome.targetData(info.ID, info.Type, info.URL)
+	data.Title = info.Title
+	tab, err := chrome.newTab(targetURL, data)
+	if nil != err {
+		log.WithFields(log.Fields{
+			"target": info.ID,
+			"opener": info.OpenerID,
+			"error":  err,
+		}).Warn("could not connect to target")
+		return
+	}
+	if "" == info.OpenerID {
+		return
+	}
+	if opener, ok := chrome.tabs.get(string(info.OpenerID)); ok {
+		opener.addPopup(tab)
+	}
+}
+
+/*
+targetDestroyed removes a closed page from the list of open tabs.
+*/
+func (chrome *Chrome) targetDestroyed(targetID target.ID) {
+	tab, ok := chrome.tabs.get(string(targetID))
+	if !ok || !chrome.tabs.remove(tab) {
+		return
+	}
+	tab.Socket().Stop()
+	if browserContext := tab.BrowserContext(); nil != browserContext {
+		browserContext.removeTab(tab)
+	}
+}
+
+/*
+targetInfoChanged updates the URL, title and type of an open tab.
+*/
+func (chrome *Chrome) targetInfoChanged(info *target.Info) {
+	tab, ok := chrome.tabs.get(string(info.ID))
+	if !ok {
+		return
+	}
+	chrome.tabs.update(tab, info.URL, info.Title, info.Type)
+}
+
+/*
+Opener returns the tab that opened this tab, or nil.
+*/
+func (tab *Tab) Opener() *Tab {
+	tab.mux.Lock()
+	defer tab.mux.Unlock()
+	return tab.opener
+}
+
+/*
+Popups returns the tabs opened by this tab in the order they were opened.
+Popups are only tracked while target discovery is enabled, see
+Chrome.DiscoverTargets.
+*/
+func (tab *Tab) Popups() []*Tab {
+	tab.mux.Lock()
+	defer tab.mux.Unlock()
+	popups := make([]*Tab, len(tab.popups))
+	copy(popups, tab.popups)
+	return popups
+}
+
+/*
+WaitForPopup waits for a page opened by this tab and returns it as a Tab. Each
+popup is returned once, in the order they were opened, so a popup opened
+before WaitForPopup was called is returned immediately. Target discovery is
+enabled if needed, an error is returned if the browser can't discover targets.
+
+	tab.WaitForPopup(ctx) // returns the login window opened by
+	                      // window.open("https://auth.example.com/")
+*/
+func (tab *Tab) WaitForPopup(ctx context.Context) (*Tab, error) {
+	discoverer, ok := tab.Chromium().(targetDiscoverer)
+	if !ok {
+		return nil, errs.New(0, "the browser doesn't support target discovery")
+	}
+	if err := discoverer.DiscoverTargets(ctx); nil != err {
+		return nil, err
+	}
+
+	for {
+		tab.mux.Lock()
+		if tab.popupsWaited < len(tab.popups) {
+			popup := tab.popups[tab.popupsWaited]
+			tab.popupsWaited++
+			tab.mux.Unlock()
+			return popup, nil
+		}
+		if nil == tab.popupChan {
+			tab.popupChan = make(chan struct{})
+		}
+		popupChan := tab.popupChan
+		tab.mux.Unlock()
+
+		select {
+		case <-popupChan:
+		case <-ctx.Done():
+			return nil, errs.Wrap(ctx.Err(), 0, "no popup was opened")
+		}
+	}
+}
+
+/*
+addPopup links a popup to this tab and its browser context and wakes
+WaitForPopup.
+*/
+func (tab *Tab) addPopup(popup *Tab) {
+	popup.mux.Lock()
+	popup.opener = tab
+	popup.mux.Unlock()
+
+	// Popups are opened in the browser context of their opener.
+	if browserContext := tab.BrowserContext(); nil != browserContext {
+		browserContext.addTab(popup)
+	}
+
+	tab.mux.Lock()
+	defer tab.mux.Unlock()
+	tab.popups = append(tab.popups, popup)
+	if nil != tab.popupChan {
+		close(tab.popupChan)
+		tab.popupChan = nil
+	}
+}
@@ -0,0 +1,89 @@
+package chrome
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/target"
+)
+
+func TestTabWaitForPopup(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabWaitForPopup")
+	first, _ := browser.NewTab("https://TestTabWaitForPopup/first")
+	second, _ := browser.NewTab("https://TestTabWaitForPopup/second")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := tab.WaitForPopup(ctx); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	tab.addPopup(first)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tab.addPopup(second)
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	popup, err := tab.WaitForPopup(ctx)
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if first != popup {
+		t.Errorf("Expected the first popup, received %s", popup.URL())
+	}
+	popup, err = tab.WaitForPopup(ctx)
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if second != popup {
+		t.Errorf("Expected the second popup, received %s", popup.URL())
+	}
+
+	if tab != second.Opener() {
+		t.Errorf("Expected the popup to be linked to its opener")
+	}
+	if nil != tab.Opener() {
+		t.Errorf("Expected no opener, received %s", tab.Opener().URL())
+	}
+	if popups := tab.Popups(); 2 != len(popups) {
+		t.Errorf("Expected 2 popups, received %d", len(popups))
+	}
+
+	// Browsers that can't discover targets are rejected.
+	tab.chrome = struct{ Chromium }{browser}
+	if _, err := tab.WaitForPopup(ctx); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestChromeTargetCreated(t *testing.T) {
+	chrome := New(&Flags{}, "", "", "", "")
+
+	// Pages are added once, other targets are ignored.
+	chrome.targetCreated(&target.Info{ID: "target-id", Type: "page", URL: "about:blank"})
+	chrome.targetCreated(&target.Info{ID: "target-id", Type: "page", URL: "about:blank"})
+	chrome.targetCreated(&target.Info{ID: "worker-id", Type: "service_worker", OpenerID: "target-id"})
+	if 1 != len(chrome.Tabs()) {
+		t.Fatalf("Expected 1 tab, received %d", len(chrome.Tabs()))
+	}
+	opener := chrome.Tabs()[0]
+
+	chrome.targetCreated(&target.Info{ID: "popup-id", Type: "page", URL: "about:blank", OpenerID: "target-id"})
+	popup, err := chrome.GetTab("popup-id")
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if opener != popup.(*Tab).Opener() {
+		t.Errorf("Expected the popup to be linked to its opener")
+	}
+}
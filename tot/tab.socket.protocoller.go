@@ -144,6 +144,13 @@ func (tab *Tab) Input() *socket.InputProtocol {
 	return tab.protocol.Input()
 }
 
+/*
+Inspector implements socket.Protocoller
+*/
+func (tab *Tab) Inspector() *socket.InspectorProtocol {
+	return tab.protocol.Inspector()
+}
+
 /*
 IO implements socket.Protocoller
 */
@@ -0,0 +1,540 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	errs "github.com/bdlm/errors"
+	storage "github.com/mkenney/go-chrome/tot/dom/storage"
+	"github.com/mkenney/go-chrome/tot/indexed/db"
+	"github.com/mkenney/go-chrome/tot/network"
+	"github.com/mkenney/go-chrome/tot/page"
+	"github.com/mkenney/go-chrome/tot/runtime"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+indexedDBPageSize is the number of IndexedDB records requested at a time.
+*/
+const indexedDBPageSize = 100
+
+/*
+restoreIndexedDBScript recreates IndexedDB databases and their records from
+IndexedDBDatabase values in the current origin.
+*/
+const restoreIndexedDBScript = `async function (databases) {
+	const keyPath = function (path) {
+		if (!path || "string" === path.type) {
+			return path ? path.string : undefined;
+		}
+		return "array" === path.type ? path.array : undefined;
+	};
+	for (const database of databases) {
+		await new Promise(function (resolve, reject) {
+			const request = indexedDB.open(database.name, database.version || undefined);
+			request.onerror = function () { reject(request.error); };
+			request.onupgradeneeded = function () {
+				const idb = request.result;
+				for (const store of database.objectStores || []) {
+					if (idb.objectStoreNames.contains(store.name)) {
+						continue;
+					}
+					const objectStore = idb.createObjectStore(store.name, {
+						keyPath: keyPath(store.keyPath),
+						autoIncrement: store.autoIncrement
+					});
+					for (const index of store.indexes || []) {
+						objectStore.createIndex(index.name, keyPath(index.keyPath), {
+							unique: index.unique,
+							multiEntry: index.multiEntry
+						});
+					}
+				}
+			};
+			request.onsuccess = function () {
+				const idb = request.result;
+				const stores = (database.objectStores || []).filter(function (store) {
+					return idb.objectStoreNames.contains(store.name);
+				});
+				if (0 === stores.length) {
+					idb.close();
+					resolve();
+					return;
+				}
+				const tx = idb.transaction(stores.map(function (store) { return store.name; }), "readwrite");
+				for (const store of stores) {
+					const objectStore = tx.objectStore(store.name);
+					for (const record of store.records || []) {
+						if (null === objectStore.keyPath && undefined !== record.key) {
+							objectStore.put(record.value, record.key);
+						} else {
+							objectStore.put(record.value);
+						}
+					}
+				}
+				tx.oncomplete = function () { idb.close(); resolve(); };
+				tx.onerror = function () { idb.close(); reject(tx.error); };
+			};
+		});
+	}
+}`
+
+/*
+StorageState is a snapshot of the cookies and per-origin storage of a tab. It
+is designed to be serialized as JSON and restored in another tab with
+RestoreStorageState.
+*/
+type StorageState struct {
+	// Cookies holds all browser cookies.
+	Cookies []*network.Cookie `json:"cookies"`
+
+	// Origins holds the storage of each origin.
+	Origins []*OriginStorage `json:"origins"`
+}
+
+/*
+OriginStorage holds the DOM storage and IndexedDB databases of an origin.
+*/
+type OriginStorage struct {
+	// Origin is the security origin, such as https://example.com.
+	Origin string `json:"origin"`
+
+	// LocalStorage holds the localStorage items.
+	LocalStorage map[string]string `json:"localStorage,omitempty"`
+
+	// SessionStorage holds the sessionStorage items.
+	SessionStorage map[string]string `json:"sessionStorage,omitempty"`
+
+	// IndexedDB holds the IndexedDB databases.
+	IndexedDB []*IndexedDBDatabase `json:"indexedDB,omitempty"`
+}
+
+/*
+empty returns true if the origin has no stored data.
+*/
+func (origin *OriginStorage) empty() bool {
+	return 0 == len(origin.LocalStorage) &&
+		0 == len(origin.SessionStorage) &&
+		0 == len(origin.IndexedDB)
+}
+
+/*
+IndexedDBDatabase is an IndexedDB database and its contents.
+*/
+type IndexedDBDatabase struct {
+	// Name is the database name.
+	Name string `json:"name"`
+
+	// Version is the database version.
+	Version int `json:"version"`
+
+	// ObjectStores holds the object stores of the database.
+	ObjectStores []*IndexedDBObjectStore `json:"objectStores"`
+}
+
+/*
+IndexedDBObjectStore is an IndexedDB object store and its records.
+*/
+type IndexedDBObjectStore struct {
+	// Name is the object store name.
+	Name string `json:"name"`
+
+	// KeyPath is the key path of stores with in-line keys.
+	KeyPath *db.KeyPath `json:"keyPath"`
+
+	// AutoIncrement is set if the store uses a key generator.
+	AutoIncrement bool `json:"autoIncrement"`
+
+	// Indexes holds the object store indexes.
+	Indexes []*db.ObjectStoreIndex `json:"indexes,omitempty"`
+
+	// Records holds the records in the object store.
+	Records []*IndexedDBRecord `json:"records"`
+}
+
+/*
+IndexedDBRecord is an IndexedDB record. Values are stored as JSON, so values
+that can't be represented in JSON, such as Blobs or Dates, aren't restored
+faithfully.
+*/
+type IndexedDBRecord struct {
+	// Key is the primary key of the record.
+	Key json.RawMessage `json:"key,omitempty"`
+
+	// Value is the record value.
+	Value json.RawMessage `json:"value"`
+}
+
+/*
+StorageState returns the cookies, localStorage, sessionStorage and IndexedDB
+contents of the tab. Storage is collected for the specified origins, or for
+the origins of all frames in the tab if none are specified.
+*/
+func (tab *Tab) StorageState(ctx context.Context, origins ...string) (*StorageState, error) {
+	var err error
+	if 0 == len(origins) {
+		origins, err = tab.frameOrigins(ctx)
+		if nil != err {
+			return nil, err
+		}
+	}
+
+	state := &StorageState{Origins: []*OriginStorage{}}
+	state.Cookies, err = tab.Cookies(ctx)
+	if nil != err {
+		return nil, err
+	}
+
+	enableChan := tab.IndexedDB().Enable()
+	select {
+	case result := <-enableChan:
+		if nil != result.Err {
+			return nil, errs.Wrap(result.Err, 0, "IndexedDB.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-enableChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "IndexedDB.enable failed")
+	}
+
+	for _, origin := range origins {
+		originStorage := &OriginStorage{Origin: origin}
+		originStorage.LocalStorage, err = tab.domStorageItems(ctx, origin, true)
+		if nil != err {
+			return nil, err
+		}
+		originStorage.SessionStorage, err = tab.domStorageItems(ctx, origin, false)
+		if nil != err {
+			return nil, err
+		}
+		originStorage.IndexedDB, err = tab.indexedDBDatabases(ctx, origin)
+		if nil != err {
+			return nil, err
+		}
+		if !originStorage.empty() {
+			state.Origins = append(state.Origins, originStorage)
+		}
+	}
+	return state, nil
+}
+
+/*
+RestoreStorageState restores a snapshot taken with StorageState, usually in a
+fresh tab. Cookies are set first, then the tab is navigated to each origin to
+restore its DOM storage and IndexedDB databases. The tab is left on the last
+restored origin and should be navigated afterwards.
+*/
+func (tab *Tab) RestoreStorageState(ctx context.Context, state *StorageState) error {
+	if 0 < len(state.Cookies) {
+		if err := tab.SetCookies(ctx, state.Cookies); nil != err {
+			return err
+		}
+	}
+
+	for _, origin := range state.Origins {
+		if origin.empty() {
+			continue
+		}
+		if err := tab.navigate(ctx, origin.Origin+"/"); nil != err {
+			return err
+		}
+		if err := tab.setDOMStorageItems(ctx, origin.Origin, true, origin.LocalStorage); nil != err {
+			return err
+		}
+		if err := tab.setDOMStorageItems(ctx, origin.Origin, false, origin.SessionStorage); nil != err {
+			return err
+		}
+		if 0 < len(origin.IndexedDB) {
+			if _, err := tab.CallFunction(ctx, restoreIndexedDBScript, origin.IndexedDB); nil != err {
+				return errs.Wrap(err, 0, fmt.Sprintf("could not restore IndexedDB for %s", origin.Origin))
+			}
+		}
+	}
+	return nil
+}
+
+/*
+domStorageItems returns the localStorage or sessionStorage items of an origin.
+*/
+func (tab *Tab) domStorageItems(ctx context.Context, origin string, local bool) (map[string]string, error) {
+	var result *storage.GetItemsResult
+	resultChan := tab.DOMStorage().GetItems(&storage.GetItemsParams{
+		StorageID: &storage.ID{SecurityOrigin: origin, IsLocalStorage: local},
+	})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "DOMStorage.getDOMStorageItems failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "DOMStorage.getDOMStorageItems failed")
+	}
+
+	items := make(map[string]string, len(result.Entries))
+	for _, entry := range result.Entries {
+		if 2 != len(entry) {
+			continue
+		}
+		key, _ := entry[0].(string)
+		value, _ := entry[1].(string)
+		items[key] = value
+	}
+	return items, nil
+}
+
+/*
+frameOrigins returns the security origins of all frames in the tab.
+*/
+func (tab *Tab) frameOrigins(ctx context.Context) ([]string, error) {
+	var result *page.GetFrameTreeResult
+	resultChan := tab.Page().GetFrameTree()
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "Page.getFrameTree failed")
+	}
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Page.getFrameTree failed")
+	}
+
+	seen := map[string]bool{}
+	var walk func(tree *page.FrameTree)
+	walk = func(tree *page.FrameTree) {
+		if nil == tree || nil == tree.Frame {
+			return
+		}
+		origin := tree.Frame.SecurityOrigin
+		// Opaque origins, such as about:blank and data: URLs, have no storage.
+		if "" != origin && "null" != origin && "://" != origin {
+			seen[origin] = true
+		}
+		for _, child := range tree.ChildFrames {
+			walk(child)
+		}
+	}
+	walk(result.FrameTree)
+
+	origins := make([]string, 0, len(seen))
+	for origin := range seen {
+		origins = append(origins, origin)
+	}
+	sort.Strings(origins)
+	return origins, nil
+}
+
+/*
+indexedDBDatabases returns the IndexedDB databases of an origin and their
+records.
+*/
+func (tab *Tab) indexedDBDatabases(ctx context.Context, origin string) ([]*IndexedDBDatabase, error) {
+	var namesResult *db.RequestDatabaseNamesResult
+	namesChan := tab.IndexedDB().RequestDatabaseNames(&db.RequestDatabaseNamesParams{
+		SecurityOrigin: origin,
+	})
+	select {
+	case namesResult = <-namesChan:
+	case <-ctx.Done():
+		go func() { <-namesChan }()
+		return nil, errs.Wrap(ctx.Err(), 0, "IndexedDB.requestDatabaseNames failed")
+	}
+	if nil != namesResult.Err {
+		return nil, errs.Wrap(namesResult.Err, 0, "IndexedDB.requestDatabaseNames failed")
+	}
+
+	databases := []*IndexedDBDatabase{}
+	for _, name := range namesResult.DatabaseNames {
+		var result *db.RequestDatabaseResult
+		resultChan := tab.IndexedDB().RequestDatabase(&db.RequestDatabaseParams{
+			SecurityOrigin: origin,
+			DatabaseName:   name,
+		})
+		select {
+		case result = <-resultChan:
+		case <-ctx.Done():
+			go func() { <-resultChan }()
+			return nil, errs.Wrap(ctx.Err(), 0, "IndexedDB.requestDatabase failed")
+		}
+		if nil != result.Err {
+			return nil, errs.Wrap(result.Err, 0, "IndexedDB.requestDatabase failed")
+		}
+
+		database := &IndexedDBDatabase{
+			Name:         result.DatabaseWithObjectStores.Name,
+			Version:      result.DatabaseWithObjectStores.Version,
+			ObjectStores: []*IndexedDBObjectStore{},
+		}
+		for _, objectStore := range result.DatabaseWithObjectStores.ObjectStores {
+			records, err := tab.indexedDBRecords(ctx, origin, name, objectStore)
+			if nil != err {
+				return nil, err
+			}
+			database.ObjectStores = append(database.ObjectStores, &IndexedDBObjectStore{
+				Name:          objectStore.Name,
+				KeyPath:       objectStore.KeyPath,
+				AutoIncrement: objectStore.AutoIncrement,
+				Indexes:       objectStore.Indexes,
+				Records:       records,
+			})
+		}
+		databases = append(databases, database)
+	}
+	return databases, nil
+}
+
+/*
+indexedDBRecords returns all records in an IndexedDB object store. Keys are only
+kept for stores with out-of-line keys.
+*/
+func (tab *Tab) indexedDBRecords(
+	ctx context.Context,
+	origin string,
+	database string,
+	objectStore *db.ObjectStore,
+) ([]*IndexedDBRecord, error) {
+	inlineKeys := nil != objectStore.KeyPath && db.KeyPathType.Null != objectStore.KeyPath.Type
+	records := []*IndexedDBRecord{}
+	for {
+		var result *db.RequestDataResult
+		resultChan := tab.IndexedDB().RequestData(&db.RequestDataParams{
+			SecurityOrigin:  origin,
+			DatabaseName:    database,
+			ObjectStoreName: objectStore.Name,
+			SkipCount:       len(records),
+			PageSize:        indexedDBPageSize,
+		})
+		select {
+		case result = <-resultChan:
+		case <-ctx.Done():
+			go func() { <-resultChan }()
+			return nil, errs.Wrap(ctx.Err(), 0, "IndexedDB.requestData failed")
+		}
+		if nil != result.Err {
+			return nil, errs.Wrap(result.Err, 0, "IndexedDB.requestData failed")
+		}
+
+		for _, entry := range result.ObjectStoreDataEntries {
+			record := &IndexedDBRecord{}
+			var err error
+			if record.Value, err = tab.remoteJSON(ctx, entry.Value); nil != err {
+				return nil, err
+			}
+			if !inlineKeys {
+				if record.Key, err = tab.remoteJSON(ctx, entry.PrimaryKey); nil != err {
+					return nil, err
+				}
+			}
+			records = append(records, record)
+		}
+		if !result.HasMore || 0 == len(result.ObjectStoreDataEntries) {
+			return records, nil
+		}
+	}
+}
+
+/*
+navigate navigates the tab and waits for the load event.
+*/
+func (tab *Tab) navigate(ctx context.Context, uri string) error {
+	loaded := make(chan struct{}, 1)
+	handler := socket.NewEventHandler(
+		"Page.loadEventFired",
+		func(response *socket.Response) {
+			select {
+			case loaded <- struct{}{}:
+			default:
+			}
+		},
+	)
+	tab.AddEventHandler(handler)
+	defer tab.RemoveEventHandler(handler)
+
+	enableChan := tab.Page().Enable()
+	select {
+	case result := <-enableChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "Page.enable failed")
+		}
+	case <-ctx.Done():
+		go func() { <-enableChan }()
+		return errs.Wrap(ctx.Err(), 0, "Page.enable failed")
+	}
+
+	var result *page.NavigateResult
+	resultChan := tab.Page().Navigate(&page.NavigateParams{URL: uri})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "navigation did not complete")
+	}
+	if nil != result.Err {
+		return errs.Wrap(result.Err, 0, "navigation failed")
+	}
+	if "" != result.ErrorText {
+		return errs.New(0, fmt.Sprintf("navigation to %s failed: %s", uri, result.ErrorText))
+	}
+
+	select {
+	case <-loaded:
+		return nil
+	case <-ctx.Done():
+		return errs.Wrap(ctx.Err(), 0, fmt.Sprintf("%s did not load", uri))
+	}
+}
+
+/*
+remoteJSON returns the JSON encoding of a remote object's value.
+*/
+func (tab *Tab) remoteJSON(ctx context.Context, object *runtime.RemoteObject) (json.RawMessage, error) {
+	if nil == object {
+		return nil, nil
+	}
+	if "" != object.ObjectID {
+		defer tab.releaseObject(object.ObjectID)
+		var err error
+		object, err = tab.callFunctionOn(ctx, &runtime.CallFunctionOnParams{
+			FunctionDeclaration: "function () { return this }",
+			ObjectID:            object.ObjectID,
+			ReturnByValue:       true,
+		})
+		if nil != err {
+			return nil, err
+		}
+	}
+
+	var value interface{}
+	if err := UnmarshalRemoteObject(object, &value); nil != err {
+		return nil, err
+	}
+	data, err := json.Marshal(value)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "could not encode storage value")
+	}
+	return data, nil
+}
+
+/*
+setDOMStorageItems sets localStorage or sessionStorage items of an origin.
+*/
+func (tab *Tab) setDOMStorageItems(ctx context.Context, origin string, local bool, items map[string]string) error {
+	for key, value := range items {
+		resultChan := tab.DOMStorage().SetItem(&storage.SetItemParams{
+			StorageID: &storage.ID{SecurityOrigin: origin, IsLocalStorage: local},
+			Key:       key,
+			Value:     value,
+		})
+		select {
+		case result := <-resultChan:
+			if nil != result.Err {
+				return errs.Wrap(result.Err, 0, "DOMStorage.setDOMStorageItem failed")
+			}
+		case <-ctx.Done():
+			go func() { <-resultChan }()
+			return errs.Wrap(ctx.Err(), 0, "DOMStorage.setDOMStorageItem failed")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/indexed/db"
+	"github.com/mkenney/go-chrome/tot/network"
+)
+
+func TestTabStorageState(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabStorageState")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := tab.StorageState(ctx); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+	if _, err := tab.StorageState(ctx, "https://example.com"); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestTabRestoreStorageState(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabRestoreStorageState")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tab.RestoreStorageState(ctx, &StorageState{}); nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	state := &StorageState{
+		Origins: []*OriginStorage{{
+			Origin:       "https://example.com",
+			LocalStorage: map[string]string{"key": "value"},
+		}},
+	}
+	if err := tab.RestoreStorageState(ctx, state); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestStorageStateJSON(t *testing.T) {
+	state := &StorageState{
+		Cookies: []*network.Cookie{{Name: "session", Value: "abc", Domain: "example.com", Path: "/"}},
+		Origins: []*OriginStorage{{
+			Origin:         "https://example.com",
+			LocalStorage:   map[string]string{"theme": "dark"},
+			SessionStorage: map[string]string{"step": "2"},
+			IndexedDB: []*IndexedDBDatabase{{
+				Name:    "app",
+				Version: 3,
+				ObjectStores: []*IndexedDBObjectStore{
+					{
+						Name:    "users",
+						KeyPath: &db.KeyPath{Type: db.KeyPathType.String, String: "id"},
+						Records: []*IndexedDBRecord{{Value: json.RawMessage(`{"id":1}`)}},
+					},
+					{
+						Name:          "log",
+						KeyPath:       &db.KeyPath{Type: db.KeyPathType.Null},
+						AutoIncrement: true,
+						Records:       []*IndexedDBRecord{{Key: json.RawMessage(`1`), Value: json.RawMessage(`"started"`)}},
+					},
+				},
+			}},
+		}},
+	}
+
+	data, err := json.Marshal(state)
+	if nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+
+	generic := map[string]interface{}{}
+	if err := json.Unmarshal(data, &generic); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	for _, key := range []string{"cookies", "origins"} {
+		if _, ok := generic[key]; !ok {
+			t.Errorf("Expected key '%s', received %s", key, data)
+		}
+	}
+
+	restored := &StorageState{}
+	if err := json.Unmarshal(data, restored); nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+	if 1 != len(restored.Cookies) || "session" != restored.Cookies[0].Name {
+		t.Errorf("Expected 1 session cookie, received %v", restored.Cookies)
+	}
+	origin := restored.Origins[0]
+	if "dark" != origin.LocalStorage["theme"] {
+		t.Errorf("Expected 'dark', received '%s'", origin.LocalStorage["theme"])
+	}
+	if "2" != origin.SessionStorage["step"] {
+		t.Errorf("Expected '2', received '%s'", origin.SessionStorage["step"])
+	}
+	stores := origin.IndexedDB[0].ObjectStores
+	if db.KeyPathType.String != stores[0].KeyPath.Type || "id" != stores[0].KeyPath.String {
+		t.Errorf("Expected key path 'id', received %v", stores[0].KeyPath)
+	}
+	if nil != stores[0].Records[0].Key {
+		t.Errorf("Expected no key, received %s", stores[0].Records[0].Key)
+	}
+	if "1" != string(stores[1].Records[0].Key) {
+		t.Errorf("Expected key 1, received %s", stores[1].Records[0].Key)
+	}
+	if `"started"` != string(stores[1].Records[0].Value) {
+		t.Errorf("Expected value \"started\", received %s", stores[1].Records[0].Value)
+	}
+}
+
+func TestOriginStorageEmpty(t *testing.T) {
+	origin := &OriginStorage{Origin: "https://example.com"}
+	if !origin.empty() {
+		t.Errorf("Expected empty origin storage")
+	}
+	origin.SessionStorage = map[string]string{"key": "value"}
+	if origin.empty() {
+		t.Errorf("Expected non-empty origin storage")
+	}
+}
@@ -1,12 +1,15 @@
 package chrome
 
 import (
+	"context"
 	"fmt"
 	"net/url"
+	"sync"
 
 	errs "github.com/bdlm/errors"
 	"github.com/bdlm/log"
 	"github.com/mkenney/go-chrome/tot/socket"
+	"github.com/mkenney/go-chrome/tot/target"
 )
 
 /*
@@ -23,43 +26,121 @@ func (chrome *Chrome) NewTab(uri string) (*Tab, error) {
 		return nil, errs.Wrap(err, 0, "invalid URL")
 	}
 
-	tab := &Tab{
-		chrome: chrome,
-		data:   &TabData{},
-		url:    targetURL,
+	// Errors are returned as is so an *HTTPError can be inspected.
+	data, err := chrome.DevTools().New(context.Background(), uri)
+	if nil != err {
+		return nil, err
 	}
 
-	_, err = tab.Chromium().Query(
-		fmt.Sprintf("/json/new?%s", url.QueryEscape(uri)),
-		url.Values{},
-		tab.data,
-	)
+	return chrome.newTab(targetURL, data)
+}
+
+/*
+NewBeginFrameTab spawns a new Tab whose BeginFrames are controlled via DevTools
+and returns a reference to it. Frames are only produced when requested with
+HeadlessExperimental.beginFrame, see NewBeginFrameController.
+
+Chromium must have been launched in headless mode with the
+'enable-begin-frame-control' flag.
+*/
+func (chrome *Chrome) NewBeginFrameTab(uri string, width, height int) (*Tab, error) {
+	if !chrome.Flags().Has("enable-begin-frame-control") {
+		return nil, errs.New(0, "chromium was not launched with the 'enable-begin-frame-control' flag")
+	}
+
+	if "" == uri {
+		uri = "about:blank"
+	}
+	targetURL, err := url.Parse(uri)
 	if nil != err {
-		return nil, errs.Wrap(err, 0, fmt.Sprintf("/new?%s query failed", url.QueryEscape(uri)))
+		return nil, errs.Wrap(err, 0, "invalid URL")
 	}
 
-	websocketURL, err := url.Parse(tab.Data().WebSocketDebuggerURL)
+	browser, err := chrome.browserSocket()
 	if nil != err {
-		return nil, errs.Wrap(err, 0, fmt.Sprintf("invalid websocket URL '%s'", tab.Data().WebSocketDebuggerURL))
+		return nil, errs.Wrap(err, 0, "browser connection failed")
+	}
+	result := <-browser.Target().CreateTarget(&target.CreateTargetParams{
+		URL:                     uri,
+		Width:                   width,
+		Height:                  height,
+		EnableBeginFrameControl: true,
+	})
+	if nil != result.Err {
+		return nil, errs.Wrap(result.Err, 0, "Target.createTarget failed")
 	}
 
-	socket := socket.New(websocketURL)
-	tab.socket = socket
-	tab.protocol = socket
-	chrome.tabs = append(chrome.tabs, tab)
+	return chrome.newTab(targetURL, chrome.targetData(result.ID, "page", uri))
+}
 
+/*
+newTab connects to the websocket of the target described by data and adds the
+resulting Tab to the list of open tabs. If the target is already open, its Tab
+is returned.
+*/
+func (chrome *Chrome) newTab(targetURL *url.URL, data *TabData) (*Tab, error) {
+	if tab, ok := chrome.tabs.get(data.ID); ok {
+		return tab, nil
+	}
+	websocketURL, err := url.Parse(data.WebSocketDebuggerURL)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, fmt.Sprintf("invalid websocket URL '%s'", data.WebSocketDebuggerURL))
+	}
+	chrome.secureSocketURL(websocketURL)
+
+	socket := socket.New(websocketURL)
+	tab := &Tab{
+		chrome:   chrome,
+		data:     data,
+		mux:      &sync.Mutex{},
+		protocol: socket,
+		socket:   socket,
+		url:      targetURL,
+	}
+	// The target may have been added concurrently, for example by target
+	// discovery while NewTab waits for Target.createTarget.
+	if registered := chrome.tabs.add(tab); registered != tab {
+		socket.Stop()
+		return registered, nil
+	}
 	return tab, nil
 }
 
+/*
+targetData returns the metadata for a target created with the Target domain
+rather than the /json/new endpoint.
+*/
+func (chrome *Chrome) targetData(targetID target.ID, targetType, uri string) *TabData {
+	return &TabData{
+		ID:   string(targetID),
+		Type: targetType,
+		URL:  uri,
+		WebSocketDebuggerURL: fmt.Sprintf(
+			"ws://%s:%d/devtools/page/%s",
+			chrome.Address(),
+			chrome.Port(),
+			targetID,
+		),
+	}
+}
+
 /*
 Tab is a struct representing an individual Chrome tab
 */
 type Tab struct {
-	chrome   Chromium
-	data     *TabData
-	protocol socket.Protocoller
-	socket   socket.Socketer
-	url      *url.URL
+	browserContext *BrowserContext
+	chrome         Chromium
+	data           *TabData
+	dialogs        *dialogManager
+	exposed        map[string]bool
+	mux            *sync.Mutex
+	opener         *Tab
+	popupChan      chan struct{}
+	popups         []*Tab
+	popupsWaited   int
+	protocol       socket.Protocoller
+	socket         socket.Socketer
+	url            *url.URL
 }
 
 /*
@@ -70,21 +151,52 @@ func (tab *Tab) Chromium() Chromium {
 }
 
 /*
-Close implements Tabber.
+devToolsBrowser is implemented by browsers with a DevTools HTTP client, such as
+Chrome.
+*/
+type devToolsBrowser interface {
+	DevTools() *DevToolsClient
+}
+
+/*
+Close implements Tabber. If a dialog policy is set, an open dialog such as a
+beforeunload prompt is accepted first.
 */
 func (tab *Tab) Close() (interface{}, error) {
 	var err error
 	var result interface{}
+	tab.mux.Lock()
+	browserContext := tab.browserContext
+	dialogs := tab.dialogs
+	tab.dialogs = nil
+	tab.mux.Unlock()
+	if nil != dialogs {
+		dialogs.close()
+	}
 	tab.Socket().Stop()
-	_, err = tab.Chromium().Query(fmt.Sprintf("/json/close/%s", tab.Data().ID), url.Values{}, &result)
-	if nil != err {
-		log.WithFields(log.Fields{
-			"result": result,
-			"error":  err,
-		}).Warn(err)
-		return nil, errs.Wrap(err, 0, fmt.Sprintf("close/%s query failed", tab.Data().ID))
+	if browser, ok := tab.Chromium().(devToolsBrowser); ok {
+		// Errors are returned as is so an *HTTPError can be inspected.
+		if err = browser.DevTools().Close(context.Background(), tab.Data().ID); nil != err {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Warn(err)
+			return nil, err
+		}
+	} else {
+		// Chromium responds with a plain text message.
+		result, err = tab.Chromium().Query(fmt.Sprintf("/json/close/%s", tab.Data().ID), url.Values{}, nil)
+		if nil != err {
+			log.WithFields(log.Fields{
+				"result": result,
+				"error":  err,
+			}).Warn(err)
+			return nil, errs.Wrap(err, 0, fmt.Sprintf("close/%s query failed", tab.Data().ID))
+		}
 	}
 	tab.Chromium().RemoveTab(tab)
+	if nil != browserContext {
+		browserContext.removeTab(tab)
+	}
 	return result, nil
 }
 
@@ -92,6 +204,8 @@ func (tab *Tab) Close() (interface{}, error) {
 Data implements Tabber.
 */
 func (tab *Tab) Data() *TabData {
+	tab.mux.Lock()
+	defer tab.mux.Unlock()
 	return tab.data
 }
 
@@ -109,6 +223,16 @@ func (tab *Tab) Socket() socket.Socketer {
 	return tab.socket
 }
 
+/*
+setData replaces the tab metadata. The previous value is left unchanged for
+callers still holding it.
+*/
+func (tab *Tab) setData(data *TabData) {
+	tab.mux.Lock()
+	defer tab.mux.Unlock()
+	tab.data = data
+}
+
 /*
 URL implements Tabber.
 */
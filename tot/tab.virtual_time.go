@@ -0,0 +1,114 @@
+package chrome
+
+import (
+	"context"
+
+	errs "github.com/bdlm/errors"
+	"github.com/mkenney/go-chrome/tot/emulation"
+	"github.com/mkenney/go-chrome/tot/page"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+/*
+AdvanceVirtualTime sets the virtual time policy for this tab and grants it a
+budget of virtual milliseconds. It blocks until Chromium reports that the budget
+has expired or the context is done.
+*/
+func (tab *Tab) AdvanceVirtualTime(
+	ctx context.Context,
+	policy emulation.VirtualTimePolicy,
+	budget int,
+) error {
+	expired := make(chan struct{}, 1)
+	handler := socket.NewEventHandler(
+		"Emulation.virtualTimeBudgetExpired",
+		func(response *socket.Response) {
+			select {
+			case expired <- struct{}{}:
+			default:
+			}
+		},
+	)
+	tab.AddEventHandler(handler)
+	defer tab.RemoveEventHandler(handler)
+
+	err := tab.setVirtualTimePolicy(ctx, &emulation.SetVirtualTimePolicyParams{
+		Policy: policy,
+		Budget: budget,
+	})
+	if nil != err {
+		return err
+	}
+
+	select {
+	case <-expired:
+		return nil
+	case <-ctx.Done():
+		return errs.Wrap(ctx.Err(), 0, "virtual time budget did not expire")
+	}
+}
+
+/*
+NavigateWithVirtualTime performs a deterministic navigation to the specified
+URL. Virtual time is paused before the navigation starts and is then advanced by
+budget virtual milliseconds under the pauseIfNetworkFetchesPending policy, so
+timers and animations only progress while no resource fetches are outstanding.
+
+NavigateWithVirtualTime blocks until the budget has expired, after which the
+rendered state of the page is reproducible across runs and can be captured with
+Page().CaptureScreenshot().
+*/
+func (tab *Tab) NavigateWithVirtualTime(
+	ctx context.Context,
+	uri string,
+	budget int,
+) error {
+	err := tab.setVirtualTimePolicy(ctx, &emulation.SetVirtualTimePolicyParams{
+		Policy: emulation.VirtualTimePolicyPause,
+	})
+	if nil != err {
+		return err
+	}
+
+	var result *page.NavigateResult
+	resultChan := tab.Page().Navigate(&page.NavigateParams{URL: uri})
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "navigation did not complete")
+	}
+	if nil != result.Err {
+		return errs.Wrap(result.Err, 0, "navigation failed")
+	}
+	if "" != result.ErrorText {
+		return errs.New(0, result.ErrorText)
+	}
+
+	return tab.AdvanceVirtualTime(
+		ctx,
+		emulation.VirtualTimePolicyPauseIfNetworkFetchesPending,
+		budget,
+	)
+}
+
+/*
+setVirtualTimePolicy sends an Emulation.setVirtualTimePolicy command and waits
+for the result.
+*/
+func (tab *Tab) setVirtualTimePolicy(
+	ctx context.Context,
+	params *emulation.SetVirtualTimePolicyParams,
+) error {
+	resultChan := tab.Emulation().SetVirtualTimePolicy(params)
+	select {
+	case result := <-resultChan:
+		if nil != result.Err {
+			return errs.Wrap(result.Err, 0, "could not set virtual time policy")
+		}
+	case <-ctx.Done():
+		go func() { <-resultChan }()
+		return errs.Wrap(ctx.Err(), 0, "could not set virtual time policy")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package chrome
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkenney/go-chrome/tot/emulation"
+	"github.com/mkenney/go-chrome/tot/socket"
+)
+
+func TestTabAdvanceVirtualTime(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabAdvanceVirtualTime")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := tab.AdvanceVirtualTime(ctx, emulation.VirtualTimePolicyAdvance, 1000)
+	if nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestTabNavigateWithVirtualTime(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabNavigateWithVirtualTime")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := tab.NavigateWithVirtualTime(ctx, "https://example.com", 1000)
+	if nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+}
+
+func TestTabAdvanceVirtualTimeParams(t *testing.T) {
+	browser := NewMock(
+		&Flags{},
+		"", //"path/to/chrome",
+		"", //"path/to/stderr",
+		"", //"path/to/stdout",
+		"", //"path/to/workdir",
+	)
+	tab, _ := browser.NewTab("https://TestTabAdvanceVirtualTimeParams")
+	mockSocket := tab.Socket().(*MockSocket)
+	mockSocket.SetResponder(func(command socket.Commander) *socket.Response {
+		return &socket.Response{ID: command.ID(), Result: []byte("{}")}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	errChan := make(chan error)
+	go func() {
+		errChan <- tab.AdvanceVirtualTime(ctx, emulation.VirtualTimePolicyPauseIfNetworkFetchesPending, 1500)
+	}()
+	for 0 == len(mockSocket.Commands("Emulation.setVirtualTimePolicy")) {
+		time.Sleep(time.Millisecond)
+	}
+	mockSocket.Emit("Emulation.virtualTimeBudgetExpired", struct{}{})
+	if err := <-errChan; nil != err {
+		t.Fatalf("Expected nil, received '%s'", err)
+	}
+
+	params := mockSocket.Commands("Emulation.setVirtualTimePolicy")[0].Params().(*emulation.SetVirtualTimePolicyParams)
+	if emulation.VirtualTimePolicyPauseIfNetworkFetchesPending != params.Policy {
+		t.Errorf("Expected '%s', received '%s'", emulation.VirtualTimePolicyPauseIfNetworkFetchesPending, params.Policy)
+	}
+	if 1500 != params.Budget {
+		t.Errorf("Expected 1500, received %d", params.Budget)
+	}
+}
@@ -66,6 +66,25 @@ type CloseTargetResult struct {
 	Err error `json:"-"`
 }
 
+/*
+CreateBrowserContextParams represents Target.createBrowserContext parameters.
+
+https://chromedevtools.github.io/devtools-protocol/tot/Target/#method-createBrowserContext
+*/
+type CreateBrowserContextParams struct {
+	// Optional. If specified, disposes this context when debugging session
+	// disconnects. EXPERIMENTAL.
+	DisposeOnDetach bool `json:"disposeOnDetach,omitempty"`
+
+	// Optional. Proxy server, similar to the one passed to --proxy-server.
+	// EXPERIMENTAL.
+	ProxyServer string `json:"proxyServer,omitempty"`
+
+	// Optional. Proxy bypass list, similar to the one passed to
+	// --proxy-bypass-list. EXPERIMENTAL.
+	ProxyBypassList string `json:"proxyBypassList,omitempty"`
+}
+
 /*
 CreateBrowserContextResult represents the result of calls to Target.createBrowserContext.
 
@@ -145,8 +164,8 @@ DisposeBrowserContextParams represents Target.disposeBrowserContext parameters.
 https://chromedevtools.github.io/devtools-protocol/tot/Target/#method-disposeBrowserContext
 */
 type DisposeBrowserContextParams struct {
-	// Target ID.
-	ID ID `json:"targetId"`
+	// The ID of the context to dispose.
+	BrowserContextID BrowserContextID `json:"browserContextId"`
 }
 
 /*
@@ -0,0 +1,431 @@
+/*
+Package visual compares screenshots captured with Page.captureScreenshot against
+stored baseline images for visual regression testing.
+
+Pixels are compared in the YIQ color space using a per-pixel threshold. Pixels
+that only differ because of anti-aliasing can be tolerated, and areas of the
+page that are expected to change (ads, timestamps, carets) can be excluded with
+ignore regions, which may be derived from DOM box models.
+*/
+package visual
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+
+	// Register the formats produced by Page.captureScreenshot.
+	_ "image/jpeg"
+
+	errs "github.com/bdlm/errors"
+	"github.com/mkenney/go-chrome/tot/dom"
+)
+
+/*
+DefaultThreshold is the per-pixel threshold used when Options.Threshold is not
+set.
+*/
+const DefaultThreshold = 0.1
+
+/*
+maxYIQDelta is the largest possible squared YIQ distance between two colors.
+*/
+const maxYIQDelta = 35215.0
+
+var (
+	diffColor        = color.NRGBA{R: 255, A: 255}
+	antiAliasedColor = color.NRGBA{R: 255, G: 255, A: 255}
+	ignoredColor     = color.NRGBA{B: 255, A: 255}
+)
+
+/*
+Options defines how two screenshots are compared.
+*/
+type Options struct {
+	// Optional. Threshold is the per-pixel color distance, from 0 to 1, above
+	// which two pixels are considered different. Defaults to DefaultThreshold.
+	Threshold float64
+
+	// Optional. TolerateAntiAliasing excludes pixels that are detected as
+	// anti-aliased from the mismatch count. They are highlighted separately in
+	// the diff image.
+	TolerateAntiAliasing bool
+
+	// Optional. IgnoreRegions lists areas of the screenshot that are excluded
+	// from the comparison. See BoxModelRegion.
+	IgnoreRegions []image.Rectangle
+
+	// Optional. Update replaces the stored baseline with the actual screenshot
+	// instead of failing when they differ. Intended to be wired to an -update
+	// test flag, golden-file style.
+	Update bool
+}
+
+/*
+Result contains the outcome of a comparison.
+*/
+type Result struct {
+	// Diff is an image of the comparison. Mismatched pixels are red,
+	// tolerated anti-aliased pixels are yellow, ignored regions are tinted blue
+	// and matching pixels are a faded copy of the baseline.
+	Diff *image.NRGBA
+
+	// AntiAliasedPixels is the number of pixels that differ only because of
+	// anti-aliasing.
+	AntiAliasedPixels int
+
+	// ComparedPixels is the number of pixels outside of the ignore regions.
+	ComparedPixels int
+
+	// MismatchedPixels is the number of compared pixels that differ.
+	MismatchedPixels int
+
+	// MismatchPercentage is MismatchedPixels as a percentage of ComparedPixels.
+	MismatchPercentage float64
+
+	// SizeMismatch is true when the baseline and the actual screenshot do not
+	// have the same dimensions.
+	SizeMismatch bool
+
+	// Updated is true when the baseline was written by this comparison.
+	Updated bool
+}
+
+/*
+Match returns true if no compared pixels differ.
+*/
+func (result *Result) Match() bool {
+	return 0 == result.MismatchedPixels && !result.SizeMismatch
+}
+
+/*
+WriteDiff encodes the diff image as a PNG file at the specified path.
+*/
+func (result *Result) WriteDiff(path string) error {
+	if nil == result.Diff {
+		return errs.New(0, "no diff image available")
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, result.Diff); nil != err {
+		return errs.Wrap(err, 0, "could not encode diff image")
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); nil != err {
+		return errs.Wrap(err, 0, fmt.Sprintf("could not write diff image '%s'", path))
+	}
+	return nil
+}
+
+/*
+BoxModelRegion returns the border box of a DOM box model, as returned by
+DOM.getBoxModel, for use as an ignore region. scale is the device scale factor
+of the screenshot, use 1 if no device metrics override is in place.
+
+An error is returned for a missing or empty box model, as reported for hidden
+elements, rather than an ignore region at the page origin.
+*/
+func BoxModelRegion(model *dom.BoxModel, scale float64) (image.Rectangle, error) {
+	if nil == model {
+		return image.Rectangle{}, errs.New(0, "no box model")
+	}
+	if 0 >= model.Width || 0 >= model.Height {
+		return image.Rectangle{}, errs.New(0, fmt.Sprintf("empty box model (%dx%d)", model.Width, model.Height))
+	}
+	if scale <= 0 {
+		scale = 1
+	}
+	x := int(math.Floor(model.Border[0] * scale))
+	y := int(math.Floor(model.Border[1] * scale))
+	return image.Rect(
+		x,
+		y,
+		x+int(math.Ceil(float64(model.Width)*scale)),
+		y+int(math.Ceil(float64(model.Height)*scale)),
+	), nil
+}
+
+/*
+Compare decodes and compares two encoded screenshots, such as the decoded Data
+of a Page.captureScreenshot result.
+*/
+func Compare(baseline, actual []byte, options *Options) (*Result, error) {
+	baselineImage, _, err := image.Decode(bytes.NewReader(baseline))
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "could not decode baseline image")
+	}
+	actualImage, _, err := image.Decode(bytes.NewReader(actual))
+	if nil != err {
+		return nil, errs.Wrap(err, 0, "could not decode actual image")
+	}
+	return CompareImages(baselineImage, actualImage, options), nil
+}
+
+/*
+CompareBaseline compares a screenshot with the baseline stored at path.
+
+If options.Update is set, the actual screenshot is written to path and the
+comparison is skipped. Otherwise a missing baseline is an error.
+*/
+func CompareBaseline(path string, actual []byte, options *Options) (*Result, error) {
+	if nil != options && options.Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+			return nil, errs.Wrap(err, 0, fmt.Sprintf("could not create baseline directory for '%s'", path))
+		}
+		if err := ioutil.WriteFile(path, actual, 0644); nil != err {
+			return nil, errs.Wrap(err, 0, fmt.Sprintf("could not write baseline '%s'", path))
+		}
+		return &Result{Updated: true}, nil
+	}
+
+	baseline, err := ioutil.ReadFile(path)
+	if nil != err {
+		return nil, errs.Wrap(err, 0, fmt.Sprintf("could not read baseline '%s'", path))
+	}
+	return Compare(baseline, actual, options)
+}
+
+/*
+CompareImages compares two images pixel by pixel. Images of different sizes are
+compared over the union of their bounds and pixels outside of either image are
+counted as mismatched.
+*/
+func CompareImages(baseline, actual image.Image, options *Options) *Result {
+	if nil == options {
+		options = &Options{}
+	}
+	threshold := options.Threshold
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	maxDelta := maxYIQDelta * threshold * threshold
+
+	img1 := normalize(baseline)
+	img2 := normalize(actual)
+	width := max(img1.Rect.Dx(), img2.Rect.Dx())
+	height := max(img1.Rect.Dy(), img2.Rect.Dy())
+
+	result := &Result{
+		Diff:         image.NewNRGBA(image.Rect(0, 0, width, height)),
+		SizeMismatch: img1.Rect.Size() != img2.Rect.Size(),
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			point := image.Pt(x, y)
+			if ignored(point, options.IgnoreRegions) {
+				result.Diff.SetNRGBA(x, y, blend(ignoredColor, fade(img1, point)))
+				continue
+			}
+			result.ComparedPixels++
+
+			if !point.In(img1.Rect) || !point.In(img2.Rect) {
+				result.MismatchedPixels++
+				result.Diff.SetNRGBA(x, y, diffColor)
+				continue
+			}
+
+			delta := colorDelta(img1.NRGBAAt(x, y), img2.NRGBAAt(x, y), false)
+			if math.Abs(delta) <= maxDelta {
+				result.Diff.SetNRGBA(x, y, fade(img1, point))
+				continue
+			}
+
+			if options.TolerateAntiAliasing &&
+				(antiAliased(img1, img2, x, y) || antiAliased(img2, img1, x, y)) {
+				result.AntiAliasedPixels++
+				result.Diff.SetNRGBA(x, y, antiAliasedColor)
+				continue
+			}
+
+			result.MismatchedPixels++
+			result.Diff.SetNRGBA(x, y, diffColor)
+		}
+	}
+
+	if result.ComparedPixels > 0 {
+		result.MismatchPercentage = float64(result.MismatchedPixels) /
+			float64(result.ComparedPixels) * 100
+	}
+	return result
+}
+
+/*
+antiAliased returns true if the pixel at x, y of img looks like an anti-aliased
+edge: it has both a darker and a lighter neighbor, each of which belongs to a
+solid area in both images.
+
+See "Anti-aliased Pixel and Intensity Slope Detector" by V. Vysniauskas, 2009.
+*/
+func antiAliased(img, other *image.NRGBA, x, y int) bool {
+	x0 := max(x-1, 0)
+	y0 := max(y-1, 0)
+	x2 := min(x+1, img.Rect.Dx()-1)
+	y2 := min(y+1, img.Rect.Dy()-1)
+
+	zeroes := 0
+	if x == x0 || x == x2 || y == y0 || y == y2 {
+		zeroes = 1
+	}
+	minDelta, maxDelta := 0.0, 0.0
+	var minX, minY, maxX, maxY int
+
+	center := img.NRGBAAt(x, y)
+	for adjX := x0; adjX <= x2; adjX++ {
+		for adjY := y0; adjY <= y2; adjY++ {
+			if adjX == x && adjY == y {
+				continue
+			}
+			delta := colorDelta(center, img.NRGBAAt(adjX, adjY), true)
+			if 0 == delta {
+				zeroes++
+				if zeroes > 2 {
+					return false
+				}
+			} else if delta < minDelta {
+				minDelta = delta
+				minX, minY = adjX, adjY
+			} else if delta > maxDelta {
+				maxDelta = delta
+				maxX, maxY = adjX, adjY
+			}
+		}
+	}
+
+	if 0 == minDelta || 0 == maxDelta {
+		return false
+	}
+
+	return (hasManySiblings(img, minX, minY) && hasManySiblings(other, minX, minY)) ||
+		(hasManySiblings(img, maxX, maxY) && hasManySiblings(other, maxX, maxY))
+}
+
+/*
+blend mixes a highlight color into a faded pixel.
+*/
+func blend(highlight, pixel color.NRGBA) color.NRGBA {
+	return color.NRGBA{
+		R: uint8((uint16(highlight.R) + uint16(pixel.R)) / 2),
+		G: uint8((uint16(highlight.G) + uint16(pixel.G)) / 2),
+		B: uint8((uint16(highlight.B) + uint16(pixel.B)) / 2),
+		A: 255,
+	}
+}
+
+/*
+colorDelta returns the squared YIQ distance between two colors, or the
+brightness difference only if yOnly is set. Semi-transparent colors are blended
+with white first.
+*/
+func colorDelta(c1, c2 color.NRGBA, yOnly bool) float64 {
+	r1, g1, b1 := blendWhite(c1)
+	r2, g2, b2 := blendWhite(c2)
+
+	y := rgb2y(r1, g1, b1) - rgb2y(r2, g2, b2)
+	if yOnly {
+		return y
+	}
+	i := rgb2i(r1, g1, b1) - rgb2i(r2, g2, b2)
+	q := rgb2q(r1, g1, b1) - rgb2q(r2, g2, b2)
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+func blendWhite(c color.NRGBA) (r, g, b float64) {
+	alpha := float64(c.A) / 255
+	r = 255 + (float64(c.R)-255)*alpha
+	g = 255 + (float64(c.G)-255)*alpha
+	b = 255 + (float64(c.B)-255)*alpha
+	return
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+/*
+fade returns a washed-out grayscale version of a pixel for the diff image.
+*/
+func fade(img *image.NRGBA, point image.Point) color.NRGBA {
+	if !point.In(img.Rect) {
+		return color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	r, g, b := blendWhite(img.NRGBAAt(point.X, point.Y))
+	gray := uint8(255 + (rgb2y(r, g, b)-255)*0.1)
+	return color.NRGBA{R: gray, G: gray, B: gray, A: 255}
+}
+
+/*
+hasManySiblings returns true if the pixel at x, y has more than two identical
+neighbors.
+*/
+func hasManySiblings(img *image.NRGBA, x, y int) bool {
+	if !image.Pt(x, y).In(img.Rect) {
+		return false
+	}
+	x0 := max(x-1, 0)
+	y0 := max(y-1, 0)
+	x2 := min(x+1, img.Rect.Dx()-1)
+	y2 := min(y+1, img.Rect.Dy()-1)
+
+	zeroes := 0
+	if x == x0 || x == x2 || y == y0 || y == y2 {
+		zeroes = 1
+	}
+
+	center := img.NRGBAAt(x, y)
+	for adjX := x0; adjX <= x2; adjX++ {
+		for adjY := y0; adjY <= y2; adjY++ {
+			if adjX == x && adjY == y {
+				continue
+			}
+			if center == img.NRGBAAt(adjX, adjY) {
+				zeroes++
+			}
+			if zeroes > 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+ignored returns true if the point is inside any of the regions.
+*/
+func ignored(point image.Point, regions []image.Rectangle) bool {
+	for _, region := range regions {
+		if point.In(region) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+normalize converts an image to NRGBA with its origin at 0, 0.
+*/
+func normalize(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	normalized := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(normalized, normalized.Rect, img, bounds.Min, draw.Src)
+	return normalized
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,173 @@
+package visual
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mkenney/go-chrome/tot/dom"
+)
+
+func newImage(width, height int, fill color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, fill)
+		}
+	}
+	return img
+}
+
+func encode(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); nil != err {
+		t.Fatalf("Expected nil, received error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+var white = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+var black = color.NRGBA{A: 255}
+
+func TestCompareImagesMatch(t *testing.T) {
+	result := CompareImages(newImage(10, 10, white), newImage(10, 10, white), nil)
+	if !result.Match() {
+		t.Errorf("Expected match, received %d mismatched pixels", result.MismatchedPixels)
+	}
+	if 100 != result.ComparedPixels {
+		t.Errorf("Expected 100, received %d", result.ComparedPixels)
+	}
+	if 0 != result.MismatchPercentage {
+		t.Errorf("Expected 0, received %f", result.MismatchPercentage)
+	}
+}
+
+func TestCompareImagesMismatch(t *testing.T) {
+	actual := newImage(10, 10, white)
+	actual.SetNRGBA(5, 5, black)
+	result := CompareImages(newImage(10, 10, white), actual, nil)
+	if 1 != result.MismatchedPixels {
+		t.Errorf("Expected 1, received %d", result.MismatchedPixels)
+	}
+	if 1 != result.MismatchPercentage {
+		t.Errorf("Expected 1, received %f", result.MismatchPercentage)
+	}
+	if diffColor != result.Diff.NRGBAAt(5, 5) {
+		t.Errorf("Expected %v, received %v", diffColor, result.Diff.NRGBAAt(5, 5))
+	}
+
+	// Below threshold.
+	actual.SetNRGBA(5, 5, color.NRGBA{R: 250, G: 250, B: 250, A: 255})
+	result = CompareImages(newImage(10, 10, white), actual, nil)
+	if !result.Match() {
+		t.Errorf("Expected match, received %d mismatched pixels", result.MismatchedPixels)
+	}
+}
+
+func TestCompareImagesIgnoreRegions(t *testing.T) {
+	actual := newImage(10, 10, white)
+	actual.SetNRGBA(5, 5, black)
+	result := CompareImages(newImage(10, 10, white), actual, &Options{
+		IgnoreRegions: []image.Rectangle{image.Rect(4, 4, 6, 6)},
+	})
+	if !result.Match() {
+		t.Errorf("Expected match, received %d mismatched pixels", result.MismatchedPixels)
+	}
+	if 96 != result.ComparedPixels {
+		t.Errorf("Expected 96, received %d", result.ComparedPixels)
+	}
+}
+
+func TestCompareImagesAntiAliasing(t *testing.T) {
+	// A hard black/white edge in the baseline, softened by a gray pixel in
+	// the actual image.
+	baseline := newImage(10, 10, white)
+	for y := 0; y < 10; y++ {
+		for x := 5; x < 10; x++ {
+			baseline.SetNRGBA(x, y, black)
+		}
+	}
+	actual := image.NewNRGBA(baseline.Rect)
+	copy(actual.Pix, baseline.Pix)
+	actual.SetNRGBA(5, 5, color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+
+	result := CompareImages(baseline, actual, nil)
+	if 1 != result.MismatchedPixels {
+		t.Errorf("Expected 1, received %d", result.MismatchedPixels)
+	}
+	result = CompareImages(baseline, actual, &Options{TolerateAntiAliasing: true})
+	if !result.Match() {
+		t.Errorf("Expected match, received %d mismatched pixels", result.MismatchedPixels)
+	}
+	if 1 != result.AntiAliasedPixels {
+		t.Errorf("Expected 1, received %d", result.AntiAliasedPixels)
+	}
+}
+
+func TestCompareImagesSizeMismatch(t *testing.T) {
+	result := CompareImages(newImage(10, 10, white), newImage(10, 5, white), nil)
+	if !result.SizeMismatch {
+		t.Errorf("Expected size mismatch, received none")
+	}
+	if 50 != result.MismatchedPixels {
+		t.Errorf("Expected 50, received %d", result.MismatchedPixels)
+	}
+}
+
+func TestCompareBaseline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "visual")
+	if nil != err {
+		t.Fatalf("Expected nil, received error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "baselines", "page.png")
+	actual := encode(t, newImage(10, 10, white))
+
+	if _, err = CompareBaseline(path, actual, nil); nil == err {
+		t.Errorf("Expected error, received nil")
+	}
+
+	result, err := CompareBaseline(path, actual, &Options{Update: true})
+	if nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	if !result.Updated {
+		t.Errorf("Expected baseline to be updated")
+	}
+
+	result, err = CompareBaseline(path, actual, nil)
+	if nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+	if !result.Match() {
+		t.Errorf("Expected match, received %d mismatched pixels", result.MismatchedPixels)
+	}
+	if err = result.WriteDiff(filepath.Join(dir, "diff.png")); nil != err {
+		t.Errorf("Expected nil, received error: %v", err)
+	}
+}
+
+func TestBoxModelRegion(t *testing.T) {
+	region, err := BoxModelRegion(&dom.BoxModel{
+		Border: dom.Quad{10, 20},
+		Width:  30,
+		Height: 40,
+	}, 2)
+	if nil != err {
+		t.Errorf("Expected nil, received '%s'", err)
+	}
+	if image.Rect(20, 40, 80, 120) != region {
+		t.Errorf("Expected %v, received %v", image.Rect(20, 40, 80, 120), region)
+	}
+
+	for _, model := range []*dom.BoxModel{nil, {}, {Border: dom.Quad{10, 20}, Width: 30}} {
+		if _, err := BoxModelRegion(model, 1); nil == err {
+			t.Errorf("Expected error, received nil")
+		}
+	}
+}